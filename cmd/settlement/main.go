@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
 	"github.com/ignacio/solara-settlement/internal/fxrate"
+	"github.com/ignacio/solara-settlement/internal/fxrate/providers"
 	"github.com/ignacio/solara-settlement/internal/processor"
 	"github.com/ignacio/solara-settlement/internal/reporter"
 	"github.com/ignacio/solara-settlement/internal/settlement"
+	"github.com/ignacio/solara-settlement/internal/stats"
+	"github.com/ignacio/solara-settlement/internal/store"
 	"github.com/shopspring/decimal"
 )
 
@@ -21,6 +28,16 @@ func main() {
 	outputPath := flag.String("output", "", "Path to output CSV file (required)")
 	startDateStr := flag.String("start-date", "", "Start date for filtering (YYYY-MM-DD format, optional)")
 	endDateStr := flag.String("end-date", "", "End date for filtering (YYYY-MM-DD format, optional)")
+	format := flag.String("format", "csv", "Output report format: csv, ofx, iso20022, ledger, or json-postings")
+	fxProviderFlag := flag.String("fx-provider", "mock", "FX rate source: mock, ecb, oxr, exchangeratehost, or chained (ecb falling back to mock)")
+	fxCacheDir := flag.String("fx-cache-dir", "", "Directory to persist FX rate lookups to on disk (optional; in-memory only if unset)")
+	statsOutputPath := flag.String("stats-output", "", "Path to write extended settlement statistics as JSON (optional)")
+	settlementCurrency := flag.String("settlement-currency", "usd", "Primary currency settlement totals are reported in (in addition to USD, which the engine always computes internally)")
+	var presentIn currencyListFlag
+	flag.Var(&presentIn, "present-in", "Additional currency to present settlement totals in, e.g. BRL (repeatable; only honored by --format csv)")
+	persist := flag.Bool("persist", false, "Save calculated settlements and their transactions to the database configured by --store-driver/--store-dsn")
+	storeDriver := flag.String("store-driver", "sqlite", "Database driver for --persist: sqlite or postgres")
+	storeDSN := flag.String("store-dsn", "", "Data source for --store-driver: a file path for sqlite, or a connection string for postgres (required with --persist)")
 	flag.Parse()
 
 	// Validate flags
@@ -36,6 +53,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *persist && *storeDSN == "" {
+		fmt.Fprintln(os.Stderr, "Error: --store-dsn is required when --persist is set")
+		flag.Usage()
+		os.Exit(1)
+	}
+
 	// Parse date flags
 	startDate, err := parseDateFlag(*startDateStr)
 	if err != nil {
@@ -51,26 +74,189 @@ func main() {
 		os.Exit(1)
 	}
 
+	reportWriter, err := writerForFormat(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fxProvider, err := fxProviderForFlag(*fxProviderFlag, *fxCacheDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	presentationCurrencies, err := resolvePresentationCurrencies(*settlementCurrency, presentIn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if csvWriter, ok := reportWriter.(*reporter.CSVWriter); ok {
+		csvWriter.PresentationCurrencies = presentationCurrencies
+	} else if len(presentationCurrencies) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: --settlement-currency/--present-in are only honored by --format csv; --format %s will not include them\n", *format)
+	}
+
+	// Open (and migrate) the persistence store as the last validation step,
+	// so a bad --store-driver/--store-dsn still fails fast, before reading
+	// and processing the whole input file, while minimizing how much of
+	// main runs with it open - os.Exit below (including inside
+	// runSettlement's error path) still skips the deferred Close, same as
+	// every other os.Exit(1) in main short-circuiting Go's normal deferred
+	// cleanup; the OS reclaims the connection on process exit either way.
+	var settlementStore *store.Store
+	if *persist {
+		settlementStore, err = storeForFlags(*storeDriver, *storeDSN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer settlementStore.Close()
+	}
+
 	// Run the settlement process
-	if err := runSettlement(*inputPath, *outputPath, startDate, endDate); err != nil {
+	if err := runSettlement(*inputPath, *outputPath, startDate, endDate, reportWriter, fxProvider, *statsOutputPath, presentationCurrencies, settlementStore); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runSettlement(inputPath, outputPath string, startDate, endDate time.Time) error {
+// currencyListFlag accumulates repeated occurrences of a flag into a slice of
+// domain.Currency, e.g. --present-in EUR --present-in BRL.
+type currencyListFlag []domain.Currency
+
+func (f *currencyListFlag) String() string {
+	codes := make([]string, len(*f))
+	for i, c := range *f {
+		codes[i] = c.String()
+	}
+	return strings.Join(codes, ",")
+}
+
+func (f *currencyListFlag) Set(value string) error {
+	currency := domain.Currency(strings.ToUpper(strings.TrimSpace(value)))
+	if err := currency.Validate(); err != nil {
+		return err
+	}
+	*f = append(*f, currency)
+	return nil
+}
+
+// resolvePresentationCurrencies combines --settlement-currency (when it's
+// not USD, which the engine always computes internally) with every
+// --present-in currency into the deduplicated list of additional currencies
+// to present settlement totals in.
+func resolvePresentationCurrencies(settlementCurrency string, presentIn []domain.Currency) ([]domain.Currency, error) {
+	primary := domain.Currency(strings.ToUpper(strings.TrimSpace(settlementCurrency)))
+	if err := primary.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid --settlement-currency: %w", err)
+	}
+
+	seen := make(map[domain.Currency]bool)
+	var resolved []domain.Currency
+
+	for _, currency := range append([]domain.Currency{primary}, presentIn...) {
+		if currency == domain.USD || seen[currency] {
+			continue
+		}
+		seen[currency] = true
+		resolved = append(resolved, currency)
+	}
+
+	return resolved, nil
+}
+
+// fxProviderForFlag resolves the --fx-provider flag to an fxrate.Provider.
+// When cacheDir is non-empty, the resolved provider is wrapped in a
+// fxrate.CachingProvider that also persists lookups to a JSON file under
+// cacheDir, so repeated runs against the same dates don't re-hit the
+// upstream API.
+func fxProviderForFlag(name, cacheDir string) (fxrate.Provider, error) {
+	var provider fxrate.Provider
+
+	switch name {
+	case "mock":
+		provider = fxrate.NewMockProvider()
+	case "ecb":
+		ecb := providers.NewECBProvider(http.DefaultClient, providers.DefaultECBFeedURL)
+		provider = fxrate.NewRetryingProvider(ecb, fxrate.DefaultRetryAttempts, fxrate.DefaultRetryBaseDelay)
+	case "oxr":
+		appID := os.Getenv("OXR_APP_ID")
+		if appID == "" {
+			return nil, fmt.Errorf("--fx-provider=oxr requires the OXR_APP_ID environment variable")
+		}
+		oxr := providers.NewOpenExchangeRatesProvider(http.DefaultClient, appID)
+		provider = fxrate.NewRetryingProvider(oxr, fxrate.DefaultRetryAttempts, fxrate.DefaultRetryBaseDelay)
+	case "exchangeratehost":
+		erh := providers.NewExchangerateHostProvider(http.DefaultClient)
+		provider = fxrate.NewRetryingProvider(erh, fxrate.DefaultRetryAttempts, fxrate.DefaultRetryBaseDelay)
+	case "chained":
+		ecb := providers.NewECBProvider(http.DefaultClient, providers.DefaultECBFeedURL)
+		retryingECB := fxrate.NewRetryingProvider(ecb, fxrate.DefaultRetryAttempts, fxrate.DefaultRetryBaseDelay)
+		provider = fxrate.NewChainProvider(retryingECB, fxrate.NewMockProvider())
+	default:
+		return nil, fmt.Errorf("unsupported --fx-provider %q (expected mock, ecb, oxr, exchangeratehost, or chained)", name)
+	}
+
+	if cacheDir == "" {
+		return provider, nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create --fx-cache-dir %s: %w", cacheDir, err)
+	}
+
+	cachePath := filepath.Join(cacheDir, "fx-rate-cache.json")
+	return fxrate.NewDiskCachingProvider(provider, fxrate.DefaultCacheTTL, fxrate.DefaultCacheSize, cachePath)
+}
+
+// writerForFormat resolves the --format flag to a reporter.Writer.
+func writerForFormat(format string) (reporter.Writer, error) {
+	switch format {
+	case "csv":
+		return reporter.NewCSVWriter(), nil
+	case "ofx":
+		return reporter.NewOFXWriter(), nil
+	case "iso20022":
+		return reporter.NewISO20022Writer(), nil
+	case "ledger":
+		return reporter.NewLedgerWriter(reporter.LedgerFormatText), nil
+	case "json-postings":
+		return reporter.NewLedgerWriter(reporter.LedgerFormatJSONPostings), nil
+	default:
+		return nil, fmt.Errorf("unsupported --format %q (expected csv, ofx, iso20022, ledger, or json-postings)", format)
+	}
+}
+
+// storeForFlags resolves --store-driver/--store-dsn to a store.Store,
+// opening (and migrating) the underlying database connection.
+func storeForFlags(driver, dsn string) (*store.Store, error) {
+	switch driver {
+	case "sqlite":
+		return store.OpenSQLite(dsn)
+	case "postgres":
+		return store.OpenPostgres(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported --store-driver %q (expected sqlite or postgres)", driver)
+	}
+}
+
+func runSettlement(inputPath, outputPath string, startDate, endDate time.Time, reportWriter reporter.Writer, fxProvider fxrate.Provider, statsOutputPath string, presentationCurrencies []domain.Currency, settlementStore *store.Store) error {
 	fmt.Printf("Reading transactions from: %s\n", inputPath)
 
 	// Initialize components
-	csvReader := processor.NewCSVReader()
+	reader, err := processor.ReaderForFile(inputPath)
+	if err != nil {
+		return err
+	}
 	validator := processor.NewValidator()
-	fxProvider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(fxProvider)
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 	engine := settlement.NewEngine(fxService)
-	csvWriter := reporter.NewCSVWriter()
+	engine.PresentationCurrencies = presentationCurrencies
 
-	// Step 1: Read transactions from CSV
-	transactions, err := csvReader.ReadFile(inputPath)
+	// Step 1: Read transactions
+	transactions, err := reader.ReadFile(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to read input file: %w", err)
 	}
@@ -100,20 +286,114 @@ func runSettlement(inputPath, outputPath string, startDate, endDate time.Time) e
 
 	fmt.Printf("Calculated settlements for %d suppliers\n", len(settlements))
 
+	// Step 3.5: Compute extended statistics and fold their FX volatility
+	// signal into each settlement's VolatilityFlag alongside the
+	// auth/capture comparison the engine already ran.
+	analyzer := stats.NewAnalyzer()
+	settlementStats := analyzer.Analyze(settlements)
+	applyCVVolatilityFlags(settlements, settlementStats, analyzer.VolatilityCVThreshold)
+
+	if statsOutputPath != "" {
+		if err := writeStatsJSON(statsOutputPath, settlementStats); err != nil {
+			return fmt.Errorf("failed to write stats output file: %w", err)
+		}
+		fmt.Printf("Statistics written to: %s\n", statsOutputPath)
+	}
+
+	// Step 3.6: Persist settlements, keyed by --end-date (or, when unset,
+	// the latest transaction timestamp in this run), so re-running the same
+	// input/date-range later overwrites the prior rows instead of
+	// accumulating a new one under today's wall-clock date each time. Note
+	// this key is the settlement date alone, not the full --start-date/
+	// --end-date range: two runs covering different ranges that share the
+	// same end date (e.g. a full month, then a partial week ending on the
+	// same day) will still overwrite each other's rows.
+	if settlementStore != nil {
+		settlementDate := endDate
+		if settlementDate.IsZero() {
+			settlementDate = latestTransactionDate(transactions)
+		}
+		if settlementDate.IsZero() {
+			return fmt.Errorf("cannot persist settlements: no --end-date was given and no transaction in this run has a timestamp to derive one from")
+		}
+
+		if err := settlementStore.SaveSettlements(context.Background(), settlementDate, settlements); err != nil {
+			return fmt.Errorf("failed to persist settlements: %w", err)
+		}
+		fmt.Println("Settlements persisted")
+	}
+
 	// Step 4: Generate report
-	if err := csvWriter.WriteFile(outputPath, settlements); err != nil {
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	if err := reportWriter.Write(outputFile, settlements); err != nil {
 		return fmt.Errorf("failed to write output file: %w", err)
 	}
 
 	fmt.Printf("Report written to: %s\n", outputPath)
 
 	// Print summary statistics
-	printSummary(settlements)
+	printSummary(settlements, settlementStats)
 
 	return nil
 }
 
-func printSummary(settlements []*domain.SupplierSettlement) {
+// applyCVVolatilityFlags flags a settlement's VolatilityFlag (and appends
+// settlement.AnomalyFXRateCV) when any currency the supplier transacted in
+// has a run-wide daily FX rate coefficient of variation above threshold, in
+// addition to whatever the engine's own auth/capture comparison already
+// found. This is a distinct anomaly from settlement.AnomalyVolatility, which
+// measures a single supplier's own auth-vs-capture FX variance rather than
+// currency-wide rate fluctuation across the whole run.
+func applyCVVolatilityFlags(settlements []*domain.SupplierSettlement, settlementStats *domain.SettlementStats, threshold decimal.Decimal) {
+	for _, s := range settlements {
+		volatileCurrency := false
+		for _, line := range s.Lines {
+			if cv, ok := settlementStats.FXVolatilityCV[line.Transaction.Currency]; ok && cv.GreaterThan(threshold) {
+				volatileCurrency = true
+				break
+			}
+		}
+
+		if !volatileCurrency {
+			continue
+		}
+
+		s.VolatilityFlag = true
+		if !containsString(s.Warnings, settlement.AnomalyFXRateCV) {
+			s.Warnings = append(s.Warnings, settlement.AnomalyFXRateCV)
+		}
+	}
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// writeStatsJSON writes settlementStats to a JSON file at path, creating or
+// overwriting it as needed.
+func writeStatsJSON(path string, settlementStats *domain.SettlementStats) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(settlementStats)
+}
+
+func printSummary(settlements []*domain.SupplierSettlement, settlementStats *domain.SettlementStats) {
 	fmt.Println("\n=== Settlement Summary ===")
 	fmt.Printf("Total Suppliers: %d\n", len(settlements))
 
@@ -141,6 +421,34 @@ func printSummary(settlements []*domain.SupplierSettlement) {
 
 	// Print warnings summary
 	printWarningsSummary(settlements)
+
+	// Print extended statistics
+	printStatsSummary(settlementStats)
+}
+
+// printStatsSummary prints the run-level extended statistics computed by
+// stats.Analyzer: concentration, refund health, FX volatility, and each
+// supplier's drawdown and Sharpe-like ratio.
+func printStatsSummary(settlementStats *domain.SettlementStats) {
+	fmt.Println("\n=== Extended Statistics ===")
+	fmt.Printf("Concentration Index (HHI): %s\n", settlementStats.ConcentrationIndex.StringFixed(4))
+	fmt.Printf("Refund-to-Capture Ratio: %s\n", settlementStats.RefundToCaptureRatio.StringFixed(4))
+
+	if len(settlementStats.FXVolatilityCV) > 0 {
+		fmt.Println("FX Volatility (coefficient of variation):")
+		for currency, cv := range settlementStats.FXVolatilityCV {
+			fmt.Printf("  %s: %s\n", currency, cv.StringFixed(4))
+		}
+	}
+
+	fmt.Println("Per-Supplier Risk:")
+	for _, s := range settlementStats.PerSupplier {
+		fmt.Printf("  %s: max drawdown $%s, Sharpe %s\n",
+			s.SupplierID,
+			s.MaxDrawdownUSD.StringFixed(2),
+			s.SharpeRatio.StringFixed(4),
+		)
+	}
 }
 
 // parseDateFlag parses a date string in YYYY-MM-DD format
@@ -180,6 +488,21 @@ func filterByDateRange(transactions []*domain.Transaction, startDate, endDate ti
 	return filtered
 }
 
+// latestTransactionDate returns the latest Timestamp among transactions, the
+// stable (input-derived, not wall-clock) default SaveSettlements upserts on
+// when --end-date wasn't given, so re-running the same input later
+// overwrites the prior persisted rows rather than adding new ones keyed by
+// today's date. Zero if transactions is empty.
+func latestTransactionDate(transactions []*domain.Transaction) time.Time {
+	var latest time.Time
+	for _, tx := range transactions {
+		if tx.Timestamp.After(latest) {
+			latest = tx.Timestamp
+		}
+	}
+	return latest
+}
+
 // formatDate formats a time.Time for display, handling zero times
 func formatDate(t time.Time) string {
 	if t.IsZero() {
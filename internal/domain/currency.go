@@ -10,7 +10,10 @@
 // floating-point arithmetic errors in calculations.
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 // Currency represents a supported currency code
 type Currency string
@@ -23,14 +26,79 @@ const (
 	USD Currency = "USD" // US Dollar
 )
 
-// Validate checks if the currency is supported
+// CurrencyInfo describes a registered currency's ISO 4217 properties: its
+// minor-unit exponent (the number of fractional digits, e.g. 2 for USD, 0
+// for JPY, 4 for CLF), its display symbol, and whether it's one of the
+// engine's reporting currencies (settlement totals are expressed in USD
+// today, so only USD sets this).
+type CurrencyInfo struct {
+	Code      Currency
+	Exponent  int32
+	Symbol    string
+	Reporting bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Currency]CurrencyInfo{}
+)
+
+func init() {
+	RegisterCurrency(CurrencyInfo{Code: ARS, Exponent: 2, Symbol: "$", Reporting: false})
+	RegisterCurrency(CurrencyInfo{Code: BRL, Exponent: 2, Symbol: "R$", Reporting: false})
+	RegisterCurrency(CurrencyInfo{Code: COP, Exponent: 2, Symbol: "$", Reporting: false})
+	RegisterCurrency(CurrencyInfo{Code: MXN, Exponent: 2, Symbol: "$", Reporting: false})
+	RegisterCurrency(CurrencyInfo{Code: USD, Exponent: 2, Symbol: "$", Reporting: true})
+}
+
+// RegisterCurrency adds or replaces a currency in the registry. This lets
+// callers support new LATAM markets (or any ISO 4217 currency) at startup
+// without a recompile; it should be called before any transaction
+// referencing the currency is processed.
+func RegisterCurrency(info CurrencyInfo) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[info.Code] = info
+}
+
+func lookupCurrency(c Currency) (CurrencyInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	info, ok := registry[c]
+	return info, ok
+}
+
+// Validate checks if the currency is registered
 func (c Currency) Validate() error {
-	switch c {
-	case ARS, BRL, COP, MXN, USD:
-		return nil
-	default:
+	if _, ok := lookupCurrency(c); !ok {
 		return fmt.Errorf("unsupported currency: %s", c)
 	}
+	return nil
+}
+
+// Exponent returns the number of fractional digits this currency's minor
+// unit has (e.g. 2 for USD, 0 for JPY), or -1 if the currency isn't registered.
+func (c Currency) Exponent() int32 {
+	info, ok := lookupCurrency(c)
+	if !ok {
+		return -1
+	}
+	return info.Exponent
+}
+
+// Symbol returns the currency's display symbol, or its raw code if it isn't registered.
+func (c Currency) Symbol() string {
+	if info, ok := lookupCurrency(c); ok {
+		return info.Symbol
+	}
+	return string(c)
+}
+
+// IsReporting returns true if this is one of the engine's reporting
+// currencies. Settlement totals are always expressed in USD today.
+func (c Currency) IsReporting() bool {
+	info, _ := lookupCurrency(c)
+	return info.Reporting
 }
 
 // String returns the string representation of the currency
@@ -38,3 +38,21 @@ func TestCurrency_String(t *testing.T) {
 	assert.Equal(t, "ARS", ARS.String())
 	assert.Equal(t, "USD", USD.String())
 }
+
+func TestCurrency_Exponent(t *testing.T) {
+	assert.Equal(t, int32(2), USD.Exponent())
+	assert.Equal(t, int32(-1), Currency("EUR").Exponent(), "unregistered currency has no exponent")
+}
+
+func TestCurrency_Symbol(t *testing.T) {
+	assert.Equal(t, "R$", BRL.Symbol())
+	assert.Equal(t, "EUR", Currency("EUR").Symbol(), "unregistered currency falls back to its code")
+}
+
+func TestRegisterCurrency_AllowsNewMarkets(t *testing.T) {
+	clp := Currency("CLP")
+	RegisterCurrency(CurrencyInfo{Code: clp, Exponent: 0, Symbol: "$", Reporting: false})
+
+	assert.NoError(t, clp.Validate())
+	assert.Equal(t, int32(0), clp.Exponent())
+}
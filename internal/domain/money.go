@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Money pairs a decimal amount with its currency and keeps the amount
+// rounded to that currency's minor-unit precision (e.g. 2 places for USD,
+// 0 for JPY) on construction and after every arithmetic operation. Rounding
+// uses banker's rounding (round-half-to-even) so repeated settlement
+// calculations don't accumulate a systematic bias.
+type Money struct {
+	amount   decimal.Decimal
+	currency Currency
+}
+
+// NewMoney creates a Money value, validating the currency and rounding the
+// amount to its registered minor-unit precision.
+func NewMoney(amount decimal.Decimal, currency Currency) (Money, error) {
+	if err := currency.Validate(); err != nil {
+		return Money{}, err
+	}
+	return Money{amount: amount.RoundBank(currency.Exponent()), currency: currency}, nil
+}
+
+// Amount returns the rounded decimal amount.
+func (m Money) Amount() decimal.Decimal {
+	return m.amount
+}
+
+// Currency returns the currency the amount is denominated in.
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// Add returns m + other, rounded to the currency's precision. Both values
+// must share the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, fmt.Errorf("cannot add %s to %s", other.currency, m.currency)
+	}
+	return NewMoney(m.amount.Add(other.amount), m.currency)
+}
+
+// Sub returns m - other, rounded to the currency's precision. Both values
+// must share the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, fmt.Errorf("cannot subtract %s from %s", other.currency, m.currency)
+	}
+	return NewMoney(m.amount.Sub(other.amount), m.currency)
+}
+
+// String renders the amount with its currency's symbol and minor-unit precision.
+func (m Money) String() string {
+	return fmt.Sprintf("%s%s", m.currency.Symbol(), m.amount.StringFixed(m.currency.Exponent()))
+}
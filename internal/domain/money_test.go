@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMoney_RoundsToMinorUnitPrecision(t *testing.T) {
+	m, err := NewMoney(decimal.NewFromFloat(100.565), USD)
+
+	require.NoError(t, err)
+	assert.True(t, m.Amount().Equal(decimal.NewFromFloat(100.56)), "100.565 should round half-to-even to 100.56")
+}
+
+func TestNewMoney_RejectsUnregisteredCurrency(t *testing.T) {
+	_, err := NewMoney(decimal.NewFromFloat(100), Currency("EUR"))
+	assert.Error(t, err)
+}
+
+func TestMoney_Add(t *testing.T) {
+	a, err := NewMoney(decimal.NewFromFloat(10.10), USD)
+	require.NoError(t, err)
+	b, err := NewMoney(decimal.NewFromFloat(5.05), USD)
+	require.NoError(t, err)
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.True(t, sum.Amount().Equal(decimal.NewFromFloat(15.15)))
+}
+
+func TestMoney_Add_RejectsMismatchedCurrencies(t *testing.T) {
+	usd, err := NewMoney(decimal.NewFromFloat(10), USD)
+	require.NoError(t, err)
+	brl, err := NewMoney(decimal.NewFromFloat(10), BRL)
+	require.NoError(t, err)
+
+	_, err = usd.Add(brl)
+	assert.Error(t, err)
+}
+
+func TestMoney_Sub(t *testing.T) {
+	a, err := NewMoney(decimal.NewFromFloat(10), USD)
+	require.NoError(t, err)
+	b, err := NewMoney(decimal.NewFromFloat(3.50), USD)
+	require.NoError(t, err)
+
+	diff, err := a.Sub(b)
+	require.NoError(t, err)
+	assert.True(t, diff.Amount().Equal(decimal.NewFromFloat(6.50)))
+}
+
+func TestMoney_String(t *testing.T) {
+	m, err := NewMoney(decimal.NewFromFloat(1234.5), USD)
+	require.NoError(t, err)
+	assert.Equal(t, "$1234.50", m.String())
+}
@@ -9,39 +9,132 @@ type SettlementLine struct {
 	Transaction *Transaction
 	FXRate      decimal.Decimal
 	USDAmount   decimal.Decimal
+
+	// USDMoney is USDAmount rounded to USD's minor-unit precision via Money's
+	// banker's rounding. USDAmount is kept alongside it for backward
+	// compatibility with existing totals, which are summed unrounded.
+	USDMoney Money
+
+	// ConversionPath lists the currencies the FX conversion passed through,
+	// e.g. [COP, BRL, USD] when no direct COP→USD rate was available. For a
+	// direct conversion this is just [Transaction.Currency, USD].
+	ConversionPath []Currency
+
+	// FXVolatilityZScore is how many standard deviations FXRate deviates from
+	// its currency's trailing rolling mean, as scored by
+	// fxrate.VolatilityDetector. Zero when no detector was configured.
+	FXVolatilityZScore decimal.Decimal
+	// FXAnomaly is true when FXVolatilityZScore exceeds the detector's
+	// configured threshold. Always false when no detector was configured.
+	FXAnomaly bool
+
+	// LotConsumption breaks down a non-USD Refund's realized FX gain/loss by
+	// the capture lot(s) it drew down, as computed by settlement.LotTracker.
+	// Empty for Capture lines (which open a lot rather than consume one) and
+	// for any line when no LotTracker was configured.
+	LotConsumption []LotSlice
+
+	// ConvertedAmounts holds this line's USDAmount re-expressed in additional
+	// presentation currencies (e.g. EUR, BRL), keyed by currency, as computed
+	// by fxrate.Service.Convert. Nil when the engine was not configured with
+	// any Engine.PresentationCurrencies.
+	ConvertedAmounts map[Currency]decimal.Decimal
+
+	// RateSource identifies which upstream FX provider served FXRate, e.g.
+	// "ecb" or "mock", for audit trails on settlement reports. Empty for
+	// USD-denominated transactions, which never consult a provider.
+	RateSource string
+}
+
+// LotSlice records the portion of a single capture lot consumed by a refund,
+// and the realized FX gain or loss that portion produced, following FIFO
+// cost-basis tracking (analogous to FIFO-by-ISIN tracking in brokerage tax
+// reports).
+type LotSlice struct {
+	CaptureID            string
+	ConsumedNativeAmount decimal.Decimal
+	LotOpenFXRate        decimal.Decimal
+	RefundFXRate         decimal.Decimal
+	// GainUSD is (RefundFXRate - LotOpenFXRate) * ConsumedNativeAmount: positive
+	// when the currency strengthened against USD between the capture and the
+	// refund (the supplier is refunding fewer USD than it originally received).
+	GainUSD decimal.Decimal
 }
 
 // SupplierSettlement represents the aggregated settlement for a supplier
 type SupplierSettlement struct {
-	SupplierID        string
-	SupplierName      string
-	Lines             []SettlementLine
-	TotalCapturesUSD  decimal.Decimal
-	TotalRefundsUSD   decimal.Decimal
-	NetAmountUSD      decimal.Decimal
-	TransactionCount  int
+	SupplierID          string
+	SupplierName        string
+	Lines               []SettlementLine
+	TotalCapturesUSD    decimal.Decimal
+	TotalRefundsUSD     decimal.Decimal
+	TotalChargebacksUSD decimal.Decimal // Chargebacks net of any ChargebackReversal lines
+	NetAmountUSD        decimal.Decimal
+	TransactionCount    int
 
 	// Stretch goal fields for anomaly detection and multi-period analysis
-	RefundRatePct    decimal.Decimal  // Refund rate as percentage of captures
-	VolatilityFlag   bool              // True if >5% FX variance detected between auth and capture
-	Warnings         []string          // List of warning codes (HIGH_REFUND_RATE, VOLATILITY_WARNING, etc.)
-	AuthTransactions []*Transaction    // Authorization transactions for volatility comparison
+	RefundRatePct    decimal.Decimal // Refund rate as percentage of captures
+	ChargebackRate   decimal.Decimal // Chargeback rate as percentage of captures
+	VolatilityFlag   bool            // True if >5% FX variance detected between auth and capture
+	Warnings         []string        // List of warning codes (HIGH_REFUND_RATE, VOLATILITY_WARNING, etc.)
+	AuthTransactions []*Transaction  // Authorization transactions for volatility comparison
+
+	// AuthCaptureMatches holds the one-to-one auth/capture assignment used for
+	// volatility detection, so the reporter can show per-pair variance instead
+	// of a single supplier-wide flag.
+	AuthCaptureMatches []AuthCaptureMatch
+	MaxVariance        decimal.Decimal // Largest FX variance across all matched pairs
+	MeanVariance       decimal.Decimal // Average FX variance across all matched pairs
+
+	// RealizedFXGainUSD is the sum of every line's LotConsumption gains: the
+	// USD impact of FX movement between when a non-USD capture's lot was
+	// opened and when it was drawn down by a refund. Populated only when the
+	// engine is configured with a LotTracker.
+	RealizedFXGainUSD decimal.Decimal
+	// UnrealizedFXGainUSD is the mark-to-market USD gain/loss on capture lots
+	// still open (not yet consumed by a refund) at the end of this
+	// settlement run, valued at the LotTracker's current FX rate per currency.
+	UnrealizedFXGainUSD decimal.Decimal
+
+	// PresentationTotals holds this supplier's net settled amount (captures
+	// minus refunds and net chargebacks, mirroring NetAmountUSD) re-expressed in additional
+	// presentation currencies, keyed by currency. Populated only when the
+	// engine is configured with Engine.PresentationCurrencies; USD itself is
+	// never a key here since NetAmountUSD already covers it.
+	PresentationTotals map[Currency]decimal.Decimal
+}
+
+// AuthCaptureMatch records the assignment of a capture to the authorization it
+// was matched against for volatility detection, along with the FX rate
+// variance observed between the two.
+type AuthCaptureMatch struct {
+	AuthID    string
+	CaptureID string
+	Variance  decimal.Decimal
 }
 
 // NewSupplierSettlement creates a new supplier settlement
 func NewSupplierSettlement(supplierID, supplierName string) *SupplierSettlement {
 	return &SupplierSettlement{
-		SupplierID:       supplierID,
-		SupplierName:     supplierName,
-		Lines:            make([]SettlementLine, 0),
-		TotalCapturesUSD: decimal.Zero,
-		TotalRefundsUSD:  decimal.Zero,
-		NetAmountUSD:     decimal.Zero,
-		TransactionCount: 0,
-		RefundRatePct:    decimal.Zero,
-		VolatilityFlag:   false,
-		Warnings:         make([]string, 0),
-		AuthTransactions: make([]*Transaction, 0),
+		SupplierID:          supplierID,
+		SupplierName:        supplierName,
+		Lines:               make([]SettlementLine, 0),
+		TotalCapturesUSD:    decimal.Zero,
+		TotalRefundsUSD:     decimal.Zero,
+		TotalChargebacksUSD: decimal.Zero,
+		NetAmountUSD:        decimal.Zero,
+		TransactionCount:    0,
+		RefundRatePct:       decimal.Zero,
+		ChargebackRate:      decimal.Zero,
+		VolatilityFlag:      false,
+		Warnings:            make([]string, 0),
+		AuthTransactions:    make([]*Transaction, 0),
+		AuthCaptureMatches:  make([]AuthCaptureMatch, 0),
+		MaxVariance:         decimal.Zero,
+		MeanVariance:        decimal.Zero,
+		RealizedFXGainUSD:   decimal.Zero,
+		UnrealizedFXGainUSD: decimal.Zero,
+		PresentationTotals:  make(map[Currency]decimal.Decimal),
 	}
 }
 
@@ -55,7 +148,56 @@ func (s *SupplierSettlement) AddLine(line SettlementLine) {
 		s.TotalCapturesUSD = s.TotalCapturesUSD.Add(line.USDAmount)
 	case Refund:
 		s.TotalRefundsUSD = s.TotalRefundsUSD.Add(line.USDAmount)
+	case Chargeback:
+		s.TotalChargebacksUSD = s.TotalChargebacksUSD.Add(line.USDAmount)
+	case ChargebackReversal:
+		// A reversed chargeback is money the supplier gets back, so it nets
+		// against TotalChargebacksUSD rather than accumulating as its own total.
+		s.TotalChargebacksUSD = s.TotalChargebacksUSD.Sub(line.USDAmount)
+	}
+
+	s.NetAmountUSD = s.TotalCapturesUSD.Sub(s.TotalRefundsUSD).Sub(s.TotalChargebacksUSD)
+
+	for _, slice := range line.LotConsumption {
+		s.RealizedFXGainUSD = s.RealizedFXGainUSD.Add(slice.GainUSD)
 	}
 
-	s.NetAmountUSD = s.TotalCapturesUSD.Sub(s.TotalRefundsUSD)
+	if len(line.ConvertedAmounts) > 0 && s.PresentationTotals == nil {
+		s.PresentationTotals = make(map[Currency]decimal.Decimal)
+	}
+	for currency, amount := range line.ConvertedAmounts {
+		signedAmount := amount
+		if line.Transaction.Type == Refund || line.Transaction.Type == Chargeback {
+			signedAmount = signedAmount.Neg()
+		}
+		s.PresentationTotals[currency] = s.PresentationTotals[currency].Add(signedAmount)
+	}
+}
+
+// RecalculateTotals rebuilds every AddLine-derived total (TotalCapturesUSD,
+// TotalRefundsUSD, TotalChargebacksUSD, NetAmountUSD, TransactionCount,
+// RealizedFXGainUSD, PresentationTotals) from the current Lines, without
+// re-running FX conversion. RefundRatePct and ChargebackRate are reset to
+// zero rather than recomputed here, since it's DetectHighRefundRate/
+// DetectHighChargebackRate's responsibility to derive them from the rebuilt
+// totals. Used by settlement.Engine.Revert after truncating Lines back to a
+// prior snapshot, so the totals reflect exactly the retained lines rather
+// than drifting out of sync with them.
+func (s *SupplierSettlement) RecalculateTotals() {
+	lines := s.Lines
+
+	s.Lines = make([]SettlementLine, 0, len(lines))
+	s.TotalCapturesUSD = decimal.Zero
+	s.TotalRefundsUSD = decimal.Zero
+	s.TotalChargebacksUSD = decimal.Zero
+	s.NetAmountUSD = decimal.Zero
+	s.TransactionCount = 0
+	s.RealizedFXGainUSD = decimal.Zero
+	s.RefundRatePct = decimal.Zero
+	s.ChargebackRate = decimal.Zero
+	s.PresentationTotals = make(map[Currency]decimal.Decimal)
+
+	for _, line := range lines {
+		s.AddLine(line)
+	}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSupplierSettlement(t *testing.T) {
@@ -125,3 +126,86 @@ func TestSupplierSettlement_MultipleTransactions(t *testing.T) {
 	assert.True(t, settlement.TotalRefundsUSD.Equal(decimal.NewFromFloat(80)))
 	assert.True(t, settlement.NetAmountUSD.Equal(decimal.NewFromFloat(370)))
 }
+
+func TestSupplierSettlement_AddLine_AccumulatesPresentationTotals(t *testing.T) {
+	settlement := NewSupplierSettlement("sup123", "Test Supplier")
+
+	captureTx := &Transaction{
+		ID:             "tx1",
+		SupplierID:     "sup123",
+		Type:           Capture,
+		OriginalAmount: decimal.NewFromFloat(100),
+		Currency:       USD,
+		Timestamp:      time.Now(),
+		Status:         Completed,
+	}
+	settlement.AddLine(SettlementLine{
+		Transaction:      captureTx,
+		FXRate:           decimal.NewFromFloat(1.0),
+		USDAmount:        decimal.NewFromFloat(100),
+		ConvertedAmounts: map[Currency]decimal.Decimal{BRL: decimal.NewFromFloat(500)},
+	})
+
+	refundTx := &Transaction{
+		ID:             "tx2",
+		SupplierID:     "sup123",
+		Type:           Refund,
+		OriginalAmount: decimal.NewFromFloat(30),
+		Currency:       USD,
+		Timestamp:      time.Now(),
+		Status:         Completed,
+	}
+	settlement.AddLine(SettlementLine{
+		Transaction:      refundTx,
+		FXRate:           decimal.NewFromFloat(1.0),
+		USDAmount:        decimal.NewFromFloat(30),
+		ConvertedAmounts: map[Currency]decimal.Decimal{BRL: decimal.NewFromFloat(150)},
+	})
+
+	require.True(t, settlement.PresentationTotals[BRL].Equal(decimal.NewFromFloat(350)))
+}
+
+func TestSupplierSettlement_RecalculateTotals_RebuildsFromTruncatedLines(t *testing.T) {
+	settlement := NewSupplierSettlement("sup123", "Test Supplier")
+
+	settlement.AddLine(SettlementLine{
+		Transaction: &Transaction{ID: "tx1", SupplierID: "sup123", Type: Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: USD, Timestamp: time.Now(), Status: Completed},
+		FXRate:      decimal.NewFromFloat(1.0),
+		USDAmount:   decimal.NewFromFloat(100),
+	})
+	settlement.AddLine(SettlementLine{
+		Transaction:      &Transaction{ID: "tx2", SupplierID: "sup123", Type: Refund, OriginalAmount: decimal.NewFromFloat(30), Currency: USD, Timestamp: time.Now(), Status: Completed},
+		FXRate:           decimal.NewFromFloat(1.0),
+		USDAmount:        decimal.NewFromFloat(30),
+		ConvertedAmounts: map[Currency]decimal.Decimal{BRL: decimal.NewFromFloat(150)},
+	})
+
+	// Simulate a revert: truncate Lines back to just the first, as
+	// settlement.Engine.Revert does, then recalculate.
+	settlement.Lines = settlement.Lines[:1]
+	settlement.RecalculateTotals()
+
+	assert.Equal(t, 1, settlement.TransactionCount)
+	assert.True(t, settlement.TotalCapturesUSD.Equal(decimal.NewFromFloat(100)))
+	assert.True(t, settlement.TotalRefundsUSD.Equal(decimal.Zero))
+	assert.True(t, settlement.NetAmountUSD.Equal(decimal.NewFromFloat(100)))
+	assert.Empty(t, settlement.PresentationTotals)
+}
+
+func TestSupplierSettlement_RecalculateTotals_ResetsRefundRatePct(t *testing.T) {
+	settlement := NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(SettlementLine{
+		Transaction: &Transaction{ID: "tx1", SupplierID: "sup123", Type: Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: USD, Timestamp: time.Now(), Status: Completed},
+		FXRate:      decimal.NewFromFloat(1.0),
+		USDAmount:   decimal.NewFromFloat(100),
+	})
+	// RefundRatePct is normally derived by settlement.DetectHighRefundRate,
+	// not AddLine; set it directly to simulate a prior round having computed
+	// a non-zero rate before the captures backing it were truncated away.
+	settlement.RefundRatePct = decimal.NewFromFloat(25)
+
+	settlement.Lines = nil
+	settlement.RecalculateTotals()
+
+	assert.True(t, settlement.RefundRatePct.IsZero())
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SettlementStats aggregates extended run-level statistics computed over a
+// completed settlement batch: per-supplier daily performance metrics plus
+// cross-supplier concentration and refund-health measures, in the style of
+// trade-stats reporting (max drawdown, Sharpe ratio, concentration index)
+// common in portfolio risk tooling. Populated by stats.Analyzer.
+type SettlementStats struct {
+	PerSupplier []SupplierStats
+
+	// FXVolatilityCV is the coefficient of variation (standard deviation /
+	// mean) of each non-USD currency's daily-average FX rate across the run,
+	// keyed by currency.
+	FXVolatilityCV map[Currency]decimal.Decimal
+
+	// ConcentrationIndex is the Herfindahl-Hirschman index (sum of squared
+	// supplier shares of total captures USD) across all suppliers in the
+	// run: it ranges from ~1/N (evenly spread) to 1 (one supplier holds
+	// everything).
+	ConcentrationIndex decimal.Decimal
+
+	// RefundToCaptureRatio is total refunds USD divided by total captures
+	// USD across every supplier in the run.
+	RefundToCaptureRatio decimal.Decimal
+}
+
+// DailyNetPoint is one calendar day's net settled USD amount (captures and
+// chargeback reversals minus refunds and chargebacks) for a supplier.
+type DailyNetPoint struct {
+	Date   time.Time
+	NetUSD decimal.Decimal
+}
+
+// SupplierStats holds the per-supplier extended statistics computed by
+// stats.Analyzer.
+type SupplierStats struct {
+	SupplierID string
+
+	// DailyNetUSD is the supplier's daily net USD series, sorted
+	// chronologically, that MaxDrawdownUSD and SharpeRatio are derived from.
+	DailyNetUSD []DailyNetPoint
+
+	// MaxDrawdownUSD is the largest peak-to-trough decline in the supplier's
+	// cumulative net USD total over the run.
+	MaxDrawdownUSD decimal.Decimal
+
+	// RefundToCaptureRatio is this supplier's total refunds USD divided by
+	// its total captures USD.
+	RefundToCaptureRatio decimal.Decimal
+
+	// SharpeRatio is a Sharpe-like ratio over the supplier's daily net USD
+	// returns: (mean daily return - risk-free rate) / standard deviation of
+	// daily returns.
+	SharpeRatio decimal.Decimal
+}
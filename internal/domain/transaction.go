@@ -11,8 +11,11 @@ import (
 type TransactionType string
 
 const (
-	Capture TransactionType = "capture"
-	Refund  TransactionType = "refund"
+	Capture            TransactionType = "capture"
+	Refund             TransactionType = "refund"
+	Authorization      TransactionType = "authorization"
+	Chargeback         TransactionType = "chargeback"
+	ChargebackReversal TransactionType = "chargeback_reversal"
 )
 
 // TransactionStatus represents the status of a transaction
@@ -67,7 +70,7 @@ func (t *Transaction) Validate() error {
 // ValidateType checks if the transaction type is valid
 func (t *Transaction) ValidateType() error {
 	switch t.Type {
-	case Capture, Refund:
+	case Capture, Refund, Authorization, Chargeback, ChargebackReversal:
 		return nil
 	default:
 		return fmt.Errorf("invalid transaction type: %s", t.Type)
@@ -86,5 +89,11 @@ func (t *Transaction) ValidateStatus() error {
 
 // IsSettleable returns true if the transaction should be included in settlement
 func (t *Transaction) IsSettleable() bool {
-	return (t.Type == Capture || t.Type == Refund) && t.Status == Completed
+	return (t.Type == Capture || t.Type == Refund || t.Type == Chargeback || t.Type == ChargebackReversal) && t.Status == Completed
+}
+
+// Money returns the transaction's original amount and currency as a rounded
+// Money value, enforcing the currency's minor-unit precision.
+func (t *Transaction) Money() (Money, error) {
+	return NewMoney(t.OriginalAmount, t.Currency)
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTransaction_Validate(t *testing.T) {
@@ -30,6 +31,19 @@ func TestTransaction_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid chargeback transaction",
+			tx: Transaction{
+				ID:             "tx123",
+				SupplierID:     "sup456",
+				Type:           Chargeback,
+				OriginalAmount: decimal.NewFromFloat(100.50),
+				Currency:       USD,
+				Timestamp:      validTime,
+				Status:         Completed,
+			},
+			wantErr: false,
+		},
 		{
 			name: "empty ID",
 			tx: Transaction{
@@ -182,8 +196,11 @@ func TestTransaction_IsSettleable(t *testing.T) {
 	}{
 		{"capture completed", Capture, Completed, true},
 		{"refund completed", Refund, Completed, true},
+		{"chargeback completed", Chargeback, Completed, true},
+		{"chargeback reversal completed", ChargebackReversal, Completed, true},
 		{"capture pending", Capture, Pending, false},
 		{"refund failed", Refund, Failed, false},
+		{"chargeback pending", Chargeback, Pending, false},
 		{"invalid type completed", TransactionType("other"), Completed, false},
 	}
 
@@ -197,3 +214,25 @@ func TestTransaction_IsSettleable(t *testing.T) {
 		})
 	}
 }
+
+func TestTransaction_Money(t *testing.T) {
+	tx := Transaction{
+		OriginalAmount: decimal.NewFromFloat(100.565),
+		Currency:       USD,
+	}
+
+	money, err := tx.Money()
+	require.NoError(t, err)
+	assert.Equal(t, USD, money.Currency())
+	assert.True(t, money.Amount().Equal(decimal.NewFromFloat(100.56)))
+}
+
+func TestTransaction_Money_InvalidCurrency(t *testing.T) {
+	tx := Transaction{
+		OriginalAmount: decimal.NewFromFloat(100),
+		Currency:       Currency("EUR"),
+	}
+
+	_, err := tx.Money()
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package fxrate
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCacheTTL is how long a CachingProvider trusts a cached rate before
+// it re-fetches from the wrapped Provider, used when CachingProvider.TTL
+// isn't set.
+const DefaultCacheTTL = 1 * time.Hour
+
+// DefaultCacheSize bounds how many (currency, day) entries a CachingProvider
+// keeps before evicting the least-recently-used one, used when
+// CachingProvider.MaxEntries isn't set.
+const DefaultCacheSize = 10000
+
+// cacheKey identifies a cached rate by currency and calendar day; lookups
+// for the same currency on the same day always hit the cache regardless of
+// the time-of-day component of the requested date.
+type cacheKey struct {
+	currency domain.Currency
+	day      string // YYYY-MM-DD
+}
+
+type cacheEntry struct {
+	key       cacheKey
+	rate      decimal.Decimal
+	source    string
+	expiresAt time.Time
+}
+
+// CachingProvider wraps a Provider with an LRU cache keyed by (currency,
+// date-truncated-to-day), so that settlement runs over large batches of
+// transactions issue at most one upstream lookup per currency per day no
+// matter how many transactions share that day. Entries older than TTL are
+// treated as misses and re-fetched.
+type CachingProvider struct {
+	provider Provider
+
+	// TTL is how long a cached rate is trusted. Zero means DefaultCacheTTL.
+	TTL time.Duration
+	// MaxEntries caps the number of cached (currency, day) entries before
+	// the least-recently-used one is evicted. Zero means DefaultCacheSize.
+	MaxEntries int
+
+	// diskPath, when set (via NewDiskCachingProvider), is a JSON file the
+	// cache is persisted to after every write, so lookups survive process
+	// restarts. Empty means in-memory only.
+	diskPath string
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	order   *list.List // front = most recently used
+}
+
+// NewCachingProvider wraps provider with a (currency, day) LRU cache using
+// ttl as the freshness window and maxEntries as the capacity. A zero ttl or
+// maxEntries falls back to DefaultCacheTTL / DefaultCacheSize respectively.
+func NewCachingProvider(provider Provider, ttl time.Duration, maxEntries int) *CachingProvider {
+	return &CachingProvider{
+		provider:   provider,
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+		entries:    make(map[cacheKey]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// GetRate returns the cached rate for (currency, date's day) if it's present
+// and not expired, otherwise it fetches from the wrapped Provider and caches
+// the result before returning it.
+func (c *CachingProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	quote, err := c.GetRateWithSource(currency, date)
+	return quote.Rate, err
+}
+
+// GetRateWithSource is GetRate's SourcedProvider counterpart: it returns the
+// same rate, annotated with which upstream provider originally served it -
+// preserved across cache hits, not just the fetch that populated the entry.
+func (c *CachingProvider) GetRateWithSource(currency domain.Currency, date time.Time) (RateQuote, error) {
+	key := cacheKey{currency: currency, day: date.UTC().Format("2006-01-02")}
+
+	if quote, ok := c.lookup(key); ok {
+		return quote, nil
+	}
+
+	quote, err := getRateWithSource(c.provider, currency, date)
+	if err != nil {
+		return RateQuote{}, err
+	}
+
+	c.store(key, quote)
+
+	if c.diskPath != "" {
+		// A failed write to disk shouldn't fail the lookup itself; the rate
+		// is still correct and cached in memory for this process.
+		_ = c.persistDisk()
+	}
+
+	return quote, nil
+}
+
+func (c *CachingProvider) lookup(key cacheKey) (RateQuote, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return RateQuote{}, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return RateQuote{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	return RateQuote{Rate: entry.rate, Source: entry.source}, true
+}
+
+func (c *CachingProvider) store(key cacheKey, quote RateQuote) {
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheSize
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).rate = quote.Rate
+		elem.Value.(*cacheEntry).source = quote.Source
+		elem.Value.(*cacheEntry).expiresAt = time.Now().Add(ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&cacheEntry{key: key, rate: quote.Rate, source: quote.Source, expiresAt: time.Now().Add(ttl)})
+	c.entries[key] = elem
+
+	for len(c.entries) > maxEntries {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,91 @@
+package fxrate
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingProvider_SameDayHitsCache(t *testing.T) {
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	cache := NewCachingProvider(provider, time.Hour, 10)
+
+	morning := time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)
+	evening := time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)
+
+	rate1, err := cache.GetRate(domain.BRL, morning)
+	require.NoError(t, err)
+	rate2, err := cache.GetRate(domain.BRL, evening)
+	require.NoError(t, err)
+
+	assert.True(t, rate1.Equal(rate2))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+}
+
+func TestCachingProvider_DifferentDayMisses(t *testing.T) {
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	cache := NewCachingProvider(provider, time.Hour, 10)
+
+	_, err := cache.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	_, err = cache.GetRate(domain.BRL, time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls))
+}
+
+func TestCachingProvider_ExpiresAfterTTL(t *testing.T) {
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	cache := NewCachingProvider(provider, time.Millisecond, 10)
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	_, err := cache.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = cache.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls))
+}
+
+func TestCachingProvider_PreservesSourceAcrossCacheHits(t *testing.T) {
+	cache := NewCachingProvider(NewMockProvider(), time.Hour, 10)
+
+	morning := time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)
+	evening := time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)
+
+	first, err := cache.GetRateWithSource(domain.BRL, morning)
+	require.NoError(t, err)
+	second, err := cache.GetRateWithSource(domain.BRL, evening)
+	require.NoError(t, err)
+
+	assert.Equal(t, "mock", first.Source)
+	assert.Equal(t, "mock", second.Source, "a cache hit should report the original upstream source, not the cache itself")
+}
+
+func TestCachingProvider_EvictsLeastRecentlyUsed(t *testing.T) {
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	cache := NewCachingProvider(provider, time.Hour, 2)
+
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	_, err := cache.GetRate(domain.BRL, day(1))
+	require.NoError(t, err)
+	_, err = cache.GetRate(domain.BRL, day(2))
+	require.NoError(t, err)
+	// Evicts day(1), the least-recently-used entry, since capacity is 2.
+	_, err = cache.GetRate(domain.BRL, day(3))
+	require.NoError(t, err)
+
+	atomic.StoreInt32(&provider.calls, 0)
+	_, err = cache.GetRate(domain.BRL, day(1))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls), "day(1) should have been evicted and re-fetched")
+}
@@ -0,0 +1,165 @@
+package fxrate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCircuitBreakerThreshold is how many consecutive failures trip a
+// CircuitBreakerProvider open, used when CircuitBreakerProvider.FailureThreshold
+// isn't set.
+const DefaultCircuitBreakerThreshold = 5
+
+// DefaultCircuitBreakerResetTimeout is how long a CircuitBreakerProvider
+// stays open before allowing a single probe request through, used when
+// CircuitBreakerProvider.ResetTimeout isn't set.
+const DefaultCircuitBreakerResetTimeout = 30 * time.Second
+
+// circuitState is the CircuitBreakerProvider's internal state machine:
+// closed (calls pass through normally), open (calls fail fast), and
+// half-open (a single probe call is allowed through to test recovery).
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreakerProvider wraps a Provider and fails fast, without calling
+// through, once the wrapped provider has failed FailureThreshold times in a
+// row - protecting a settlement run from paying the full timeout/retry cost
+// of a persistently down upstream on every single lookup. It's meant to sit
+// in front of a flaky provider inside a ChainProvider, so once it trips open
+// the chain falls through to the next provider immediately instead of
+// waiting on doomed requests; after ResetTimeout elapses it allows one probe
+// request through (half-open) to test whether the upstream has recovered.
+type CircuitBreakerProvider struct {
+	provider Provider
+
+	// FailureThreshold is how many consecutive failures trip the breaker
+	// open. Zero means DefaultCircuitBreakerThreshold.
+	FailureThreshold int
+	// ResetTimeout is how long the breaker stays open before allowing a
+	// half-open probe through. Zero means DefaultCircuitBreakerResetTimeout.
+	ResetTimeout time.Duration
+
+	// now is overridden in tests to avoid real delays.
+	now func() time.Time
+
+	mu                  sync.Mutex
+	state               circuitState
+	consecutiveFailures int
+	openedAt            time.Time
+}
+
+// NewCircuitBreakerProvider wraps provider with a circuit breaker that trips
+// open after failureThreshold consecutive failures and stays open for
+// resetTimeout before probing the upstream again. A zero failureThreshold or
+// resetTimeout falls back to DefaultCircuitBreakerThreshold /
+// DefaultCircuitBreakerResetTimeout respectively.
+func NewCircuitBreakerProvider(provider Provider, failureThreshold int, resetTimeout time.Duration) *CircuitBreakerProvider {
+	return &CircuitBreakerProvider{
+		provider:         provider,
+		FailureThreshold: failureThreshold,
+		ResetTimeout:     resetTimeout,
+		now:              time.Now,
+	}
+}
+
+// GetRate calls the wrapped Provider unless the breaker is open, in which
+// case it fails fast with an error naming the wrapped provider rather than
+// waiting on a call likely to fail.
+func (cb *CircuitBreakerProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	quote, err := cb.GetRateWithSource(currency, date)
+	return quote.Rate, err
+}
+
+// GetRateWithSource is GetRate's SourcedProvider counterpart: it applies the
+// same breaker logic and returns the rate annotated with the wrapped
+// provider's source.
+func (cb *CircuitBreakerProvider) GetRateWithSource(currency domain.Currency, date time.Time) (RateQuote, error) {
+	if !cb.allowRequest() {
+		return RateQuote{}, fmt.Errorf("circuit breaker open for %s: too many consecutive failures", providerName(cb.provider))
+	}
+
+	quote, err := getRateWithSource(cb.provider, currency, date)
+	if err != nil {
+		cb.recordFailure()
+		return RateQuote{}, err
+	}
+
+	cb.recordSuccess()
+	return quote, nil
+}
+
+// allowRequest reports whether a request should be let through: always when
+// closed, never when open (unless ResetTimeout has elapsed, which moves the
+// breaker to half-open and allows exactly this one probe through).
+func (cb *CircuitBreakerProvider) allowRequest() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != circuitOpen {
+		return true
+	}
+
+	resetTimeout := cb.ResetTimeout
+	if resetTimeout <= 0 {
+		resetTimeout = DefaultCircuitBreakerResetTimeout
+	}
+
+	if cb.nowFunc().Sub(cb.openedAt) < resetTimeout {
+		return false
+	}
+
+	cb.state = circuitHalfOpen
+	return true
+}
+
+// recordFailure increments the consecutive-failure count and trips the
+// breaker open once FailureThreshold is reached. A failed half-open probe
+// reopens the breaker immediately.
+func (cb *CircuitBreakerProvider) recordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == circuitHalfOpen {
+		cb.state = circuitOpen
+		cb.openedAt = cb.nowFunc()
+		return
+	}
+
+	cb.consecutiveFailures++
+
+	threshold := cb.FailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultCircuitBreakerThreshold
+	}
+
+	if cb.consecutiveFailures >= threshold {
+		cb.state = circuitOpen
+		cb.openedAt = cb.nowFunc()
+	}
+}
+
+// recordSuccess resets the breaker to closed; a successful half-open probe
+// means the upstream has recovered.
+func (cb *CircuitBreakerProvider) recordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.state = circuitClosed
+	cb.consecutiveFailures = 0
+}
+
+func (cb *CircuitBreakerProvider) nowFunc() time.Time {
+	if cb.now != nil {
+		return cb.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,96 @@
+package fxrate
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alwaysFailsProvider fails every call, so tests can deterministically trip
+// a CircuitBreakerProvider open.
+type alwaysFailsProvider struct {
+	calls int32
+}
+
+func (p *alwaysFailsProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	atomic.AddInt32(&p.calls, 1)
+	return decimal.Zero, fmt.Errorf("provider unavailable")
+}
+
+func TestCircuitBreakerProvider_TripsOpenAfterThreshold(t *testing.T) {
+	provider := &alwaysFailsProvider{}
+	breaker := NewCircuitBreakerProvider(provider, 2, time.Minute)
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	_, err := breaker.GetRate(domain.BRL, date)
+	assert.Error(t, err)
+	_, err = breaker.GetRate(domain.BRL, date)
+	assert.Error(t, err)
+
+	// The breaker is now open: a third call should fail fast without
+	// reaching the wrapped provider.
+	_, err = breaker.GetRate(domain.BRL, date)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls), "open breaker should not call through")
+}
+
+func TestCircuitBreakerProvider_HalfOpenProbeAfterResetTimeout(t *testing.T) {
+	provider := &flakyProvider{failuresBeforeSuccess: 2, rate: decimal.NewFromFloat(0.20)}
+	breaker := NewCircuitBreakerProvider(provider, 2, time.Minute)
+
+	current := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return current }
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	_, err := breaker.GetRate(domain.BRL, date)
+	assert.Error(t, err)
+	_, err = breaker.GetRate(domain.BRL, date)
+	assert.Error(t, err)
+
+	// Still within ResetTimeout: fails fast without calling through.
+	_, err = breaker.GetRate(domain.BRL, date)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls))
+
+	// Advance past ResetTimeout: the breaker allows one probe through, and
+	// flakyProvider's third call (its first success) closes it again.
+	current = current.Add(2 * time.Minute)
+	rate, err := breaker.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&provider.calls))
+}
+
+func TestCircuitBreakerProvider_GetRateWithSourceReportsUpstream(t *testing.T) {
+	breaker := NewCircuitBreakerProvider(NewMockProvider(), 2, time.Minute)
+
+	quote, err := breaker.GetRateWithSource(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "mock", quote.Source)
+}
+
+func TestCircuitBreakerProvider_RecoversOnSuccessBetweenFailures(t *testing.T) {
+	provider := &flakyProvider{failuresBeforeSuccess: 1, rate: decimal.NewFromFloat(0.20)}
+	breaker := NewCircuitBreakerProvider(provider, 2, time.Minute)
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	_, err := breaker.GetRate(domain.BRL, date)
+	assert.Error(t, err)
+
+	// A success resets the consecutive-failure count, so the breaker never
+	// trips even though more failures could follow later.
+	_, err = breaker.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+
+	breaker.recordFailure()
+	assert.Equal(t, circuitClosed, breaker.state, "a single failure after a reset shouldn't trip the breaker")
+}
@@ -0,0 +1,109 @@
+package fxrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// diskCacheRecord is the on-disk JSON representation of one cached rate.
+// cacheKey and cacheEntry aren't used directly because a Go map key can't
+// round-trip through JSON as an object.
+type diskCacheRecord struct {
+	Currency  string    `json:"currency"`
+	Day       string    `json:"day"`
+	Rate      string    `json:"rate"`
+	Source    string    `json:"source,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewDiskCachingProvider creates a CachingProvider exactly like
+// NewCachingProvider, additionally persisting every cached rate to a JSON
+// file at path so the cache survives process restarts. Any entries already
+// in path (and not expired) are loaded immediately; every subsequent write
+// rewrites the file with the cache's full current contents.
+func NewDiskCachingProvider(provider Provider, ttl time.Duration, maxEntries int, path string) (*CachingProvider, error) {
+	cp := NewCachingProvider(provider, ttl, maxEntries)
+	cp.diskPath = path
+
+	if err := cp.loadDisk(); err != nil {
+		return nil, fmt.Errorf("failed to load disk cache from %s: %w", path, err)
+	}
+
+	return cp, nil
+}
+
+// loadDisk populates the in-memory cache from diskPath, if it exists. A
+// missing file just means there's nothing cached yet, not an error.
+func (c *CachingProvider) loadDisk() error {
+	if c.diskPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.diskPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var records []diskCacheRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("invalid disk cache file: %w", err)
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, record := range records {
+		if now.After(record.ExpiresAt) {
+			continue
+		}
+
+		rate, err := decimal.NewFromString(record.Rate)
+		if err != nil {
+			continue
+		}
+
+		key := cacheKey{currency: domain.Currency(record.Currency), day: record.Day}
+		elem := c.order.PushBack(&cacheEntry{key: key, rate: rate, source: record.Source, expiresAt: record.ExpiresAt})
+		c.entries[key] = elem
+	}
+
+	return nil
+}
+
+// persistDisk rewrites diskPath with the cache's full current contents.
+func (c *CachingProvider) persistDisk() error {
+	c.mu.Lock()
+	records := make([]diskCacheRecord, 0, len(c.entries))
+	for key, elem := range c.entries {
+		entry := elem.Value.(*cacheEntry)
+		records = append(records, diskCacheRecord{
+			Currency:  string(key.currency),
+			Day:       key.day,
+			Rate:      entry.rate.String(),
+			Source:    entry.source,
+			ExpiresAt: entry.expiresAt,
+		})
+	}
+	c.mu.Unlock()
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal disk cache: %w", err)
+	}
+
+	if err := os.WriteFile(c.diskPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write disk cache: %w", err)
+	}
+
+	return nil
+}
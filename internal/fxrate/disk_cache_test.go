@@ -0,0 +1,84 @@
+package fxrate
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiskCachingProvider_PersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fx-cache.json")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	cache, err := NewDiskCachingProvider(provider, time.Hour, 10, path)
+	require.NoError(t, err)
+
+	_, err = cache.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+
+	// A fresh CachingProvider backed by the same file should load the
+	// previous run's entry instead of hitting the wrapped provider again.
+	reloaded, err := NewDiskCachingProvider(provider, time.Hour, 10, path)
+	require.NoError(t, err)
+
+	rate, err := reloaded.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls), "reloaded cache should hit the persisted entry, not the wrapped provider")
+}
+
+func TestNewDiskCachingProvider_PersistsSourceAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fx-cache.json")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	cache, err := NewDiskCachingProvider(NewMockProvider(), time.Hour, 10, path)
+	require.NoError(t, err)
+
+	_, err = cache.GetRateWithSource(domain.BRL, date)
+	require.NoError(t, err)
+
+	reloaded, err := NewDiskCachingProvider(NewMockProvider(), time.Hour, 10, path)
+	require.NoError(t, err)
+
+	quote, err := reloaded.GetRateWithSource(domain.BRL, date)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", quote.Source)
+}
+
+func TestNewDiskCachingProvider_MissingFileIsNotAnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist.json")
+
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	cache, err := NewDiskCachingProvider(provider, time.Hour, 10, path)
+	require.NoError(t, err)
+	assert.NotNil(t, cache)
+}
+
+func TestNewDiskCachingProvider_IgnoresExpiredEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fx-cache.json")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	cache, err := NewDiskCachingProvider(provider, time.Millisecond, 10, path)
+	require.NoError(t, err)
+
+	_, err = cache.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	reloaded, err := NewDiskCachingProvider(provider, time.Hour, 10, path)
+	require.NoError(t, err)
+
+	_, err = reloaded.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls), "an expired persisted entry should be refetched, not reused")
+}
@@ -0,0 +1,215 @@
+package fxrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// RateParser extracts a decimal exchange rate for a currency from the raw
+// response body of a REST FX endpoint. Implementations are specific to the
+// shape of the upstream API (ECB, openexchangerates, a venue-style JSON feed,
+// etc.), which is why parsing is pluggable rather than baked into HTTPProvider.
+type RateParser func(body []byte, currency domain.Currency, date time.Time) (decimal.Decimal, error)
+
+// httpRateCacheKey identifies a cached rate by currency and calendar day, so
+// repeated lookups within the same batch never hit the network twice.
+type httpRateCacheKey struct {
+	currency domain.Currency
+	date     string // YYYY-MM-DD
+}
+
+// HTTPProvider implements Provider by fetching daily close rates from a
+// configurable REST exchange rate API. It requests the rate for the exact
+// date first and, if the upstream has no close for that day (e.g. a weekend
+// or holiday), walks backward up to lookbackDays to find the closest prior
+// daily close. Responses are cached in memory per (currency, date) so that
+// fxrate.Service.ConvertToUSD stays deterministic within a batch.
+type HTTPProvider struct {
+	client       *http.Client
+	baseURL      string
+	apiKeyHeader string
+	apiKey       string
+	parseRate    RateParser
+
+	maxRetries   int
+	retryBackoff time.Duration
+	lookbackDays int
+
+	mu    sync.Mutex
+	cache map[httpRateCacheKey]decimal.Decimal
+}
+
+// NewHTTPProvider creates a live FX rate provider backed by a REST endpoint.
+// baseURL is expected to accept requests of the form "<baseURL>/<currency>/<date>"
+// where date is formatted as YYYY-MM-DD; parseRate interprets the response body
+// for the specific API being integrated. apiKeyHeader/apiKey are sent as a
+// request header when apiKeyHeader is non-empty.
+func NewHTTPProvider(client *http.Client, baseURL, apiKeyHeader, apiKey string, parseRate RateParser) *HTTPProvider {
+	return &HTTPProvider{
+		client:       client,
+		baseURL:      baseURL,
+		apiKeyHeader: apiKeyHeader,
+		apiKey:       apiKey,
+		parseRate:    parseRate,
+		maxRetries:   3,
+		retryBackoff: 250 * time.Millisecond,
+		lookbackDays: 7,
+		cache:        make(map[httpRateCacheKey]decimal.Decimal),
+	}
+}
+
+// GetRate returns the exchange rate for converting from the specified currency
+// to USD on the given date, fetching from the REST endpoint and caching the
+// result. It satisfies the Provider interface using a background context with
+// no deadline beyond the one already configured on the underlying http.Client.
+func (p *HTTPProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	return p.GetRateContext(context.Background(), currency, date)
+}
+
+// GetRateContext is the context-aware counterpart to GetRate, allowing callers
+// to bound or cancel the underlying HTTP requests.
+func (p *HTTPProvider) GetRateContext(ctx context.Context, currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+
+	day := date.UTC().Truncate(24 * time.Hour)
+
+	for offset := 0; offset <= p.lookbackDays; offset++ {
+		candidate := day.AddDate(0, 0, -offset)
+		key := httpRateCacheKey{currency: currency, date: candidate.Format("2006-01-02")}
+
+		if rate, ok := p.cachedRate(key); ok {
+			return rate, nil
+		}
+
+		rate, err := p.fetchRate(ctx, currency, candidate)
+		if err == nil {
+			p.storeRate(key, rate)
+			return rate, nil
+		}
+	}
+
+	return decimal.Zero, fmt.Errorf("no rate available for %s within %d days of %s", currency, p.lookbackDays, day.Format("2006-01-02"))
+}
+
+func (p *HTTPProvider) cachedRate(key httpRateCacheKey) (decimal.Decimal, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rate, ok := p.cache[key]
+	return rate, ok
+}
+
+// Name identifies this provider as "http" for source auditability. The
+// named providers for specific upstreams (ECB, openexchangerates, etc.) in
+// the providers subpackage don't use HTTPProvider; they implement Provider
+// directly and report their own names.
+func (p *HTTPProvider) Name() string {
+	return "http"
+}
+
+func (p *HTTPProvider) storeRate(key httpRateCacheKey, rate decimal.Decimal) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache[key] = rate
+}
+
+// fetchRate performs the HTTP round-trip for a single (currency, date) pair,
+// retrying transient failures with a linear backoff.
+func (p *HTTPProvider) fetchRate(ctx context.Context, currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	url := fmt.Sprintf("%s/%s/%s", p.baseURL, currency, date.Format("2006-01-02"))
+
+	var lastErr error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return decimal.Zero, ctx.Err()
+			case <-time.After(p.retryBackoff * time.Duration(attempt)):
+			}
+		}
+
+		rate, err := p.doRequest(ctx, url, currency, date)
+		if err == nil {
+			return rate, nil
+		}
+		lastErr = err
+	}
+
+	return decimal.Zero, fmt.Errorf("fetching rate from %s: %w", url, lastErr)
+}
+
+func (p *HTTPProvider) doRequest(ctx context.Context, url string, currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("building request: %w", err)
+	}
+
+	if p.apiKeyHeader != "" {
+		req.Header.Set(p.apiKeyHeader, p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("unexpected status %d from FX endpoint", resp.StatusCode)
+	}
+
+	return p.parseRate(body, currency, date)
+}
+
+// ChainProvider tries each Provider in order, in the spirit of a fallback
+// chain: a live HTTPProvider can be listed first with a MockProvider (or any
+// other offline source) as the last resort, so rate lookups keep working
+// even when the upstream API is unreachable.
+type ChainProvider struct {
+	providers []Provider
+}
+
+// NewChainProvider creates a Provider that falls back through the given
+// providers in order, returning the first successful rate.
+func NewChainProvider(providers ...Provider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+// GetRate tries each provider in the chain in order and returns the first
+// rate obtained without error. If every provider fails, the error from the
+// last provider in the chain is returned.
+func (c *ChainProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	quote, err := c.GetRateWithSource(currency, date)
+	return quote.Rate, err
+}
+
+// GetRateWithSource is GetRate's SourcedProvider counterpart: it returns the
+// same rate, annotated with which provider in the chain served it.
+func (c *ChainProvider) GetRateWithSource(currency domain.Currency, date time.Time) (RateQuote, error) {
+	var lastErr error
+	for _, provider := range c.providers {
+		quote, err := getRateWithSource(provider, currency, date)
+		if err == nil {
+			return quote, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no providers configured")
+	}
+	return RateQuote{}, fmt.Errorf("all providers in chain failed: %w", lastErr)
+}
@@ -0,0 +1,123 @@
+package fxrate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixedRateParser(rate decimal.Decimal) RateParser {
+	return func(body []byte, currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+		return rate, nil
+	}
+}
+
+func TestHTTPProvider_GetRate_USDIsAlwaysOne(t *testing.T) {
+	provider := NewHTTPProvider(http.DefaultClient, "http://unused", "", "", fixedRateParser(decimal.Zero))
+
+	rate, err := provider.GetRate(domain.USD, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
+
+func TestHTTPProvider_GetRate_FetchesAndCaches(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"rate":"0.20"}`)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(http.DefaultClient, server.URL, "X-API-Key", "test-key", fixedRateParser(decimal.NewFromFloat(0.20)))
+
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	rate1, err := provider.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	assert.True(t, rate1.Equal(decimal.NewFromFloat(0.20)))
+
+	rate2, err := provider.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	assert.True(t, rate2.Equal(rate1))
+
+	// Second lookup for the same (currency, date) should hit the cache, not the network.
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requestCount))
+}
+
+func TestHTTPProvider_GetRate_FallsBackToPriorDailyClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only serve a close for 2024-01-12; every later date 404s, forcing the
+		// provider to walk backward to the closest prior daily close.
+		if r.URL.Path == "/BRL/2024-01-12" {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"rate":"0.19"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(http.DefaultClient, server.URL, "", "", fixedRateParser(decimal.NewFromFloat(0.19)))
+	provider.maxRetries = 0
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.19)))
+}
+
+func TestHTTPProvider_GetRate_NoCloseWithinLookback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(http.DefaultClient, server.URL, "", "", fixedRateParser(decimal.Zero))
+	provider.maxRetries = 0
+	provider.lookbackDays = 2
+
+	_, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestChainProvider_FallsBackToNextProvider(t *testing.T) {
+	failing := NewHTTPProvider(http.DefaultClient, "http://127.0.0.1:0", "", "", fixedRateParser(decimal.Zero))
+	failing.maxRetries = 0
+
+	mock := NewMockProvider()
+	chain := NewChainProvider(failing, mock)
+
+	rate, err := chain.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.GreaterThan(decimal.Zero))
+}
+
+func TestChainProvider_AllProvidersFail(t *testing.T) {
+	failing := NewHTTPProvider(http.DefaultClient, "http://127.0.0.1:0", "", "", fixedRateParser(decimal.Zero))
+	failing.maxRetries = 0
+
+	chain := NewChainProvider(failing)
+
+	_, err := chain.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestChainProvider_GetRateWithSourceReportsServingProvider(t *testing.T) {
+	failing := NewHTTPProvider(http.DefaultClient, "http://127.0.0.1:0", "", "", fixedRateParser(decimal.Zero))
+	failing.maxRetries = 0
+
+	mock := NewMockProvider()
+	chain := NewChainProvider(failing, mock)
+
+	quote, err := chain.GetRateWithSource(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "mock", quote.Source)
+}
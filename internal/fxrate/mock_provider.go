@@ -49,3 +49,8 @@ func (m *MockProvider) GetRate(currency domain.Currency, date time.Time) (decima
 
 	return adjustedRate, nil
 }
+
+// Name identifies this provider as "mock" for source auditability.
+func (m *MockProvider) Name() string {
+	return "mock"
+}
@@ -2,16 +2,29 @@
 //
 // This package defines the Provider interface for retrieving historical exchange rates
 // and includes implementations for converting transaction amounts from local currencies
-// to USD. The current implementation uses a MockProvider with simulated rates for
-// demonstration purposes, but the Provider interface can be implemented with real
-// FX data sources (e.g., OpenExchangeRates, CurrencyLayer, or internal services).
+// to USD. MockProvider offers simulated rates for demonstration and testing, while
+// HTTPProvider fetches real daily close rates from a configurable REST endpoint
+// (e.g., OpenExchangeRates, CurrencyLayer, or internal services). Production-grade
+// named providers for specific upstream APIs live in the providers subpackage; they
+// compose with the wrappers defined here: ChainProvider falls back through a list of
+// providers in order, CachingProvider memoizes (currency, day) lookups with an LRU
+// cap and TTL, and RetryingProvider retries a failing provider with exponential
+// backoff and jitter before the chain moves on to the next one. ReplayProvider and
+// RecordingProvider pair up for byte-exact regression testing: RecordingProvider
+// wraps any provider and captures every rate it serves to a fixture file, and
+// ReplayProvider loads that fixture back as an exact-rate provider, so a test can
+// assert a settlement total against a golden decimal instead of a volatility range.
 //
 // The Service type provides high-level conversion functionality that applies
 // historical FX rates based on transaction dates, ensuring accurate settlement
 // calculations that reflect market conditions at the time of each transaction.
+// NewService accepts the providers to use plus optional WithCache/WithRetry
+// options, so a settlement run over 100k+ transactions issues at most one
+// upstream call per (currency, day).
 package fxrate
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
@@ -25,3 +38,77 @@ type Provider interface {
 	// rate cannot be retrieved.
 	GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error)
 }
+
+// NamedProvider is implemented by providers that identify themselves, so a
+// ChainProvider (or Service) can report which upstream source actually
+// served a given rate for auditability. Providers that don't implement it
+// are reported by their Go type name instead (see providerName).
+type NamedProvider interface {
+	Provider
+
+	// Name returns a short, stable identifier for this provider, e.g. "ecb"
+	// or "openexchangerates".
+	Name() string
+}
+
+// RateQuote is a GetRate result annotated with which named provider served
+// it.
+type RateQuote struct {
+	Rate   decimal.Decimal
+	Source string
+}
+
+// SourcedProvider is implemented by providers that can report which
+// provider ultimately served a rate. ChainProvider, RetryingProvider, and
+// CachingProvider all implement it, delegating to the wrapped provider's
+// SourcedProvider (if it has one) or falling back to providerName.
+type SourcedProvider interface {
+	Provider
+
+	GetRateWithSource(currency domain.Currency, date time.Time) (RateQuote, error)
+}
+
+// providerName returns p's Name() if it implements NamedProvider, or a
+// best-effort fallback derived from its Go type otherwise.
+func providerName(p Provider) string {
+	if named, ok := p.(NamedProvider); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// getRateWithSource calls p.GetRateWithSource if p implements SourcedProvider,
+// or falls back to p.GetRate annotated with providerName(p) otherwise. It's
+// the shared delegation step every wrapper provider (Chain/Retrying/Caching)
+// uses to propagate source information through the chain.
+func getRateWithSource(p Provider, currency domain.Currency, date time.Time) (RateQuote, error) {
+	if sourced, ok := p.(SourcedProvider); ok {
+		return sourced.GetRateWithSource(currency, date)
+	}
+	rate, err := p.GetRate(currency, date)
+	if err != nil {
+		return RateQuote{}, err
+	}
+	return RateQuote{Rate: rate, Source: providerName(p)}, nil
+}
+
+// CurrencyPair identifies a directed FX edge quoting From in terms of To,
+// e.g. {From: ARS, To: BRL} is the ARS→BRL rate.
+type CurrencyPair struct {
+	From domain.Currency
+	To   domain.Currency
+}
+
+// GraphProvider is implemented by providers that can expose their full set of
+// known direct exchange rates for a date. Service uses this to find a
+// multi-hop conversion path (e.g. COP→BRL→USD) when a direct rate to USD
+// isn't available; providers that don't implement it simply can't be used
+// for multi-hop conversion and Service.ConvertToUSD falls back to returning
+// the direct-lookup error.
+type GraphProvider interface {
+	Provider
+
+	// Rates returns every direct exchange rate the provider knows about for
+	// the given date, keyed by the directed currency pair.
+	Rates(date time.Time) (map[CurrencyPair]decimal.Decimal, error)
+}
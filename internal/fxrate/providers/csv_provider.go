@@ -0,0 +1,102 @@
+package providers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// csvProviderKey identifies a loaded rate by currency and calendar day.
+type csvProviderKey struct {
+	currency domain.Currency
+	day      string // YYYY-MM-DD
+}
+
+// CSVProvider implements fxrate.Provider by loading a date,currency,rate
+// table from disk, where rate is the number of USD one unit of currency is
+// worth. It's meant for offline/test environments and as a last-resort
+// fallback in a fxrate.ChainProvider when no upstream API is reachable.
+type CSVProvider struct {
+	rates map[csvProviderKey]decimal.Decimal
+}
+
+// NewCSVProvider loads a rate table from the CSV file at path. The file must
+// have a header row "date,currency,rate" with date formatted as YYYY-MM-DD.
+func NewCSVProvider(path string) (*CSVProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rate table: %w", err)
+	}
+	defer file.Close()
+
+	return newCSVProviderFromReader(file)
+}
+
+func newCSVProviderFromReader(r io.Reader) (*CSVProvider, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate table header: %w", err)
+	}
+	if len(header) != 3 || header[0] != "date" || header[1] != "currency" || header[2] != "rate" {
+		return nil, fmt.Errorf("rate table header must be \"date,currency,rate\", got %v", header)
+	}
+
+	rates := make(map[csvProviderKey]decimal.Decimal)
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rate table row: %w", err)
+		}
+		if len(record) != 3 {
+			return nil, fmt.Errorf("rate table row has %d fields, expected 3: %v", len(record), record)
+		}
+
+		date, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q in rate table: %w", record[0], err)
+		}
+
+		rate, err := decimal.NewFromString(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %q in rate table: %w", record[2], err)
+		}
+
+		key := csvProviderKey{currency: domain.Currency(record[1]), day: date.Format("2006-01-02")}
+		rates[key] = rate
+	}
+
+	return &CSVProvider{rates: rates}, nil
+}
+
+// GetRate returns the rate loaded for (currency, date's day), ignoring the
+// time-of-day component of date. Returns an error if no row matches.
+func (p *CSVProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+
+	key := csvProviderKey{currency: currency, day: date.UTC().Format("2006-01-02")}
+	rate, ok := p.rates[key]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no rate table entry for %s on %s", currency, key.day)
+	}
+
+	return rate, nil
+}
+
+// Name identifies this provider as "csv" for source auditability.
+func (p *CSVProvider) Name() string {
+	return "csv"
+}
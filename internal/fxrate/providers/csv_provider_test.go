@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVProvider_GetRate_USDIsAlwaysOne(t *testing.T) {
+	provider, err := newCSVProviderFromReader(strings.NewReader("date,currency,rate\n"))
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(domain.USD, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
+
+func TestCSVProvider_GetRate_LoadsTableRow(t *testing.T) {
+	csv := "date,currency,rate\n2024-01-15,BRL,0.20\n2024-01-16,BRL,0.19\n"
+	provider, err := newCSVProviderFromReader(strings.NewReader(csv))
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+}
+
+func TestCSVProvider_GetRate_MissingRowReturnsError(t *testing.T) {
+	csv := "date,currency,rate\n2024-01-15,BRL,0.20\n"
+	provider, err := newCSVProviderFromReader(strings.NewReader(csv))
+	require.NoError(t, err)
+
+	_, err = provider.GetRate(domain.BRL, time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestCSVProvider_New_InvalidHeader(t *testing.T) {
+	_, err := newCSVProviderFromReader(strings.NewReader("day,ccy,value\n"))
+	assert.Error(t, err)
+}
+
+func TestCSVProvider_New_MissingFile(t *testing.T) {
+	_, err := NewCSVProvider("/no/such/file.csv")
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// defaultCurrencyLayerBaseURL is currencylayer's historical-rates endpoint.
+const defaultCurrencyLayerBaseURL = "https://api.currencylayer.com/historical"
+
+// currencyLayerResponse is the subset of currencylayer's historical response
+// this provider needs. Quotes are keyed "<source><currency>" (e.g. "USDARS")
+// and quote a USD base, matching the source currency configured for the
+// account.
+type currencyLayerResponse struct {
+	Success bool               `json:"success"`
+	Source  string             `json:"source"`
+	Quotes  map[string]float64 `json:"quotes"`
+	Error   struct {
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// CurrencyLayerProvider implements fxrate.Provider against currencylayer's
+// historical endpoint, which quotes rates from a USD base (1 USD = N
+// <currency>) under a "USD<currency>" key. Since fxrate.Provider wants the
+// inverse (1 <currency> = N USD), GetRate inverts the quoted rate.
+//
+// It does not retry or cache on its own; wrap it in fxrate.NewRetryingProvider
+// and fxrate.NewCachingProvider (or put it behind a fxrate.ChainProvider
+// alongside other sources) for production use.
+type CurrencyLayerProvider struct {
+	client    *http.Client
+	baseURL   string
+	accessKey string
+}
+
+// NewCurrencyLayerProvider creates a Provider backed by currencylayer's
+// historical rates endpoint, authenticating with the given access key.
+func NewCurrencyLayerProvider(client *http.Client, accessKey string) *CurrencyLayerProvider {
+	return &CurrencyLayerProvider{
+		client:    client,
+		baseURL:   defaultCurrencyLayerBaseURL,
+		accessKey: accessKey,
+	}
+}
+
+// GetRate returns the exchange rate for converting from the specified
+// currency to USD on the given date.
+func (p *CurrencyLayerProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+
+	params := url.Values{}
+	params.Set("access_key", p.accessKey)
+	params.Set("date", date.UTC().Format("2006-01-02"))
+	requestURL := fmt.Sprintf("%s?%s", p.baseURL, params.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("building currencylayer request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("performing currencylayer request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("reading currencylayer response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("unexpected status %d from currencylayer", resp.StatusCode)
+	}
+
+	var parsed currencyLayerResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return decimal.Zero, fmt.Errorf("parsing currencylayer response: %w", err)
+	}
+
+	if !parsed.Success {
+		return decimal.Zero, fmt.Errorf("currencylayer request failed: %s", parsed.Error.Info)
+	}
+
+	quoteKey := "USD" + string(currency)
+	usdToCurrency, ok := parsed.Quotes[quoteKey]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("currencylayer response has no quote for %s", quoteKey)
+	}
+	if usdToCurrency == 0 {
+		return decimal.Zero, fmt.Errorf("currencylayer returned a zero rate for %s", currency)
+	}
+
+	return decimal.NewFromInt(1).Div(decimal.NewFromFloat(usdToCurrency)), nil
+}
+
+// Name identifies this provider as "currencylayer" for source auditability.
+func (p *CurrencyLayerProvider) Name() string {
+	return "currencylayer"
+}
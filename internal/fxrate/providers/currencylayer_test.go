@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyLayerProvider_GetRate_USDIsAlwaysOne(t *testing.T) {
+	provider := NewCurrencyLayerProvider(http.DefaultClient, "test-access-key")
+
+	rate, err := provider.GetRate(domain.USD, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
+
+func TestCurrencyLayerProvider_GetRate_InvertsUSDBaseQuote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2024-01-15", r.URL.Query().Get("date"))
+		assert.Equal(t, "test-access-key", r.URL.Query().Get("access_key"))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"success":true,"source":"USD","quotes":{"USDBRL":5.0}}`)
+	}))
+	defer server.Close()
+
+	provider := NewCurrencyLayerProvider(http.DefaultClient, "test-access-key")
+	provider.baseURL = server.URL
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.2)), "expected 1/5.0 = 0.2, got %s", rate)
+}
+
+func TestCurrencyLayerProvider_GetRate_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"success":false,"error":{"info":"invalid access key"}}`)
+	}))
+	defer server.Close()
+
+	provider := NewCurrencyLayerProvider(http.DefaultClient, "bad-key")
+	provider.baseURL = server.URL
+
+	_, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
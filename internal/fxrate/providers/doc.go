@@ -0,0 +1,23 @@
+// Package providers contains concrete fxrate.Provider implementations for
+// real upstream rate sources: OpenExchangeRatesProvider, CurrencyLayerProvider,
+// and ExchangerateHostProvider (HTTP APIs with historical, date-keyed
+// endpoints), ECBProvider (the European Central Bank's historical reference
+// rates feed, quoted against EUR rather than USD), and CSVProvider (a local
+// date→rate table for offline/test environments).
+//
+// Each of these builds on fxrate.HTTPProvider or implements fxrate.Provider
+// directly, so they compose with the wrappers that already live in the
+// fxrate package: fxrate.NewChainProvider to fall back across sources,
+// fxrate.NewCachingProvider to memoize (currency, day) lookups, and
+// fxrate.NewRetryingProvider to back off on transient failures. A typical
+// wiring looks like:
+//
+//	oxr := providers.NewOpenExchangeRatesProvider(http.DefaultClient, appID)
+//	cl := providers.NewCurrencyLayerProvider(http.DefaultClient, apiKey)
+//	csv, err := providers.NewCSVProvider("fixtures/fx_rates.csv")
+//	service := fxrate.NewService(
+//		[]fxrate.Provider{oxr, cl, csv},
+//		fxrate.WithRetry(3, 250*time.Millisecond),
+//		fxrate.WithCache(time.Hour, 10000),
+//	)
+package providers
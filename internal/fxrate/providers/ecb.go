@@ -0,0 +1,230 @@
+package providers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultECBFeedURL is the European Central Bank's historical daily
+// reference rates feed, quoting every published currency against EUR for
+// every business day the ECB has published rates for.
+const DefaultECBFeedURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.xml"
+
+// DefaultECBLookbackDays bounds how many days ECBProvider.GetRate walks
+// backward from the requested date to find a published rate, used when
+// ECBProvider.LookbackDays isn't set. The ECB doesn't publish rates on
+// weekends or EU holidays, so a short lookback covers those gaps.
+const DefaultECBLookbackDays = 10
+
+// ecbEnvelope mirrors the root of the ECB's eurofxref-hist.xml feed:
+//
+//	<gesmes:Envelope>
+//	  <Cube>
+//	    <Cube time="2024-01-15">
+//	      <Cube currency="USD" rate="1.0950"/>
+//	      ...
+//	    </Cube>
+//	    ...
+//	  </Cube>
+//	</gesmes:Envelope>
+//
+// encoding/xml matches elements by local name when the struct tag carries no
+// namespace, so the gesmes namespace prefix on the root doesn't need to be
+// declared here.
+type ecbEnvelope struct {
+	XMLName xml.Name    `xml:"Envelope"`
+	Cube    ecbRootCube `xml:"Cube"`
+}
+
+type ecbRootCube struct {
+	Days []ecbDayCube `xml:"Cube"`
+}
+
+type ecbDayCube struct {
+	Time  string        `xml:"time,attr"`
+	Rates []ecbRateCube `xml:"Cube"`
+}
+
+type ecbRateCube struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+// ecbDay is a single published day's rates, each quoting 1 EUR in terms of
+// the listed currency.
+type ecbDay struct {
+	date        time.Time
+	ratesPerEUR map[domain.Currency]decimal.Decimal
+}
+
+// ECBProvider implements fxrate.Provider against the ECB's historical
+// reference rates feed. The feed quotes every currency against EUR rather
+// than USD, so GetRate derives the USD rate as ratesPerEUR[USD] /
+// ratesPerEUR[currency]: both sides of "1 EUR = N <currency>".
+//
+// The ECB doesn't publish every ISO 4217 currency (notably, of this
+// project's supported currencies, it quotes BRL and MXN but not ARS or
+// COP) — GetRate returns an error for currencies the feed has never quoted,
+// which a fxrate.ChainProvider can fall back past.
+//
+// The full feed is fetched and parsed once, lazily, on first use and then
+// held in memory; it does not retry or cache per-lookup on its own, so wrap
+// it in fxrate.NewRetryingProvider / fxrate.NewCachingProvider for
+// production use like the other providers in this package.
+type ECBProvider struct {
+	client  *http.Client
+	feedURL string
+
+	// LookbackDays bounds how many days GetRate walks backward looking for
+	// a published rate. Zero means DefaultECBLookbackDays.
+	LookbackDays int
+
+	mu     sync.Mutex
+	loaded bool
+	days   []ecbDay // sorted descending by date
+}
+
+// NewECBProvider creates a Provider backed by the ECB's historical
+// reference rates feed at feedURL.
+func NewECBProvider(client *http.Client, feedURL string) *ECBProvider {
+	return &ECBProvider{client: client, feedURL: feedURL}
+}
+
+// GetRate returns the exchange rate for converting from the specified
+// currency to USD, using the feed's most recently published rate on or
+// before date.
+func (p *ECBProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+
+	if err := p.ensureLoaded(); err != nil {
+		return decimal.Zero, err
+	}
+
+	lookback := p.LookbackDays
+	if lookback <= 0 {
+		lookback = DefaultECBLookbackDays
+	}
+
+	day := date.UTC().Truncate(24 * time.Hour)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for offset := 0; offset <= lookback; offset++ {
+		candidate := day.AddDate(0, 0, -offset)
+		rates, ok := p.ratesForDay(candidate)
+		if !ok {
+			continue
+		}
+
+		usdPerEUR, ok := rates[domain.USD]
+		if !ok {
+			continue
+		}
+		ccyPerEUR, ok := rates[currency]
+		if !ok || ccyPerEUR.IsZero() {
+			continue
+		}
+
+		return usdPerEUR.Div(ccyPerEUR), nil
+	}
+
+	return decimal.Zero, fmt.Errorf("no ECB rate for %s within %d days of %s", currency, lookback, day.Format("2006-01-02"))
+}
+
+// Name identifies this provider as "ecb" for source auditability.
+func (p *ECBProvider) Name() string {
+	return "ecb"
+}
+
+func (p *ECBProvider) ratesForDay(day time.Time) (map[domain.Currency]decimal.Decimal, bool) {
+	for _, d := range p.days {
+		if d.date.Equal(day) {
+			return d.ratesPerEUR, true
+		}
+	}
+	return nil, false
+}
+
+// ensureLoaded fetches and parses the feed on first use.
+func (p *ECBProvider) ensureLoaded() error {
+	p.mu.Lock()
+	if p.loaded {
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Unlock()
+
+	days, err := p.fetchDays()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.days = days
+	p.loaded = true
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *ECBProvider) fetchDays() ([]ecbDay, error) {
+	req, err := http.NewRequest(http.MethodGet, p.feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building ECB feed request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ECB feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading ECB feed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from ECB feed", resp.StatusCode)
+	}
+
+	return parseECBFeed(body)
+}
+
+func parseECBFeed(body []byte) ([]ecbDay, error) {
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("parsing ECB feed: %w", err)
+	}
+
+	days := make([]ecbDay, 0, len(envelope.Cube.Days))
+	for _, dayCube := range envelope.Cube.Days {
+		date, err := time.Parse("2006-01-02", dayCube.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q in ECB feed: %w", dayCube.Time, err)
+		}
+
+		rates := make(map[domain.Currency]decimal.Decimal, len(dayCube.Rates))
+		for _, rateCube := range dayCube.Rates {
+			rate, err := decimal.NewFromString(rateCube.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate %q for %s in ECB feed: %w", rateCube.Rate, rateCube.Currency, err)
+			}
+			rates[domain.Currency(rateCube.Currency)] = rate
+		}
+
+		days = append(days, ecbDay{date: date, ratesPerEUR: rates})
+	}
+
+	return days, nil
+}
@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testECBFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+  <gesmes:subject>Reference rates</gesmes:subject>
+  <Cube>
+    <Cube time="2024-01-15">
+      <Cube currency="USD" rate="1.0950"/>
+      <Cube currency="BRL" rate="5.3795"/>
+      <Cube currency="MXN" rate="18.3500"/>
+    </Cube>
+    <Cube time="2024-01-12">
+      <Cube currency="USD" rate="1.0940"/>
+      <Cube currency="BRL" rate="5.3600"/>
+    </Cube>
+  </Cube>
+</gesmes:Envelope>`
+
+func newTestECBServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestECBProvider_GetRate_USDIsAlwaysOne(t *testing.T) {
+	provider := NewECBProvider(http.DefaultClient, "http://unused")
+
+	rate, err := provider.GetRate(domain.USD, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
+
+func TestECBProvider_GetRate_DerivesUSDRateFromEURQuotes(t *testing.T) {
+	server := newTestECBServer(t, testECBFeed)
+	defer server.Close()
+
+	provider := NewECBProvider(http.DefaultClient, server.URL)
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	// 1 EUR = 1.0950 USD = 5.3795 BRL, so 1 BRL = 1.0950/5.3795 USD.
+	want := decimal.NewFromFloat(1.0950).Div(decimal.NewFromFloat(5.3795))
+	assert.True(t, rate.Equal(want), "expected %s, got %s", want, rate)
+}
+
+func TestECBProvider_GetRate_FallsBackToPriorPublishedDay(t *testing.T) {
+	server := newTestECBServer(t, testECBFeed)
+	defer server.Close()
+
+	provider := NewECBProvider(http.DefaultClient, server.URL)
+
+	// 2024-01-14 is a Sunday the ECB didn't publish for; the feed only has
+	// 2024-01-12 and 2024-01-15.
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	want := decimal.NewFromFloat(1.0940).Div(decimal.NewFromFloat(5.3600))
+	assert.True(t, rate.Equal(want), "expected the most recent prior published day (2024-01-12), got %s", rate)
+}
+
+func TestECBProvider_GetRate_UnquotedCurrency(t *testing.T) {
+	server := newTestECBServer(t, testECBFeed)
+	defer server.Close()
+
+	provider := NewECBProvider(http.DefaultClient, server.URL)
+
+	_, err := provider.GetRate(domain.ARS, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err, "the ECB feed never quotes ARS, so this must fail rather than silently return a wrong rate")
+}
+
+func TestECBProvider_GetRate_FeedFetchedOnce(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(testECBFeed))
+	}))
+	defer server.Close()
+
+	provider := NewECBProvider(http.DefaultClient, server.URL)
+
+	_, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	_, err = provider.GetRate(domain.MXN, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, requestCount, "the feed should be fetched once and reused for subsequent lookups")
+}
@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// defaultExchangerateHostBaseURL is exchangerate.host's historical-rates
+// endpoint, which accepts requests of the form
+// "<baseURL>/<YYYY-MM-DD>?base=USD&symbols=<currency>".
+const defaultExchangerateHostBaseURL = "https://api.exchangerate.host/historical"
+
+// exchangerateHostResponse is the subset of exchangerate.host's historical
+// response this provider needs: a USD-quoted rate for the requested
+// currency on the requested day.
+type exchangerateHostResponse struct {
+	Success bool               `json:"success"`
+	Date    string             `json:"date"`
+	Rates   map[string]float64 `json:"rates"`
+}
+
+// ExchangerateHostProvider implements fxrate.Provider against
+// exchangerate.host's historical endpoint. Unlike openexchangerates.org and
+// currencylayer, exchangerate.host accepts an explicit base currency, so
+// this provider requests base=USD directly and still inverts the result
+// (exchangerate.host quotes "1 USD = N <currency>"; fxrate.Provider wants
+// "1 <currency> = N USD").
+//
+// It does not retry or cache on its own; wrap it in fxrate.NewRetryingProvider
+// and fxrate.NewCachingProvider (or put it behind a fxrate.ChainProvider
+// alongside other sources) for production use.
+type ExchangerateHostProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewExchangerateHostProvider creates a Provider backed by
+// exchangerate.host's historical rates endpoint.
+func NewExchangerateHostProvider(client *http.Client) *ExchangerateHostProvider {
+	return &ExchangerateHostProvider{
+		client:  client,
+		baseURL: defaultExchangerateHostBaseURL,
+	}
+}
+
+// GetRate returns the exchange rate for converting from the specified
+// currency to USD on the given date.
+func (p *ExchangerateHostProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+
+	url := fmt.Sprintf("%s/%s?base=USD&symbols=%s", p.baseURL, date.UTC().Format("2006-01-02"), currency)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("building request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("unexpected status %d from exchangerate.host", resp.StatusCode)
+	}
+
+	var parsed exchangerateHostResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return decimal.Zero, fmt.Errorf("parsing response: %w", err)
+	}
+	if !parsed.Success {
+		return decimal.Zero, fmt.Errorf("exchangerate.host reported failure for %s on %s", currency, date.Format("2006-01-02"))
+	}
+
+	usdPerCurrency, ok := parsed.Rates[string(currency)]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no rate for %s in exchangerate.host response", currency)
+	}
+	if usdPerCurrency == 0 {
+		return decimal.Zero, fmt.Errorf("exchangerate.host returned a zero rate for %s", currency)
+	}
+
+	return decimal.NewFromInt(1).Div(decimal.NewFromFloat(usdPerCurrency)), nil
+}
+
+// Name identifies this provider as "exchangeratehost" for source
+// auditability.
+func (p *ExchangerateHostProvider) Name() string {
+	return "exchangeratehost"
+}
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangerateHostProvider_GetRate_USDIsAlwaysOne(t *testing.T) {
+	provider := NewExchangerateHostProvider(http.DefaultClient)
+
+	rate, err := provider.GetRate(domain.USD, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
+
+func TestExchangerateHostProvider_GetRate_InvertsUSDBaseQuote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2024-01-15", r.URL.Path)
+		assert.Equal(t, "USD", r.URL.Query().Get("base"))
+		assert.Equal(t, "BRL", r.URL.Query().Get("symbols"))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"success":true,"date":"2024-01-15","rates":{"BRL":5.0}}`)
+	}))
+	defer server.Close()
+
+	provider := NewExchangerateHostProvider(http.DefaultClient)
+	provider.baseURL = server.URL
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.2)), "expected 1/5.0 = 0.2, got %s", rate)
+}
+
+func TestExchangerateHostProvider_GetRate_MissingCurrency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"success":true,"date":"2024-01-15","rates":{}}`)
+	}))
+	defer server.Close()
+
+	provider := NewExchangerateHostProvider(http.DefaultClient)
+	provider.baseURL = server.URL
+
+	_, err := provider.GetRate(domain.ARS, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestExchangerateHostProvider_GetRate_ReportedFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"success":false}`)
+	}))
+	defer server.Close()
+
+	provider := NewExchangerateHostProvider(http.DefaultClient)
+	provider.baseURL = server.URL
+
+	_, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestExchangerateHostProvider_GetRate_UpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider := NewExchangerateHostProvider(http.DefaultClient)
+	provider.baseURL = server.URL
+
+	_, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
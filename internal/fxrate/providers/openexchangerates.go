@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// defaultOXRBaseURL is openexchangerates.org's historical-rates endpoint,
+// which accepts requests of the form "<baseURL>/<YYYY-MM-DD>.json?app_id=...".
+const defaultOXRBaseURL = "https://openexchangerates.org/api/historical"
+
+// oxrHistoricalResponse is the subset of openexchangerates.org's historical
+// response this provider needs: USD-quoted rates for every currency on the
+// requested day.
+type oxrHistoricalResponse struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// OpenExchangeRatesProvider implements fxrate.Provider against
+// openexchangerates.org's historical endpoint, which always quotes rates
+// from a USD base (1 USD = N <currency>). Since fxrate.Provider wants the
+// inverse (1 <currency> = N USD), GetRate inverts the quoted rate.
+//
+// It does not retry or cache on its own; wrap it in fxrate.NewRetryingProvider
+// and fxrate.NewCachingProvider (or put it behind a fxrate.ChainProvider
+// alongside other sources) for production use.
+type OpenExchangeRatesProvider struct {
+	client  *http.Client
+	baseURL string
+	appID   string
+}
+
+// NewOpenExchangeRatesProvider creates a Provider backed by
+// openexchangerates.org's historical rates endpoint, authenticating with
+// the given app ID.
+func NewOpenExchangeRatesProvider(client *http.Client, appID string) *OpenExchangeRatesProvider {
+	return &OpenExchangeRatesProvider{
+		client:  client,
+		baseURL: defaultOXRBaseURL,
+		appID:   appID,
+	}
+}
+
+// GetRate returns the exchange rate for converting from the specified
+// currency to USD on the given date.
+func (p *OpenExchangeRatesProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+
+	url := fmt.Sprintf("%s/%s.json?app_id=%s", p.baseURL, date.UTC().Format("2006-01-02"), p.appID)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("building openexchangerates request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("performing openexchangerates request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("reading openexchangerates response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("unexpected status %d from openexchangerates", resp.StatusCode)
+	}
+
+	var parsed oxrHistoricalResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return decimal.Zero, fmt.Errorf("parsing openexchangerates response: %w", err)
+	}
+
+	usdToCurrency, ok := parsed.Rates[string(currency)]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("openexchangerates response has no rate for %s", currency)
+	}
+	if usdToCurrency == 0 {
+		return decimal.Zero, fmt.Errorf("openexchangerates returned a zero rate for %s", currency)
+	}
+
+	return decimal.NewFromInt(1).Div(decimal.NewFromFloat(usdToCurrency)), nil
+}
+
+// Name identifies this provider as "openexchangerates" for source
+// auditability.
+func (p *OpenExchangeRatesProvider) Name() string {
+	return "openexchangerates"
+}
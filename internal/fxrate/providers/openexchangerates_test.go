@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenExchangeRatesProvider_GetRate_USDIsAlwaysOne(t *testing.T) {
+	provider := NewOpenExchangeRatesProvider(http.DefaultClient, "test-app-id")
+
+	rate, err := provider.GetRate(domain.USD, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
+
+func TestOpenExchangeRatesProvider_GetRate_InvertsUSDBaseQuote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2024-01-15.json", r.URL.Path)
+		assert.Equal(t, "test-app-id", r.URL.Query().Get("app_id"))
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"base":"USD","rates":{"BRL":5.0,"ARS":833.33}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenExchangeRatesProvider(http.DefaultClient, "test-app-id")
+	provider.baseURL = server.URL
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.2)), "expected 1/5.0 = 0.2, got %s", rate)
+}
+
+func TestOpenExchangeRatesProvider_GetRate_MissingCurrency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"base":"USD","rates":{"BRL":5.0}}`)
+	}))
+	defer server.Close()
+
+	provider := NewOpenExchangeRatesProvider(http.DefaultClient, "test-app-id")
+	provider.baseURL = server.URL
+
+	_, err := provider.GetRate(domain.ARS, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestOpenExchangeRatesProvider_GetRate_UpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider := NewOpenExchangeRatesProvider(http.DefaultClient, "test-app-id")
+	provider.baseURL = server.URL
+
+	_, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
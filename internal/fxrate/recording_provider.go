@@ -0,0 +1,90 @@
+package fxrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// RecordingProvider wraps a Provider, capturing every (currency, day, rate)
+// it returns so the run can be replayed later via NewReplayProvider - e.g.
+// record one run against a live or mock provider, commit the fixture Save
+// writes, and assert against it in a test that no longer depends on the
+// wrapped provider's live/simulated behavior.
+type RecordingProvider struct {
+	provider Provider
+
+	mu      sync.Mutex
+	records map[replayKey]decimal.Decimal
+}
+
+// NewRecordingProvider wraps provider, recording every rate it serves.
+func NewRecordingProvider(provider Provider) *RecordingProvider {
+	return &RecordingProvider{
+		provider: provider,
+		records:  make(map[replayKey]decimal.Decimal),
+	}
+}
+
+// GetRate delegates to the wrapped provider and records the result before
+// returning it. A failed lookup isn't recorded.
+func (r *RecordingProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	rate, err := r.provider.GetRate(currency, date)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	key := replayKey{currency: currency, day: date.UTC().Format("2006-01-02")}
+	r.mu.Lock()
+	r.records[key] = rate
+	r.mu.Unlock()
+
+	return rate, nil
+}
+
+// Name identifies this provider as "recording:<wrapped>" for source
+// auditability.
+func (r *RecordingProvider) Name() string {
+	return fmt.Sprintf("recording:%s", providerName(r.provider))
+}
+
+// Save writes every rate recorded so far to path as a JSON fixture loadable
+// by NewReplayProvider, overwriting any existing file. Records are sorted by
+// (day, currency) so re-recording unchanged rates produces a byte-identical
+// file instead of a reordered diff.
+func (r *RecordingProvider) Save(path string) error {
+	r.mu.Lock()
+	records := make([]replayRecord, 0, len(r.records))
+	for key, rate := range r.records {
+		records = append(records, replayRecord{
+			Date:     key.day,
+			Currency: key.currency.String(),
+			Rate:     rate.String(),
+		})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Date != records[j].Date {
+			return records[i].Date < records[j].Date
+		}
+		return records[i].Currency < records[j].Currency
+	})
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recorded rates: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write replay fixture: %w", err)
+	}
+
+	return nil
+}
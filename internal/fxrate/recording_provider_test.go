@@ -0,0 +1,46 @@
+package fxrate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingProvider_GetRate_DelegatesAndRecords(t *testing.T) {
+	wrapped := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	recording := NewRecordingProvider(wrapped)
+
+	rate, err := recording.GetRate(domain.BRL, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+	assert.Equal(t, int32(1), wrapped.calls)
+}
+
+func TestRecordingProvider_Save_ProducesFixtureReplayProviderCanLoad(t *testing.T) {
+	mock := NewMockProvider()
+	recording := NewRecordingProvider(mock)
+
+	date := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	want, err := recording.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "recorded.json")
+	require.NoError(t, recording.Save(path))
+
+	replay, err := NewReplayProvider(path)
+	require.NoError(t, err)
+
+	got, err := replay.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(want), "replayed rate %s should exactly match the recorded rate %s", got, want)
+}
+
+func TestRecordingProvider_Name_IdentifiesWrappedProvider(t *testing.T) {
+	recording := NewRecordingProvider(NewMockProvider())
+	assert.Equal(t, "recording:mock", recording.Name())
+}
@@ -0,0 +1,136 @@
+package fxrate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// replayKey identifies a loaded rate by currency and calendar day.
+type replayKey struct {
+	currency domain.Currency
+	day      string // YYYY-MM-DD
+}
+
+// replayRecord is the on-disk representation of one (date, currency, rate)
+// tuple, shared by ReplayProvider's loader and RecordingProvider's writer so
+// a recorded fixture round-trips through ReplayProvider unchanged.
+type replayRecord struct {
+	Date     string `json:"date"`
+	Currency string `json:"currency"`
+	Rate     string `json:"rate"`
+}
+
+// ReplayProvider implements Provider by loading a fixed table of
+// (date, currency, rate) tuples from a fixture file, returning the exact
+// rate on file rather than a simulated or live one. It's meant for
+// regression tests that need byte-exact settlement output: record a real or
+// mock provider's output once with RecordingProvider, then replay that same
+// fixture on every subsequent test run so the asserted totals never drift
+// with upstream rate changes or MockProvider's date-based volatility.
+//
+// The fixture format is chosen by path's extension: ".json" loads a JSON
+// array of {"date", "currency", "rate"} objects (RecordingProvider.Save's
+// format); anything else loads a CSV file with header "date,currency,rate".
+type ReplayProvider struct {
+	rates map[replayKey]decimal.Decimal
+}
+
+// NewReplayProvider loads the fixture file at path. See ReplayProvider for
+// the supported formats.
+func NewReplayProvider(path string) (*ReplayProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay fixture: %w", err)
+	}
+
+	var records []replayRecord
+	if strings.HasSuffix(path, ".json") {
+		records, err = parseReplayJSON(data)
+	} else {
+		records, err = parseReplayCSV(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse replay fixture %s: %w", path, err)
+	}
+
+	rates := make(map[replayKey]decimal.Decimal, len(records))
+	for _, record := range records {
+		date, err := time.Parse("2006-01-02", record.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q in replay fixture: %w", record.Date, err)
+		}
+		rate, err := decimal.NewFromString(record.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %q in replay fixture: %w", record.Rate, err)
+		}
+		rates[replayKey{currency: domain.Currency(record.Currency), day: date.Format("2006-01-02")}] = rate
+	}
+
+	return &ReplayProvider{rates: rates}, nil
+}
+
+func parseReplayJSON(data []byte) ([]replayRecord, error) {
+	var records []replayRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func parseReplayCSV(data []byte) ([]replayRecord, error) {
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if len(header) != 3 || header[0] != "date" || header[1] != "currency" || header[2] != "rate" {
+		return nil, fmt.Errorf("header must be \"date,currency,rate\", got %v", header)
+	}
+
+	var records []replayRecord
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		if len(row) != 3 {
+			return nil, fmt.Errorf("row has %d fields, expected 3: %v", len(row), row)
+		}
+		records = append(records, replayRecord{Date: row[0], Currency: row[1], Rate: row[2]})
+	}
+	return records, nil
+}
+
+// GetRate returns the fixture's rate for (currency, date's day), ignoring
+// the time-of-day component of date. Returns an error if no row matches.
+func (p *ReplayProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+
+	key := replayKey{currency: currency, day: date.UTC().Format("2006-01-02")}
+	rate, ok := p.rates[key]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no replay fixture entry for %s on %s", currency, key.day)
+	}
+	return rate, nil
+}
+
+// Name identifies this provider as "replay" for source auditability.
+func (p *ReplayProvider) Name() string {
+	return "replay"
+}
@@ -0,0 +1,84 @@
+package fxrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayProvider_GetRate_USDIsAlwaysOne(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[]`), 0644))
+
+	provider, err := NewReplayProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(domain.USD, time.Now())
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromInt(1)))
+}
+
+func TestReplayProvider_GetRate_LoadsJSONFixtureRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	fixture := `[{"date":"2024-01-15","currency":"BRL","rate":"0.20"},{"date":"2024-01-16","currency":"BRL","rate":"0.19"}]`
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0644))
+
+	provider, err := NewReplayProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+}
+
+func TestReplayProvider_GetRate_LoadsCSVFixtureRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	fixture := "date,currency,rate\n2024-01-15,BRL,0.20\n"
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0644))
+
+	provider, err := NewReplayProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(domain.BRL, time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+}
+
+func TestReplayProvider_GetRate_MissingRowReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"date":"2024-01-15","currency":"BRL","rate":"0.20"}]`), 0644))
+
+	provider, err := NewReplayProvider(path)
+	require.NoError(t, err)
+
+	_, err = provider.GetRate(domain.BRL, time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestReplayProvider_New_MissingFile(t *testing.T) {
+	_, err := NewReplayProvider("/no/such/fixture.json")
+	assert.Error(t, err)
+}
+
+func TestReplayProvider_New_InvalidCSVHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	require.NoError(t, os.WriteFile(path, []byte("day,ccy,value\n"), 0644))
+
+	_, err := NewReplayProvider(path)
+	assert.Error(t, err)
+}
+
+func TestReplayProvider_Name(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[]`), 0644))
+
+	provider, err := NewReplayProvider(path)
+	require.NoError(t, err)
+	assert.Equal(t, "replay", provider.Name())
+}
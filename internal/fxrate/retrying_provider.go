@@ -0,0 +1,98 @@
+package fxrate
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultRetryAttempts is how many GetRate calls a RetryingProvider makes in
+// total, including the first, before giving up, used when
+// RetryingProvider.MaxAttempts isn't set.
+const DefaultRetryAttempts = 3
+
+// DefaultRetryBaseDelay is the base delay a RetryingProvider backs off by
+// before jitter is applied, used when RetryingProvider.BaseDelay isn't set.
+const DefaultRetryBaseDelay = 100 * time.Millisecond
+
+// RetryingProvider wraps a Provider and retries a failing GetRate call with
+// exponential backoff plus full jitter, so a provider sitting behind a
+// flaky upstream (rate limiting, transient network errors) doesn't fail a
+// whole settlement run over a single blip. It's meant to sit outside a
+// CachingProvider's retry-free fast path but inside a ChainProvider, so a
+// persistently failing provider still falls through to the next one in the
+// chain once its own retries are exhausted.
+type RetryingProvider struct {
+	provider Provider
+
+	// MaxAttempts is the number of GetRate calls made before giving up,
+	// including the first one. Zero means DefaultRetryAttempts.
+	MaxAttempts int
+	// BaseDelay is the backoff base; attempt N waits a random duration in
+	// [0, BaseDelay*2^(N-1)]. Zero means DefaultRetryBaseDelay.
+	BaseDelay time.Duration
+
+	// sleep is overridden in tests to avoid real delays.
+	sleep func(time.Duration)
+}
+
+// NewRetryingProvider wraps provider with exponential-backoff-with-jitter
+// retries, using maxAttempts total attempts and baseDelay as the backoff
+// base. A zero maxAttempts or baseDelay falls back to DefaultRetryAttempts /
+// DefaultRetryBaseDelay respectively.
+func NewRetryingProvider(provider Provider, maxAttempts int, baseDelay time.Duration) *RetryingProvider {
+	return &RetryingProvider{
+		provider:    provider,
+		MaxAttempts: maxAttempts,
+		BaseDelay:   baseDelay,
+		sleep:       time.Sleep,
+	}
+}
+
+// GetRate calls the wrapped Provider, retrying on error with exponential
+// backoff and full jitter until MaxAttempts is reached. It returns the last
+// error seen if every attempt fails.
+func (r *RetryingProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	quote, err := r.GetRateWithSource(currency, date)
+	return quote.Rate, err
+}
+
+// GetRateWithSource is GetRate's SourcedProvider counterpart: it applies the
+// same retry/backoff loop and returns the rate annotated with the wrapped
+// provider's source.
+func (r *RetryingProvider) GetRateWithSource(currency domain.Currency, date time.Time) (RateQuote, error) {
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultRetryAttempts
+	}
+	baseDelay := r.BaseDelay
+	if baseDelay <= 0 {
+		baseDelay = DefaultRetryBaseDelay
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			max := baseDelay * time.Duration(1<<uint(attempt-1))
+			r.sleepFor(time.Duration(rand.Int63n(int64(max) + 1)))
+		}
+
+		quote, err := getRateWithSource(r.provider, currency, date)
+		if err == nil {
+			return quote, nil
+		}
+		lastErr = err
+	}
+
+	return RateQuote{}, lastErr
+}
+
+func (r *RetryingProvider) sleepFor(d time.Duration) {
+	if r.sleep != nil {
+		r.sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
@@ -0,0 +1,33 @@
+package fxrate
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryingProvider_SucceedsAfterTransientErrors(t *testing.T) {
+	provider := &flakyProvider{failuresBeforeSuccess: 2, rate: decimal.NewFromFloat(0.20)}
+	retrying := NewRetryingProvider(provider, 5, time.Millisecond)
+	retrying.sleep = func(time.Duration) {} // don't actually wait in tests
+
+	rate, err := retrying.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&provider.calls))
+}
+
+func TestRetryingProvider_GivesUpAfterMaxAttempts(t *testing.T) {
+	provider := &flakyProvider{failuresBeforeSuccess: 10, rate: decimal.NewFromFloat(0.20)}
+	retrying := NewRetryingProvider(provider, 3, time.Millisecond)
+	retrying.sleep = func(time.Duration) {}
+
+	_, err := retrying.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&provider.calls))
+}
@@ -2,42 +2,333 @@ package fxrate
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
 	"github.com/shopspring/decimal"
 )
 
+// defaultMaxHops bounds how many intermediate currencies ConvertToUSD will
+// traverse when no direct <CCY>→USD rate is available.
+const defaultMaxHops = 3
+
 // Service provides foreign exchange rate conversion functionality
 type Service struct {
 	provider Provider
+
+	// MaxHops limits how many edges a multi-hop conversion path may use when
+	// the provider has no direct rate to USD. Defaults to defaultMaxHops.
+	MaxHops int
+}
+
+// serviceConfig collects the options NewService applies on top of the
+// caller's providers, in the order they're assembled in NewService:
+// chain-of-fallback (when several providers are given), then retry, then
+// cache.
+type serviceConfig struct {
+	cacheTTL   time.Duration
+	cacheSize  int
+	retryMax   int
+	retryDelay time.Duration
+	withCache  bool
+	withRetry  bool
+}
+
+// ServiceOption configures optional caching and retry behavior on NewService.
+type ServiceOption func(*serviceConfig)
+
+// WithCache wraps every provider in a CachingProvider keyed by (currency,
+// date-truncated-to-day), so a settlement run over many transactions on the
+// same day issues at most one upstream lookup per currency per day. A zero
+// ttl or maxEntries falls back to DefaultCacheTTL / DefaultCacheSize.
+func WithCache(ttl time.Duration, maxEntries int) ServiceOption {
+	return func(cfg *serviceConfig) {
+		cfg.withCache = true
+		cfg.cacheTTL = ttl
+		cfg.cacheSize = maxEntries
+	}
+}
+
+// WithRetry wraps every provider in a RetryingProvider that retries failed
+// lookups with exponential backoff and jitter before falling through to the
+// next provider in the chain. A zero maxAttempts or baseDelay falls back to
+// DefaultRetryAttempts / DefaultRetryBaseDelay.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ServiceOption {
+	return func(cfg *serviceConfig) {
+		cfg.withRetry = true
+		cfg.retryMax = maxAttempts
+		cfg.retryDelay = baseDelay
+	}
 }
 
-// NewService creates a new FX rate service with the given provider
-func NewService(provider Provider) *Service {
+// NewService creates a new FX rate service backed by the given providers.
+// A single provider is used as-is, preserving its concrete type so that
+// ConvertToUSDWithPath can still type-assert it as a GraphProvider for
+// multi-hop conversion; multiple providers are combined into a ChainProvider
+// that tries them in order and falls back on error. WithCache and WithRetry
+// wrap the resulting provider so that, combined with a ChainProvider of
+// upstream sources, a batch of 100k+ transactions issues at most one
+// upstream call per (currency, day).
+func NewService(providers []Provider, opts ...ServiceOption) *Service {
+	var cfg serviceConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var provider Provider
+	switch len(providers) {
+	case 0:
+		provider = NewMockProvider()
+	case 1:
+		provider = providers[0]
+	default:
+		provider = NewChainProvider(providers...)
+	}
+
+	if cfg.withRetry {
+		provider = NewRetryingProvider(provider, cfg.retryMax, cfg.retryDelay)
+	}
+	if cfg.withCache {
+		provider = NewCachingProvider(provider, cfg.cacheTTL, cfg.cacheSize)
+	}
+
 	return &Service{
 		provider: provider,
+		MaxHops:  defaultMaxHops,
 	}
 }
 
+// ConversionResult describes how a transaction amount was converted to USD,
+// including the chain of currencies the conversion passed through. For a
+// direct conversion, Path is simply [tx.Currency, USD]; for a multi-hop
+// conversion it lists every intermediate currency, e.g. [COP, BRL, USD].
+type ConversionResult struct {
+	USDAmount decimal.Decimal
+	Rate      decimal.Decimal
+	Path      []domain.Currency
+
+	// Source identifies which upstream provider served Rate (see
+	// NamedProvider), for audit trails on settlement reports. Empty for USD
+	// transactions, which never consult a provider.
+	Source string
+}
+
 // ConvertToUSD converts the given transaction to USD using the appropriate
 // exchange rate for the transaction's date and currency
 func (s *Service) ConvertToUSD(tx *domain.Transaction) (decimal.Decimal, decimal.Decimal, error) {
-	// Validate the currency
+	result, err := s.ConvertToUSDWithPath(tx)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	return result.USDAmount, result.Rate, nil
+}
+
+// ConvertToUSDWithPath converts the given transaction to USD like ConvertToUSD,
+// but also returns the conversion path. When the provider has a direct
+// <currency>→USD rate, the path is the single edge [currency, USD]. When it
+// doesn't and the provider implements GraphProvider, ConvertToUSDWithPath
+// searches for a path through intermediate currencies (e.g. COP→BRL→USD),
+// bounded by s.MaxHops, and returns the product of rates along that path.
+func (s *Service) ConvertToUSDWithPath(tx *domain.Transaction) (*ConversionResult, error) {
 	if err := tx.Currency.Validate(); err != nil {
-		return decimal.Zero, decimal.Zero, fmt.Errorf("invalid currency: %w", err)
+		return nil, fmt.Errorf("invalid currency: %w", err)
+	}
+
+	rate, path, source, err := s.rateToUSDWithSource(tx.Currency, tx.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConversionResult{
+		USDAmount: tx.OriginalAmount.Mul(rate).RoundBank(domain.USD.Exponent()),
+		Rate:      rate,
+		Path:      path,
+		Source:    source,
+	}, nil
+}
+
+// rateToUSD returns the rate (and the path it was derived through) for
+// converting 1 unit of currency into USD. It's a thin wrapper over
+// rateToUSDWithSource for callers (Convert) that don't need provider
+// attribution.
+func (s *Service) rateToUSD(currency domain.Currency, date time.Time) (decimal.Decimal, []domain.Currency, error) {
+	rate, path, _, err := s.rateToUSDWithSource(currency, date)
+	return rate, path, err
+}
+
+// rateToUSDWithSource returns the rate, path, and serving provider's name for
+// converting 1 unit of currency into USD. It tries the provider's direct
+// rate first - the common case, and what keeps existing providers (e.g.
+// MockProvider) working exactly as before - and falls back to a multi-hop
+// GraphProvider search when no direct rate is available. For a multi-hop
+// path, Source names the GraphProvider whose own edges were combined to
+// derive the rate, since it's still the single upstream responsible for
+// every edge even though the rate itself isn't one of its direct quotes.
+func (s *Service) rateToUSDWithSource(currency domain.Currency, date time.Time) (decimal.Decimal, []domain.Currency, string, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), []domain.Currency{domain.USD}, "", nil
+	}
+
+	quote, directErr := getRateWithSource(s.provider, currency, date)
+	if directErr == nil {
+		return quote.Rate, []domain.Currency{currency, domain.USD}, quote.Source, nil
+	}
+
+	graphProvider, ok := s.provider.(GraphProvider)
+	if !ok {
+		return decimal.Zero, nil, "", fmt.Errorf("failed to get FX rate: %w", directErr)
+	}
+
+	path, pathRate, err := s.findConversionPath(graphProvider, currency, date)
+	if err != nil {
+		return decimal.Zero, nil, "", fmt.Errorf("failed to get FX rate: %w", directErr)
+	}
+
+	return pathRate, path, providerName(s.provider), nil
+}
+
+// Quote describes how Convert derived the rate between two currencies:
+// directly from the provider (to == USD), by inverting a known inverse
+// quote (from == USD), or by triangulating through USD for an arbitrary
+// pair. Path lists every currency the derivation passed through, e.g.
+// [BRL, USD, MXN] for a triangulated BRL→MXN quote.
+type Quote struct {
+	Amount decimal.Decimal
+	Rate   decimal.Decimal
+	Path   []domain.Currency
+}
+
+// Convert converts amount from one currency to another on the given date,
+// returning the converted amount alongside a Quote describing how the rate
+// was derived. Unlike ConvertToUSD, the target currency doesn't have to be
+// USD:
+//   - from == to: the rate is 1, no conversion needed.
+//   - to == USD: direct (or multi-hop) conversion, same as ConvertToUSD.
+//   - from == USD: the USD→to rate is derived by inverting the known
+//     to→USD rate (rate(A→B) = 1 / rate(B→A)).
+//   - otherwise: the rate is triangulated through USD
+//     (rate(A→B) = rate(A→USD) / rate(B→USD)).
+//
+// This is what lets settlement report a balance in any supported currency,
+// e.g. a BRL supplier's settlement expressed in MXN, rather than only USD.
+func (s *Service) Convert(from, to domain.Currency, amount decimal.Decimal, date time.Time) (*Quote, error) {
+	if err := from.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid source currency: %w", err)
+	}
+	if err := to.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid target currency: %w", err)
+	}
+
+	if from == to {
+		return &Quote{Amount: amount, Rate: decimal.NewFromInt(1), Path: []domain.Currency{from}}, nil
+	}
+
+	fromRate, fromPath, err := s.rateToUSD(from, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if to == domain.USD {
+		return &Quote{
+			Amount: amount.Mul(fromRate).RoundBank(to.Exponent()),
+			Rate:   fromRate,
+			Path:   fromPath,
+		}, nil
+	}
+
+	toRate, toPath, err := s.rateToUSD(to, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == domain.USD {
+		rate := decimal.NewFromInt(1).Div(toRate)
+		return &Quote{
+			Amount: amount.Mul(rate).RoundBank(to.Exponent()),
+			Rate:   rate,
+			Path:   reverseCurrencyPath(toPath),
+		}, nil
 	}
 
-	// Get the FX rate for the transaction date
-	rate, err := s.provider.GetRate(tx.Currency, tx.Timestamp)
+	rate := fromRate.Div(toRate)
+	reversedToPath := reverseCurrencyPath(toPath)
+	path := append(append([]domain.Currency{}, fromPath...), reversedToPath[1:]...)
+
+	return &Quote{
+		Amount: amount.Mul(rate).RoundBank(to.Exponent()),
+		Rate:   rate,
+		Path:   path,
+	}, nil
+}
+
+// reverseCurrencyPath returns path reversed, e.g. [MXN, USD] -> [USD, MXN].
+func reverseCurrencyPath(path []domain.Currency) []domain.Currency {
+	reversed := make([]domain.Currency, len(path))
+	for i, c := range path {
+		reversed[len(path)-1-i] = c
+	}
+	return reversed
+}
+
+// findConversionPath searches the provider's known direct exchange rates for
+// a path from source to USD, treating each rate as a directed edge weighted
+// by -log(rate) and expanding hop-by-hop (a bounded Bellman-Ford relaxation,
+// which tolerates the negative edge weights that arise whenever a currency
+// appreciates against the one it's quoted in). It keeps the cheapest path
+// found to each currency and stops once MaxHops edges have been explored.
+func (s *Service) findConversionPath(gp GraphProvider, source domain.Currency, date time.Time) ([]domain.Currency, decimal.Decimal, error) {
+	edges, err := gp.Rates(date)
 	if err != nil {
-		return decimal.Zero, decimal.Zero, fmt.Errorf("failed to get FX rate: %w", err)
+		return nil, decimal.Zero, fmt.Errorf("failed to load rate graph: %w", err)
+	}
+
+	maxHops := s.MaxHops
+	if maxHops <= 0 {
+		maxHops = defaultMaxHops
+	}
+
+	type path struct {
+		cost float64
+		path []domain.Currency
 	}
 
-	// Convert to USD
-	usdAmount := tx.OriginalAmount.Mul(rate)
+	best := map[domain.Currency]path{source: {cost: 0, path: []domain.Currency{source}}}
+	frontier := []domain.Currency{source}
+
+	for hop := 0; hop < maxHops && len(frontier) > 0; hop++ {
+		var next []domain.Currency
+
+		for _, from := range frontier {
+			current := best[from]
+
+			for pair, rate := range edges {
+				if pair.From != from || !rate.IsPositive() {
+					continue
+				}
+
+				weight := -math.Log(rate.InexactFloat64())
+				candidateCost := current.cost + weight
+
+				if existing, seen := best[pair.To]; seen && existing.cost <= candidateCost {
+					continue
+				}
+
+				extended := append(append([]domain.Currency{}, current.path...), pair.To)
+				best[pair.To] = path{cost: candidateCost, path: extended}
+				next = append(next, pair.To)
+			}
+		}
+
+		frontier = next
+	}
+
+	target, ok := best[domain.USD]
+	if !ok {
+		return nil, decimal.Zero, fmt.Errorf("no conversion path from %s to USD within %d hops", source, maxHops)
+	}
 
-	return usdAmount, rate, nil
+	return target.path, decimal.NewFromFloat(math.Exp(-target.cost)), nil
 }
 
 // GetRate retrieves the FX rate for a given currency and date
@@ -1,24 +1,27 @@
 package fxrate
 
 import (
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewService(t *testing.T) {
 	provider := NewMockProvider()
-	service := NewService(provider)
+	service := NewService([]Provider{provider})
 	assert.NotNil(t, service)
 	assert.NotNil(t, service.provider)
 }
 
 func TestService_ConvertToUSD(t *testing.T) {
 	provider := NewMockProvider()
-	service := NewService(provider)
+	service := NewService([]Provider{provider})
 
 	tests := []struct {
 		name           string
@@ -114,7 +117,7 @@ func TestService_ConvertToUSD(t *testing.T) {
 
 func TestService_ConvertToUSD_Deterministic(t *testing.T) {
 	provider := NewMockProvider()
-	service := NewService(provider)
+	service := NewService([]Provider{provider})
 
 	tx := &domain.Transaction{
 		ID:             "tx1",
@@ -137,3 +140,239 @@ func TestService_ConvertToUSD_Deterministic(t *testing.T) {
 	assert.True(t, usd1.Equal(usd2), "USD amounts should be equal")
 	assert.True(t, rate1.Equal(rate2), "rates should be equal")
 }
+
+// noDirectUSDProvider is a GraphProvider with no direct <currency>→USD rate
+// for ARS, forcing Service to find a multi-hop path through BRL instead.
+type noDirectUSDProvider struct{}
+
+func (p *noDirectUSDProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	switch currency {
+	case domain.USD:
+		return decimal.NewFromInt(1), nil
+	case domain.BRL:
+		return decimal.NewFromFloat(0.20), nil
+	default:
+		return decimal.Zero, fmt.Errorf("no direct rate for %s", currency)
+	}
+}
+
+func (p *noDirectUSDProvider) Rates(date time.Time) (map[CurrencyPair]decimal.Decimal, error) {
+	return map[CurrencyPair]decimal.Decimal{
+		{From: domain.ARS, To: domain.BRL}: decimal.NewFromFloat(0.006), // 1 ARS = 0.006 BRL
+		{From: domain.BRL, To: domain.USD}: decimal.NewFromFloat(0.20), // 1 BRL = 0.20 USD
+	}, nil
+}
+
+func TestService_ConvertToUSDWithPath_MultiHop(t *testing.T) {
+	service := NewService([]Provider{&noDirectUSDProvider{}})
+
+	tx := &domain.Transaction{
+		ID:             "tx1",
+		SupplierID:     "sup1",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(1000),
+		Currency:       domain.ARS,
+		Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Status:         domain.Completed,
+	}
+
+	result, err := service.ConvertToUSDWithPath(tx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Currency{domain.ARS, domain.BRL, domain.USD}, result.Path)
+	assert.Equal(t, "*fxrate.noDirectUSDProvider", result.Source, "multi-hop source names the GraphProvider whose edges derived the rate")
+
+	// 1000 ARS * 0.006 BRL/ARS * 0.20 USD/BRL = 1.2 USD
+	expected := decimal.NewFromFloat(1.2)
+	diff := result.USDAmount.Sub(expected).Abs()
+	assert.True(t, diff.LessThan(decimal.NewFromFloat(0.01)), "expected ~%s USD, got %s", expected, result.USDAmount)
+}
+
+func TestService_ConvertToUSDWithPath_DirectRatePopulatesSource(t *testing.T) {
+	service := NewService([]Provider{NewMockProvider()})
+
+	tx := &domain.Transaction{
+		ID:             "tx1",
+		SupplierID:     "sup1",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(100),
+		Currency:       domain.BRL,
+		Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Status:         domain.Completed,
+	}
+
+	result, err := service.ConvertToUSDWithPath(tx)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", result.Source)
+}
+
+// deadEndProvider is a GraphProvider whose only edge leads away from USD,
+// so no path to USD exists at any hop count.
+type deadEndProvider struct{}
+
+func (p *deadEndProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+	return decimal.Zero, fmt.Errorf("no direct rate for %s", currency)
+}
+
+func (p *deadEndProvider) Rates(date time.Time) (map[CurrencyPair]decimal.Decimal, error) {
+	return map[CurrencyPair]decimal.Decimal{
+		{From: domain.ARS, To: domain.COP}: decimal.NewFromFloat(0.21),
+	}, nil
+}
+
+func TestService_ConvertToUSDWithPath_NoPathFound(t *testing.T) {
+	service := NewService([]Provider{&deadEndProvider{}})
+
+	tx := &domain.Transaction{
+		ID:             "tx1",
+		SupplierID:     "sup1",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(1000),
+		Currency:       domain.ARS,
+		Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Status:         domain.Completed,
+	}
+
+	_, err := service.ConvertToUSDWithPath(tx)
+	assert.Error(t, err)
+}
+
+// countingProvider counts how many times GetRate is called, so tests can
+// assert caching actually suppresses upstream lookups.
+type countingProvider struct {
+	calls int32
+	rate  decimal.Decimal
+}
+
+func (p *countingProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	atomic.AddInt32(&p.calls, 1)
+	return p.rate, nil
+}
+
+func TestNewService_MultipleProvidersBuildsChain(t *testing.T) {
+	failing := &noDirectUSDProvider{}
+	mock := NewMockProvider()
+	service := NewService([]Provider{failing, mock})
+
+	rate, err := service.GetRate(domain.ARS, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.GreaterThan(decimal.Zero))
+}
+
+func TestNewService_WithCache_DeduplicatesUpstreamCalls(t *testing.T) {
+	provider := &countingProvider{rate: decimal.NewFromFloat(0.20)}
+	service := NewService([]Provider{provider}, WithCache(time.Hour, 100))
+
+	date := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	sameDayLater := time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)
+
+	_, err := service.GetRate(domain.BRL, date)
+	require.NoError(t, err)
+	_, err = service.GetRate(domain.BRL, sameDayLater)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+}
+
+func TestNewService_WithRetry_RetriesOnError(t *testing.T) {
+	provider := &flakyProvider{failuresBeforeSuccess: 2, rate: decimal.NewFromFloat(0.20)}
+	service := NewService([]Provider{provider}, WithRetry(5, time.Millisecond))
+
+	rate, err := service.GetRate(domain.BRL, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, rate.Equal(decimal.NewFromFloat(0.20)))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&provider.calls))
+}
+
+// flakyProvider fails the first failuresBeforeSuccess calls, then succeeds.
+type flakyProvider struct {
+	calls                 int32
+	failuresBeforeSuccess int32
+	rate                  decimal.Decimal
+}
+
+func (p *flakyProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	n := atomic.AddInt32(&p.calls, 1)
+	if n <= p.failuresBeforeSuccess {
+		return decimal.Zero, fmt.Errorf("transient upstream error")
+	}
+	return p.rate, nil
+}
+
+func TestService_Convert_SameCurrencyIsIdentity(t *testing.T) {
+	service := NewService([]Provider{NewMockProvider()})
+
+	quote, err := service.Convert(domain.BRL, domain.BRL, decimal.NewFromFloat(100), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, quote.Rate.Equal(decimal.NewFromInt(1)))
+	assert.True(t, quote.Amount.Equal(decimal.NewFromFloat(100)))
+	assert.Equal(t, []domain.Currency{domain.BRL}, quote.Path)
+}
+
+func TestService_Convert_ToUSDIsDirect(t *testing.T) {
+	provider := &fixedRateProvider{rates: map[domain.Currency]decimal.Decimal{domain.BRL: decimal.NewFromFloat(0.20)}}
+	service := NewService([]Provider{provider})
+
+	quote, err := service.Convert(domain.BRL, domain.USD, decimal.NewFromFloat(100), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, quote.Rate.Equal(decimal.NewFromFloat(0.20)))
+	assert.True(t, quote.Amount.Equal(decimal.NewFromFloat(20)))
+	assert.Equal(t, []domain.Currency{domain.BRL, domain.USD}, quote.Path)
+}
+
+func TestService_Convert_FromUSDIsInverted(t *testing.T) {
+	provider := &fixedRateProvider{rates: map[domain.Currency]decimal.Decimal{domain.BRL: decimal.NewFromFloat(0.20)}}
+	service := NewService([]Provider{provider})
+
+	quote, err := service.Convert(domain.USD, domain.BRL, decimal.NewFromFloat(20), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, quote.Rate.Equal(decimal.NewFromFloat(5)), "expected 1/0.20 = 5, got %s", quote.Rate)
+	assert.True(t, quote.Amount.Equal(decimal.NewFromFloat(100)))
+	assert.Equal(t, []domain.Currency{domain.USD, domain.BRL}, quote.Path)
+}
+
+func TestService_Convert_CrossPairTriangulatesThroughUSD(t *testing.T) {
+	provider := &fixedRateProvider{rates: map[domain.Currency]decimal.Decimal{
+		domain.BRL: decimal.NewFromFloat(0.20),
+		domain.MXN: decimal.NewFromFloat(0.05),
+	}}
+	service := NewService([]Provider{provider})
+
+	quote, err := service.Convert(domain.BRL, domain.MXN, decimal.NewFromFloat(100), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	// 100 BRL * (0.20/0.05) = 400 MXN
+	expectedRate := decimal.NewFromFloat(4)
+	assert.True(t, quote.Rate.Equal(expectedRate), "expected rate 4, got %s", quote.Rate)
+	assert.True(t, quote.Amount.Equal(decimal.NewFromFloat(400)))
+	assert.Equal(t, []domain.Currency{domain.BRL, domain.USD, domain.MXN}, quote.Path)
+}
+
+func TestService_Convert_InvalidCurrency(t *testing.T) {
+	service := NewService([]Provider{NewMockProvider()})
+
+	_, err := service.Convert(domain.Currency("EUR"), domain.USD, decimal.NewFromFloat(100), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+
+	_, err = service.Convert(domain.USD, domain.Currency("EUR"), decimal.NewFromFloat(100), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+// fixedRateProvider returns a fixed per-currency rate with no volatility,
+// making Convert's derived rates exact and easy to assert on.
+type fixedRateProvider struct {
+	rates map[domain.Currency]decimal.Decimal
+}
+
+func (p *fixedRateProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	if currency == domain.USD {
+		return decimal.NewFromInt(1), nil
+	}
+	rate, ok := p.rates[currency]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no rate for %s", currency)
+	}
+	return rate, nil
+}
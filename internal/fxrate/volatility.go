@@ -0,0 +1,199 @@
+package fxrate
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultVolatilityWindow is how many trailing days VolatilityDetector pulls
+// from the Provider to build its rolling mean/standard deviation, used when
+// VolatilityDetector.Window isn't set.
+const DefaultVolatilityWindow = 30
+
+// DefaultZScoreThreshold is how many standard deviations a rate must
+// deviate from its trailing rolling mean before VolatilityDetector flags it
+// as anomalous, used when VolatilityDetector.ZScoreThreshold isn't set.
+const DefaultZScoreThreshold = 3.0
+
+// VolatilityScore describes how a currency's rate on a given date compares
+// to its trailing rolling window.
+type VolatilityScore struct {
+	Currency domain.Currency
+	Date     time.Time
+	Rate     decimal.Decimal
+
+	// Mean and StdDev summarize the trailing window used to score Rate.
+	// When fewer than two days of history are available (e.g. a new
+	// currency, or the window reaches before the provider's earliest data),
+	// StdDev is zero and the rate cannot be meaningfully scored.
+	Mean   decimal.Decimal
+	StdDev decimal.Decimal
+
+	// ZScore is (Rate-Mean)/StdDev, or zero when StdDev is zero.
+	ZScore decimal.Decimal
+
+	// IsAnomalous is true when |ZScore| exceeds the detector's
+	// ZScoreThreshold.
+	IsAnomalous bool
+}
+
+// volatilityCacheKey identifies a cached score by currency and calendar
+// day, so repeated lookups for the same (currency, date) - common across a
+// batch of same-day transactions - only walk the rolling window once.
+type volatilityCacheKey struct {
+	currency domain.Currency
+	day      string // YYYY-MM-DD
+}
+
+// VolatilityDetector flags FX rates that deviate anomalously from their own
+// recent history: for a given (currency, date), it pulls a trailing window
+// of daily rates from Provider, computes the rolling mean and standard
+// deviation, and scores the date's rate against them. Days the Provider has
+// no rate for (weekends, holidays) are skipped rather than treated as zero,
+// so missing data doesn't distort the window.
+type VolatilityDetector struct {
+	provider Provider
+
+	// Window is how many trailing days to pull for the rolling mean/standard
+	// deviation. Zero means DefaultVolatilityWindow.
+	Window int
+	// ZScoreThreshold is how many standard deviations from the mean a rate
+	// must deviate to be flagged anomalous. Zero means DefaultZScoreThreshold.
+	ZScoreThreshold float64
+
+	mu    sync.Mutex
+	cache map[volatilityCacheKey]*VolatilityScore
+}
+
+// NewVolatilityDetector creates a VolatilityDetector backed by provider,
+// using DefaultVolatilityWindow and DefaultZScoreThreshold.
+func NewVolatilityDetector(provider Provider) *VolatilityDetector {
+	return &VolatilityDetector{
+		provider:        provider,
+		Window:          DefaultVolatilityWindow,
+		ZScoreThreshold: DefaultZScoreThreshold,
+		cache:           make(map[volatilityCacheKey]*VolatilityScore),
+	}
+}
+
+// Score returns the VolatilityScore for currency on date, computing it from
+// the provider's trailing window if it isn't already cached for this
+// (currency, day).
+func (d *VolatilityDetector) Score(currency domain.Currency, date time.Time) (*VolatilityScore, error) {
+	day := date.UTC().Truncate(24 * time.Hour)
+	key := volatilityCacheKey{currency: currency, day: day.Format("2006-01-02")}
+
+	if cached, ok := d.lookup(key); ok {
+		return cached, nil
+	}
+
+	rate, err := d.provider.GetRate(currency, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get FX rate for %s on %s: %w", currency, key.day, err)
+	}
+
+	score := d.scoreAgainstWindow(currency, day, rate)
+	d.store(key, score)
+	return score, nil
+}
+
+// scoreAgainstWindow builds the trailing window of daily rates preceding
+// day (skipping any day the provider has no rate for) and scores rate
+// against their mean and standard deviation.
+func (d *VolatilityDetector) scoreAgainstWindow(currency domain.Currency, day time.Time, rate decimal.Decimal) *VolatilityScore {
+	window := d.Window
+	if window <= 0 {
+		window = DefaultVolatilityWindow
+	}
+
+	var samples []decimal.Decimal
+	for offset := 1; offset <= window; offset++ {
+		candidate := day.AddDate(0, 0, -offset)
+		sampleRate, err := d.provider.GetRate(currency, candidate)
+		if err != nil {
+			continue // missing day (weekend/holiday): skip rather than zero-fill
+		}
+		samples = append(samples, sampleRate)
+	}
+
+	score := &VolatilityScore{
+		Currency: currency,
+		Date:     day,
+		Rate:     rate,
+	}
+
+	if len(samples) < 2 {
+		// Not enough history for a meaningful standard deviation.
+		score.Mean = rate
+		return score
+	}
+
+	mean := decimalMean(samples)
+	stdDev := decimalStdDev(samples, mean)
+
+	score.Mean = mean
+	score.StdDev = stdDev
+
+	if stdDev.IsZero() {
+		return score
+	}
+
+	threshold := d.ZScoreThreshold
+	if threshold <= 0 {
+		threshold = DefaultZScoreThreshold
+	}
+
+	z := rate.Sub(mean).Div(stdDev).InexactFloat64()
+	score.ZScore = decimal.NewFromFloat(z)
+	score.IsAnomalous = math.Abs(z) > threshold
+
+	return score
+}
+
+func (d *VolatilityDetector) lookup(key volatilityCacheKey) (*VolatilityScore, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	score, ok := d.cache[key]
+	return score, ok
+}
+
+func (d *VolatilityDetector) store(key volatilityCacheKey, score *VolatilityScore) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cache[key] = score
+}
+
+// decimalMean returns the arithmetic mean of samples, accumulated in
+// decimal so summing many rates doesn't drift the way repeated float64
+// addition does.
+func decimalMean(samples []decimal.Decimal) decimal.Decimal {
+	sum := decimal.Zero
+	for _, s := range samples {
+		sum = sum.Add(s)
+	}
+	return sum.Div(decimal.NewFromInt(int64(len(samples))))
+}
+
+// decimalStdDev returns the population standard deviation of samples around
+// mean. The sum of squared deviations is accumulated in decimal - so a
+// perfectly flat window produces an exact zero variance rather than the
+// floating-point noise (~1e-17) repeated float64 subtraction and squaring
+// would leave behind - and only the final sqrt drops to float64, since
+// decimal has no native square root.
+func decimalStdDev(samples []decimal.Decimal, mean decimal.Decimal) decimal.Decimal {
+	sumSquares := decimal.Zero
+	for _, s := range samples {
+		diff := s.Sub(mean)
+		sumSquares = sumSquares.Add(diff.Mul(diff))
+	}
+	variance := sumSquares.Div(decimal.NewFromInt(int64(len(samples))))
+	if variance.IsZero() {
+		return decimal.Zero
+	}
+	return decimal.NewFromFloat(math.Sqrt(variance.InexactFloat64()))
+}
@@ -0,0 +1,136 @@
+package fxrate
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dailyRateProvider serves a fixed rate for every day except the ones
+// listed in missing, which return an error to simulate weekends/holidays.
+type dailyRateProvider struct {
+	rates   map[string]float64 // YYYY-MM-DD -> rate
+	missing map[string]bool
+	calls   int32
+}
+
+func (p *dailyRateProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	atomic.AddInt32(&p.calls, 1)
+	day := date.UTC().Format("2006-01-02")
+	if p.missing[day] {
+		return decimal.Zero, fmt.Errorf("no rate for %s", day)
+	}
+	rate, ok := p.rates[day]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no rate for %s", day)
+	}
+	return decimal.NewFromFloat(rate), nil
+}
+
+func buildStableWindow(anomalyDay string, anomalyRate float64) *dailyRateProvider {
+	rates := make(map[string]float64)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 40; i++ {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		rates[day] = 0.20
+	}
+	rates[anomalyDay] = anomalyRate
+	return &dailyRateProvider{rates: rates}
+}
+
+func TestVolatilityDetector_Score_StableWindowIsNotAnomalous(t *testing.T) {
+	provider := buildStableWindow("2024-02-05", 0.20)
+	detector := NewVolatilityDetector(provider)
+
+	score, err := detector.Score(domain.BRL, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, score.IsAnomalous)
+	assert.True(t, score.StdDev.IsZero())
+}
+
+func TestVolatilityDetector_Score_SpikeIsAnomalous(t *testing.T) {
+	rates := make(map[string]float64)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		// Tiny variance around 0.20 so stddev is small but nonzero.
+		rates[day] = 0.20 + 0.001*float64(i%3)
+	}
+	spikeDay := start.AddDate(0, 0, 31)
+	rates[spikeDay.Format("2006-01-02")] = 0.40 // a 100% spike
+
+	provider := &dailyRateProvider{rates: rates}
+	detector := NewVolatilityDetector(provider)
+
+	score, err := detector.Score(domain.BRL, spikeDay)
+	require.NoError(t, err)
+	assert.True(t, score.IsAnomalous, "expected a 100%% spike to be flagged anomalous, z-score was %s", score.ZScore)
+}
+
+func TestVolatilityDetector_Score_SkipsMissingDaysInsteadOfZeroFilling(t *testing.T) {
+	rates := make(map[string]float64)
+	missing := make(map[string]bool)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		day := start.AddDate(0, 0, i)
+		dayStr := day.Format("2006-01-02")
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			missing[dayStr] = true
+			continue
+		}
+		rates[dayStr] = 0.20
+	}
+	targetDay := start.AddDate(0, 0, 31)
+	rates[targetDay.Format("2006-01-02")] = 0.20
+
+	provider := &dailyRateProvider{rates: rates, missing: missing}
+	detector := NewVolatilityDetector(provider)
+
+	score, err := detector.Score(domain.BRL, targetDay)
+	require.NoError(t, err)
+	// Every weekday rate is identical, so skipping weekends (rather than
+	// zero-filling them) must still produce a zero stddev, not a spurious
+	// spike from the zero-filled days.
+	assert.True(t, score.StdDev.IsZero())
+	assert.False(t, score.IsAnomalous)
+}
+
+func TestVolatilityDetector_Score_CachesPerCurrencyAndDay(t *testing.T) {
+	provider := buildStableWindow("2024-02-05", 0.20)
+	detector := NewVolatilityDetector(provider)
+
+	date := time.Date(2024, 2, 5, 9, 0, 0, 0, time.UTC)
+	sameDayLater := time.Date(2024, 2, 5, 21, 0, 0, 0, time.UTC)
+
+	_, err := detector.Score(domain.BRL, date)
+	require.NoError(t, err)
+	callsAfterFirst := atomic.LoadInt32(&provider.calls)
+
+	_, err = detector.Score(domain.BRL, sameDayLater)
+	require.NoError(t, err)
+
+	assert.Equal(t, callsAfterFirst, atomic.LoadInt32(&provider.calls), "second lookup for the same day should hit the cache")
+}
+
+func TestVolatilityDetector_Score_InsufficientHistoryIsNotAnomalous(t *testing.T) {
+	provider := &dailyRateProvider{rates: map[string]float64{"2024-01-01": 0.20}}
+	detector := NewVolatilityDetector(provider)
+
+	score, err := detector.Score(domain.BRL, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, score.IsAnomalous)
+}
+
+func TestVolatilityDetector_Score_ProviderErrorForTargetDate(t *testing.T) {
+	provider := &dailyRateProvider{rates: map[string]float64{}}
+	detector := NewVolatilityDetector(provider)
+
+	_, err := detector.Score(domain.BRL, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+// Package ledger converts calculated settlements into balanced double-entry
+// journal entries, independent of any particular on-disk format (CSV, text
+// ledger, Formance postings - see internal/reporter for those). It exists for
+// callers that want the postings themselves, e.g. to assert an accounting
+// invariant in a test or to feed a general ledger system that isn't one of
+// the formats reporter already writes.
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	sideDebit  = "debit"
+	sideCredit = "credit"
+)
+
+// Posting is a single double-entry line, denominated in USD (the ledger's
+// sole unit of account - FX conversion already happened by the time a
+// SettlementLine reaches here).
+type Posting struct {
+	TxnID     string `json:"txn_id"`
+	Account   string `json:"account"`
+	Side      string `json:"side"` // "debit" or "credit"
+	AmountUSD string `json:"amount_usd"`
+}
+
+// JournalEntry groups the postings generated from one supplier's settlement,
+// dated by the latest transaction timestamp it contains.
+type JournalEntry struct {
+	SupplierID     string    `json:"supplier_id"`
+	SettlementDate string    `json:"settlement_date"`
+	Postings       []Posting `json:"postings"`
+}
+
+// FromSettlements converts each settlement into a JournalEntry, in the same
+// order as settlements. A capture debits merchant_receivable:<supplier> and
+// credits pending_settlement:<currency>; a refund or chargeback reverses
+// that; a chargeback reversal posts the same direction as a capture (it pays
+// the supplier back, same as RecalculateTotals nets it against
+// TotalChargebacksUSD rather than TotalRefundsUSD). Whenever the settlement
+// carries FIFO FX gain/loss figures (RealizedFXGainUSD / UnrealizedFXGainUSD),
+// each nonzero one posts as its own debit/credit pair against
+// fx_gain_loss:<supplier>, so FX movement stays visible apart from
+// capture/refund/chargeback postings. Every pair this function emits debits
+// and credits the same amount, so sum(debits) == sum(credits) holds for each
+// entry and therefore for the whole slice.
+func FromSettlements(settlements []*domain.SupplierSettlement) ([]JournalEntry, error) {
+	entries := make([]JournalEntry, 0, len(settlements))
+	for _, settlement := range settlements {
+		if settlement == nil {
+			continue
+		}
+		entries = append(entries, entryForSettlement(settlement))
+	}
+	return entries, nil
+}
+
+func entryForSettlement(settlement *domain.SupplierSettlement) JournalEntry {
+	entry := JournalEntry{
+		SupplierID:     settlement.SupplierID,
+		SettlementDate: latestTimestamp(settlement),
+	}
+
+	for _, line := range settlement.Lines {
+		entry.Postings = append(entry.Postings, postingsForLine(settlement.SupplierID, line)...)
+	}
+
+	if postings, ok := fxGainLossPostings(settlement.SupplierID, "fx-realized", settlement.RealizedFXGainUSD); ok {
+		entry.Postings = append(entry.Postings, postings...)
+	}
+	if postings, ok := fxGainLossPostings(settlement.SupplierID, "fx-unrealized", settlement.UnrealizedFXGainUSD); ok {
+		entry.Postings = append(entry.Postings, postings...)
+	}
+
+	return entry
+}
+
+func postingsForLine(supplierID string, line domain.SettlementLine) []Posting {
+	tx := line.Transaction
+	receivable := merchantReceivableAccount(supplierID)
+	pending := pendingSettlementAccount(tx.Currency)
+	amount := line.USDAmount.StringFixed(domain.USD.Exponent())
+
+	debitAccount, creditAccount := receivable, pending
+	if tx.Type == domain.Refund || tx.Type == domain.Chargeback {
+		debitAccount, creditAccount = pending, receivable
+	}
+
+	return []Posting{
+		{TxnID: tx.ID, Account: debitAccount, Side: sideDebit, AmountUSD: amount},
+		{TxnID: tx.ID, Account: creditAccount, Side: sideCredit, AmountUSD: amount},
+	}
+}
+
+// fxGainLossPostings returns the balanced debit/credit pair for one nonzero
+// FX gain/loss figure, posted against the supplier's receivable so a gain
+// increases what they're owed and a loss reduces it. ok is false when gain
+// is zero, so callers can skip appending an empty pair.
+func fxGainLossPostings(supplierID, label string, gain decimal.Decimal) ([]Posting, bool) {
+	if gain.IsZero() {
+		return nil, false
+	}
+
+	receivable := merchantReceivableAccount(supplierID)
+	gainAccount := fxGainLossAccount(supplierID)
+	amount := gain.Abs().StringFixed(domain.USD.Exponent())
+	txnID := fmt.Sprintf("%s:%s", label, supplierID)
+
+	debitAccount, creditAccount := receivable, gainAccount
+	if gain.IsNegative() {
+		debitAccount, creditAccount = gainAccount, receivable
+	}
+
+	return []Posting{
+		{TxnID: txnID, Account: debitAccount, Side: sideDebit, AmountUSD: amount},
+		{TxnID: txnID, Account: creditAccount, Side: sideCredit, AmountUSD: amount},
+	}, true
+}
+
+func merchantReceivableAccount(supplierID string) string {
+	return fmt.Sprintf("merchant_receivable:%s", supplierID)
+}
+
+func pendingSettlementAccount(currency domain.Currency) string {
+	return fmt.Sprintf("pending_settlement:%s", currency)
+}
+
+func fxGainLossAccount(supplierID string) string {
+	return fmt.Sprintf("fx_gain_loss:%s", supplierID)
+}
+
+// latestTimestamp returns the most recent transaction timestamp in the
+// settlement, formatted as a date, mirroring reporter.LedgerWriter's closing
+// transaction date.
+func latestTimestamp(settlement *domain.SupplierSettlement) string {
+	var latest string
+	for _, line := range settlement.Lines {
+		ts := line.Transaction.Timestamp.Format("2006-01-02")
+		if ts > latest {
+			latest = ts
+		}
+	}
+	return latest
+}
+
+// MarshalJournalEntries serializes entries as a JSON array, ready to write
+// to a file or response body.
+func MarshalJournalEntries(entries []JournalEntry) ([]byte, error) {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal journal entries: %w", err)
+	}
+	return data, nil
+}
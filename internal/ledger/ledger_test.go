@@ -0,0 +1,162 @@
+package ledger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildSettlement(supplierID string, currency domain.Currency, lines []domain.TransactionType) *domain.SupplierSettlement {
+	validTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	settlement := domain.NewSupplierSettlement(supplierID, "Test Supplier")
+	for i, txType := range lines {
+		settlement.AddLine(domain.SettlementLine{
+			Transaction: &domain.Transaction{
+				ID:             "tx" + string(rune('0'+i)),
+				SupplierID:     supplierID,
+				Type:           txType,
+				OriginalAmount: decimal.NewFromFloat(100),
+				Currency:       currency,
+				Timestamp:      validTime.Add(time.Duration(i) * time.Hour),
+				Status:         domain.Completed,
+			},
+			FXRate:    decimal.NewFromInt(1),
+			USDAmount: decimal.NewFromFloat(100),
+		})
+	}
+	return settlement
+}
+
+func TestFromSettlements_CaptureDebitsReceivableCreditsPendingSettlement(t *testing.T) {
+	settlement := buildSettlement("sup123", domain.USD, []domain.TransactionType{domain.Capture})
+
+	entries, err := FromSettlements([]*domain.SupplierSettlement{settlement})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Len(t, entries[0].Postings, 2)
+
+	assert.Equal(t, sideDebit, entries[0].Postings[0].Side)
+	assert.Equal(t, "merchant_receivable:sup123", entries[0].Postings[0].Account)
+	assert.Equal(t, sideCredit, entries[0].Postings[1].Side)
+	assert.Equal(t, "pending_settlement:USD", entries[0].Postings[1].Account)
+}
+
+func TestFromSettlements_RefundAndChargebackReversePostings(t *testing.T) {
+	settlement := buildSettlement("sup123", domain.USD, []domain.TransactionType{domain.Refund, domain.Chargeback})
+
+	entries, err := FromSettlements([]*domain.SupplierSettlement{settlement})
+	require.NoError(t, err)
+	require.Len(t, entries[0].Postings, 4)
+
+	for _, postingPair := range [][2]Posting{{entries[0].Postings[0], entries[0].Postings[1]}, {entries[0].Postings[2], entries[0].Postings[3]}} {
+		assert.Equal(t, sideDebit, postingPair[0].Side)
+		assert.Equal(t, "pending_settlement:USD", postingPair[0].Account)
+		assert.Equal(t, sideCredit, postingPair[1].Side)
+		assert.Equal(t, "merchant_receivable:sup123", postingPair[1].Account)
+	}
+}
+
+func TestFromSettlements_ChargebackReversalPostsSameDirectionAsCapture(t *testing.T) {
+	settlement := buildSettlement("sup123", domain.USD, []domain.TransactionType{domain.ChargebackReversal})
+
+	entries, err := FromSettlements([]*domain.SupplierSettlement{settlement})
+	require.NoError(t, err)
+	require.Len(t, entries[0].Postings, 2)
+
+	assert.Equal(t, sideDebit, entries[0].Postings[0].Side)
+	assert.Equal(t, "merchant_receivable:sup123", entries[0].Postings[0].Account)
+	assert.Equal(t, sideCredit, entries[0].Postings[1].Side)
+	assert.Equal(t, "pending_settlement:USD", entries[0].Postings[1].Account)
+}
+
+func TestFromSettlements_FXGainLossPostsAgainstReceivable(t *testing.T) {
+	settlement := buildSettlement("sup456", domain.BRL, []domain.TransactionType{domain.Capture})
+	settlement.RealizedFXGainUSD = decimal.NewFromFloat(5.50)
+	settlement.UnrealizedFXGainUSD = decimal.NewFromFloat(-2.25)
+
+	entries, err := FromSettlements([]*domain.SupplierSettlement{settlement})
+	require.NoError(t, err)
+	require.Len(t, entries[0].Postings, 6)
+
+	realizedDebit, realizedCredit := entries[0].Postings[2], entries[0].Postings[3]
+	assert.Equal(t, sideDebit, realizedDebit.Side)
+	assert.Equal(t, "merchant_receivable:sup456", realizedDebit.Account)
+	assert.Equal(t, sideCredit, realizedCredit.Side)
+	assert.Equal(t, "fx_gain_loss:sup456", realizedCredit.Account)
+
+	unrealizedDebit, unrealizedCredit := entries[0].Postings[4], entries[0].Postings[5]
+	assert.Equal(t, sideDebit, unrealizedDebit.Side)
+	assert.Equal(t, "fx_gain_loss:sup456", unrealizedDebit.Account)
+	assert.Equal(t, sideCredit, unrealizedCredit.Side)
+	assert.Equal(t, "merchant_receivable:sup456", unrealizedCredit.Account)
+}
+
+func TestFromSettlements_SettlementDateIsLatestTransactionTimestamp(t *testing.T) {
+	settlement := buildSettlement("sup123", domain.USD, []domain.TransactionType{domain.Capture, domain.Refund})
+
+	entries, err := FromSettlements([]*domain.SupplierSettlement{settlement})
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-15", entries[0].SettlementDate)
+}
+
+func TestMarshalJournalEntries_RoundTripsThroughJSON(t *testing.T) {
+	settlement := buildSettlement("sup123", domain.USD, []domain.TransactionType{domain.Capture})
+	entries, err := FromSettlements([]*domain.SupplierSettlement{settlement})
+	require.NoError(t, err)
+
+	data, err := MarshalJournalEntries(entries)
+	require.NoError(t, err)
+
+	var decoded []JournalEntry
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, entries, decoded)
+}
+
+// TestFromSettlements_SumOfDebitsEqualsSumOfCredits is the property-based
+// invariant every JournalEntry must hold: across every settlement fixture
+// shape this package exercises (single/multi-currency, capture/refund/
+// chargeback/chargeback-reversal, with and without FX gain/loss), the
+// postings it produces must balance.
+func TestFromSettlements_SumOfDebitsEqualsSumOfCredits(t *testing.T) {
+	fixtures := []*domain.SupplierSettlement{
+		buildSettlement("sup001", domain.USD, []domain.TransactionType{domain.Capture}),
+		buildSettlement("sup002", domain.USD, []domain.TransactionType{domain.Refund}),
+		buildSettlement("sup003", domain.BRL, []domain.TransactionType{domain.Chargeback}),
+		buildSettlement("sup004", domain.ARS, []domain.TransactionType{domain.ChargebackReversal}),
+		buildSettlement("sup005", domain.COP, []domain.TransactionType{domain.Capture, domain.Refund, domain.Chargeback, domain.ChargebackReversal}),
+	}
+	fixtures[4].RealizedFXGainUSD = decimal.NewFromFloat(12.34)
+	fixtures[4].UnrealizedFXGainUSD = decimal.NewFromFloat(-7.89)
+
+	entries, err := FromSettlements(fixtures)
+	require.NoError(t, err)
+
+	for i, entry := range entries {
+		var debits, credits decimal.Decimal
+		for _, posting := range entry.Postings {
+			amount, err := decimal.NewFromString(posting.AmountUSD)
+			require.NoError(t, err)
+			switch posting.Side {
+			case sideDebit:
+				debits = debits.Add(amount)
+			case sideCredit:
+				credits = credits.Add(amount)
+			default:
+				t.Fatalf("unknown posting side %q", posting.Side)
+			}
+		}
+		assert.Truef(t, debits.Equal(credits), "fixture %d (%s): debits %s != credits %s", i, fixtures[i].SupplierID, debits, credits)
+	}
+}
+
+func TestFromSettlements_SkipsNilSettlements(t *testing.T) {
+	entries, err := FromSettlements([]*domain.SupplierSettlement{nil})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
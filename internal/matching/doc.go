@@ -0,0 +1,11 @@
+// Package matching implements FIFO cost-basis linkage between captures and
+// refunds, modeled on how a brokerage matches a sell order against FIFO buy
+// lots by ISIN. settlement.DetectOrphanedRefunds only checks whether a
+// supplier has any completed capture at all in the batch - a refund for a
+// wildly different amount, on a different day, still passes as long as one
+// capture exists somewhere. Matcher instead tracks each capture as a
+// time-ordered lot with its own remaining balance and draws refunds down
+// against the oldest still-open lot first, so a refund (or part of one)
+// only counts as orphaned once every lot it could plausibly be funded by is
+// exhausted.
+package matching
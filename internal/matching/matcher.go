@@ -0,0 +1,254 @@
+package matching
+
+import (
+	"sort"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Config tunes how Matcher falls back to cross-currency matching.
+type Config struct {
+	// USDTolerance is the maximum USD-equivalent amount a refund is allowed
+	// to go unmatched by (after exhausting same-currency lots and any
+	// cross-currency fallback) before it's reported as a true orphan,
+	// absorbing rounding slack rather than flagging dust as an anomaly. It
+	// also gates cross-currency matching itself: a zero Config (the default)
+	// disables the fallback entirely, so a refund only ever draws down
+	// same-currency lots and any unmatched amount is immediately an orphan.
+	USDTolerance decimal.Decimal
+}
+
+// Entry is one capture or refund transaction to feed into Matcher.Match,
+// alongside the USD-equivalent amount the settlement engine already
+// resolved for it via fxrate.Service - Matcher itself never looks up an FX
+// rate.
+type Entry struct {
+	Transaction *domain.Transaction
+	USDAmount   decimal.Decimal
+}
+
+// CaptureConsumption records the portion of a single capture lot a refund
+// drew down.
+type CaptureConsumption struct {
+	CaptureID      string
+	Currency       domain.Currency
+	AmountConsumed decimal.Decimal
+	USDConsumed    decimal.Decimal
+	// CrossCurrency is true when this lot was funded from a different
+	// currency than the refund itself, via Config.USDTolerance's fallback.
+	CrossCurrency bool
+}
+
+// MatchResult records how a single refund or chargeback was funded by open
+// capture lots.
+type MatchResult struct {
+	// RefundID is the funded transaction's ID - a Refund or Chargeback.
+	RefundID   string
+	SupplierID string
+	Currency   domain.Currency
+	Amount     decimal.Decimal
+
+	// FundedBy lists the capture lot(s) consumed to fund this refund, oldest
+	// first. Empty when no open lot could fund any of it.
+	FundedBy []CaptureConsumption
+
+	// RemainderUSD is the USD-equivalent portion of this refund no open lot
+	// (same-currency or, with Config.USDTolerance set, cross-currency) could
+	// fund, after Config.USDTolerance has already absorbed any amount within
+	// tolerance. Zero means the refund was fully matched.
+	RemainderUSD decimal.Decimal
+
+	// IsOrphan is true when RemainderUSD is positive: some or all of this
+	// refund has no capture lot to justify it, unlike the supplier-level
+	// heuristic settlement.DetectOrphanedRefunds used, which only checked
+	// whether the supplier had any capture at all.
+	IsOrphan bool
+}
+
+// lot is a single capture's unconsumed balance, available to fund refunds
+// in FIFO order (oldest lot first). Lots are appended in the order Match
+// processes captures, which is timestamp order, so a supplier's slice is
+// already oldest-first.
+type lot struct {
+	captureID    string
+	currency     domain.Currency
+	remaining    decimal.Decimal
+	remainingUSD decimal.Decimal
+}
+
+// Matcher assigns FIFO cost-basis linkage between captures and refunds, per
+// supplier. A Matcher is not safe for concurrent use.
+type Matcher struct {
+	cfg  Config
+	lots map[string][]*lot // keyed by supplier ID, oldest lot first
+}
+
+// NewMatcher creates a Matcher configured by cfg.
+func NewMatcher(cfg Config) *Matcher {
+	return &Matcher{cfg: cfg, lots: make(map[string][]*lot)}
+}
+
+// Reset discards every open lot, returning the Matcher to its
+// just-constructed state. Callers that recompute a full dataset from
+// scratch (e.g. settlement.Engine.Calculate, as opposed to
+// CalculateIncremental) should Reset the Matcher first so the new run's
+// lots aren't drawn down against balances left over from a previous run
+// over the same or an overlapping dataset.
+func (m *Matcher) Reset() {
+	m.lots = make(map[string][]*lot)
+}
+
+// Match processes entries in timestamp order and returns one MatchResult
+// per completed Refund or Chargeback, in the order it was processed.
+// Captures and ChargebackReversals each open a new lot; Refunds and
+// Chargebacks each draw down the oldest open lot(s) for the same supplier -
+// the same Capture/ChargebackReversal vs. Refund/Chargeback split
+// settlement.LotTracker uses, since a ChargebackReversal puts funds back
+// the same way a Capture originally did. entries need not already be sorted
+// - Match sorts a copy by Transaction.Timestamp first. Only entries with
+// domain.Completed status participate; every other entry is ignored.
+//
+// Calling Match again on the same Matcher continues from the lot state left
+// by the previous call, so a caller processing a dataset incrementally (one
+// batch of entries at a time) gets the same linkage a single call over the
+// whole dataset would have produced.
+func (m *Matcher) Match(entries []Entry) []MatchResult {
+	ordered := make([]Entry, len(entries))
+	copy(ordered, entries)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Transaction.Timestamp.Before(ordered[j].Transaction.Timestamp)
+	})
+
+	var results []MatchResult
+	for _, e := range ordered {
+		tx := e.Transaction
+		if tx.Status != domain.Completed {
+			continue
+		}
+		switch tx.Type {
+		case domain.Capture, domain.ChargebackReversal:
+			m.openLot(tx, e.USDAmount)
+		case domain.Refund, domain.Chargeback:
+			results = append(results, m.consumeRefund(tx, e.USDAmount))
+		}
+	}
+	return results
+}
+
+// openLot records tx as a new FIFO lot for its supplier.
+func (m *Matcher) openLot(tx *domain.Transaction, usdAmount decimal.Decimal) {
+	m.lots[tx.SupplierID] = append(m.lots[tx.SupplierID], &lot{
+		captureID:    tx.ID,
+		currency:     tx.Currency,
+		remaining:    tx.OriginalAmount,
+		remainingUSD: usdAmount,
+	})
+}
+
+// consumeRefund draws tx's amount down from its supplier's open lots,
+// same-currency lots first and, if Config.USDTolerance permits, other
+// currencies' lots next, then reports whatever's left (net of tolerance) as
+// MatchResult.RemainderUSD.
+func (m *Matcher) consumeRefund(tx *domain.Transaction, usdAmount decimal.Decimal) MatchResult {
+	result := MatchResult{
+		RefundID:   tx.ID,
+		SupplierID: tx.SupplierID,
+		Currency:   tx.Currency,
+		Amount:     tx.OriginalAmount,
+	}
+
+	lots := m.lots[tx.SupplierID]
+	remainingNative := tx.OriginalAmount
+	remainingUSD := usdAmount
+
+	for _, l := range lots {
+		if remainingNative.LessThanOrEqual(decimal.Zero) || l.currency != tx.Currency || l.remaining.LessThanOrEqual(decimal.Zero) {
+			continue
+		}
+		take := decMin(l.remaining, remainingNative)
+		takeUSD := proportionalUSD(l, take)
+
+		result.FundedBy = append(result.FundedBy, CaptureConsumption{
+			CaptureID:      l.captureID,
+			Currency:       l.currency,
+			AmountConsumed: take,
+			USDConsumed:    takeUSD,
+		})
+
+		l.remaining = l.remaining.Sub(take)
+		l.remainingUSD = l.remainingUSD.Sub(takeUSD)
+		remainingNative = remainingNative.Sub(take)
+		remainingUSD = remainingUSD.Sub(takeUSD)
+	}
+
+	if remainingUSD.IsPositive() && m.cfg.USDTolerance.IsPositive() {
+		for _, l := range lots {
+			if remainingUSD.LessThanOrEqual(decimal.Zero) || l.currency == tx.Currency || l.remainingUSD.LessThanOrEqual(decimal.Zero) {
+				continue
+			}
+			takeUSD := decMin(l.remainingUSD, remainingUSD)
+			takeNative := proportionalNative(l, takeUSD)
+
+			result.FundedBy = append(result.FundedBy, CaptureConsumption{
+				CaptureID:      l.captureID,
+				Currency:       l.currency,
+				AmountConsumed: takeNative,
+				USDConsumed:    takeUSD,
+				CrossCurrency:  true,
+			})
+
+			l.remaining = l.remaining.Sub(takeNative)
+			l.remainingUSD = l.remainingUSD.Sub(takeUSD)
+			remainingUSD = remainingUSD.Sub(takeUSD)
+		}
+	}
+
+	if remainingUSD.Abs().LessThanOrEqual(m.cfg.USDTolerance) {
+		remainingUSD = decimal.Zero
+	}
+
+	m.lots[tx.SupplierID] = compact(lots)
+
+	result.RemainderUSD = remainingUSD
+	result.IsOrphan = remainingUSD.IsPositive()
+	return result
+}
+
+// proportionalUSD returns l's USD-equivalent value for a native-currency
+// amount taken from it, scaled by l's own remaining native/USD ratio.
+func proportionalUSD(l *lot, takeNative decimal.Decimal) decimal.Decimal {
+	if l.remaining.IsZero() {
+		return decimal.Zero
+	}
+	return l.remainingUSD.Mul(takeNative).Div(l.remaining)
+}
+
+// proportionalNative is proportionalUSD's inverse: the native-currency
+// amount corresponding to a USD value taken from l.
+func proportionalNative(l *lot, takeUSD decimal.Decimal) decimal.Decimal {
+	if l.remainingUSD.IsZero() {
+		return decimal.Zero
+	}
+	return l.remaining.Mul(takeUSD).Div(l.remainingUSD)
+}
+
+// compact drops fully-consumed lots from lots, preserving the relative
+// (oldest-first) order of what's left.
+func compact(lots []*lot) []*lot {
+	kept := lots[:0]
+	for _, l := range lots {
+		if l.remaining.IsPositive() {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+// decMin returns the smaller of a and b.
+func decMin(a, b decimal.Decimal) decimal.Decimal {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
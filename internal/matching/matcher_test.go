@@ -0,0 +1,197 @@
+package matching
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(id, supplierID string, txType domain.TransactionType, amount string, currency domain.Currency, usd string, ts time.Time) Entry {
+	return Entry{
+		Transaction: &domain.Transaction{
+			ID:             id,
+			SupplierID:     supplierID,
+			Type:           txType,
+			OriginalAmount: decimal.RequireFromString(amount),
+			Currency:       currency,
+			Timestamp:      ts,
+			Status:         domain.Completed,
+		},
+		USDAmount: decimal.RequireFromString(usd),
+	}
+}
+
+func day(n int) time.Time {
+	return time.Date(2024, 1, n, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMatcher_Match_SameCurrencyFullyFunds(t *testing.T) {
+	m := NewMatcher(Config{})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "100", domain.USD, "100", day(1)),
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(2)),
+	})
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsOrphan)
+	assert.True(t, results[0].RemainderUSD.IsZero())
+	require.Len(t, results[0].FundedBy, 1)
+	assert.Equal(t, "cap1", results[0].FundedBy[0].CaptureID)
+	assert.False(t, results[0].FundedBy[0].CrossCurrency)
+}
+
+func TestMatcher_Match_PartialFillAcrossMultipleLots(t *testing.T) {
+	m := NewMatcher(Config{})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "60", domain.USD, "60", day(1)),
+		entry("cap2", "sup1", domain.Capture, "60", domain.USD, "60", day(2)),
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(3)),
+	})
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsOrphan)
+	require.Len(t, results[0].FundedBy, 2)
+	assert.Equal(t, "cap1", results[0].FundedBy[0].CaptureID)
+	assert.True(t, results[0].FundedBy[0].AmountConsumed.Equal(decimal.NewFromInt(60)))
+	assert.Equal(t, "cap2", results[0].FundedBy[1].CaptureID)
+	assert.True(t, results[0].FundedBy[1].AmountConsumed.Equal(decimal.NewFromInt(40)))
+}
+
+func TestMatcher_Match_NoCaptureAtAllIsOrphan(t *testing.T) {
+	m := NewMatcher(Config{})
+
+	results := m.Match([]Entry{
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(1)),
+	})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsOrphan)
+	assert.Empty(t, results[0].FundedBy)
+	assert.True(t, results[0].RemainderUSD.Equal(decimal.NewFromInt(100)))
+}
+
+func TestMatcher_Match_CaptureExistsButInsufficientIsPartialOrphan(t *testing.T) {
+	// settlement.DetectOrphanedRefunds would pass this refund through
+	// untouched since the supplier has a capture at all; Matcher should
+	// still flag the unfunded remainder.
+	m := NewMatcher(Config{})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "30", domain.USD, "30", day(1)),
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(2)),
+	})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsOrphan)
+	require.Len(t, results[0].FundedBy, 1)
+	assert.True(t, results[0].RemainderUSD.Equal(decimal.NewFromInt(70)))
+}
+
+func TestMatcher_Match_CrossCurrencyFallbackWithinTolerance(t *testing.T) {
+	m := NewMatcher(Config{USDTolerance: decimal.NewFromInt(1)})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "100", domain.BRL, "108", day(1)),
+		entry("ref1", "sup1", domain.Refund, "50", domain.USD, "50", day(2)),
+	})
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsOrphan)
+	require.Len(t, results[0].FundedBy, 1)
+	assert.True(t, results[0].FundedBy[0].CrossCurrency)
+	assert.Equal(t, "cap1", results[0].FundedBy[0].CaptureID)
+}
+
+func TestMatcher_Match_CrossCurrencyFallbackExceedingToleranceStillOrphan(t *testing.T) {
+	// No cross-currency fallback configured (USDTolerance is zero), so a
+	// BRL capture can't fund a USD refund at all.
+	m := NewMatcher(Config{})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "100", domain.BRL, "108", day(1)),
+		entry("ref1", "sup1", domain.Refund, "50", domain.USD, "50", day(2)),
+	})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsOrphan)
+	assert.Empty(t, results[0].FundedBy)
+}
+
+func TestMatcher_Match_DustWithinToleranceIsNotOrphan(t *testing.T) {
+	m := NewMatcher(Config{USDTolerance: decimal.NewFromFloat(0.5)})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "99.80", domain.USD, "99.80", day(1)),
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(2)),
+	})
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsOrphan)
+	assert.True(t, results[0].RemainderUSD.IsZero())
+}
+
+func TestMatcher_Match_DifferentSuppliersDoNotShareLots(t *testing.T) {
+	m := NewMatcher(Config{})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "100", domain.USD, "100", day(1)),
+		entry("ref1", "sup2", domain.Refund, "100", domain.USD, "100", day(2)),
+	})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsOrphan)
+}
+
+func TestMatcher_Match_RefundBeforeCaptureInArrivalOrderIsReorderedByTimestamp(t *testing.T) {
+	m := NewMatcher(Config{})
+
+	// Entries are passed in reverse-timestamp order; Match should still
+	// process the capture first since it sorts by Timestamp itself.
+	results := m.Match([]Entry{
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(2)),
+		entry("cap1", "sup1", domain.Capture, "100", domain.USD, "100", day(1)),
+	})
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsOrphan)
+}
+
+func TestMatcher_Match_ChargebackReversalReopensALotForChargeback(t *testing.T) {
+	m := NewMatcher(Config{})
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "100", domain.USD, "100", day(1)),
+		entry("cb1", "sup1", domain.Chargeback, "100", domain.USD, "100", day(2)),
+		entry("rev1", "sup1", domain.ChargebackReversal, "100", domain.USD, "100", day(3)),
+		entry("cb2", "sup1", domain.Chargeback, "100", domain.USD, "100", day(4)),
+	})
+
+	require.Len(t, results, 2)
+	assert.False(t, results[0].IsOrphan, "cb1 draws down cap1's original lot")
+	assert.False(t, results[1].IsOrphan, "cb2 draws down the lot rev1 reopened")
+}
+
+func TestMatcher_Reset_ClearsOpenLotsBetweenRuns(t *testing.T) {
+	m := NewMatcher(Config{})
+
+	m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "100", domain.USD, "100", day(1)),
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(2)),
+	})
+
+	m.Reset()
+
+	results := m.Match([]Entry{
+		entry("cap1", "sup1", domain.Capture, "100", domain.USD, "100", day(1)),
+		entry("ref1", "sup1", domain.Refund, "100", domain.USD, "100", day(2)),
+	})
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].IsOrphan, "Reset should let the same capture fund a second run's refund again")
+}
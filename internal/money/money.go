@@ -0,0 +1,152 @@
+// Package money implements fixed-point monetary arithmetic backed by a
+// 256-bit unsigned integer instead of decimal.Decimal's arbitrary-precision
+// math/big.Int. decimal.Decimal is the right default - see internal/domain's
+// Money type - but its coefficient grows without bound, which costs
+// throughput at millions-of-transactions scale and makes the cost of a
+// single multiply depend on how many operations came before it. Amount and
+// Rate instead store a fixed four-word magnitude (following the approach AMM
+// projects use when moving from bigint to uint256/int256): every operation
+// is a constant number of machine words, and overflow is detected
+// deterministically rather than silently growing the allocation.
+//
+// Amount is scaled by 10^4 (enough headroom past the widest minor-unit
+// currency this package settles - see domain.Currency.Exponent) and Rate by
+// 10^8, matching the precision settlement FX quotes are already carried at.
+// Rescaling after a multiply always rounds the dropped digits using
+// banker's rounding (round-half-to-even), the same convention
+// domain.Money and decimal.Decimal's RoundBank use, so switching an engine
+// over to this package doesn't introduce a new rounding bias.
+package money
+
+import (
+	"fmt"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// AmountScale and RateScale are the fixed-point scaling factors Amount and
+// Rate store their magnitude at: an Amount's uint256 holds amount*10^4, and
+// a Rate's holds rate*10^8.
+const (
+	AmountScale = 10_000
+	RateScale   = 100_000_000
+)
+
+// Amount is a signed, fixed-point monetary value scaled by AmountScale.
+type Amount struct {
+	neg bool
+	mag uint256
+}
+
+// Rate is an unsigned, fixed-point exchange rate scaled by RateScale. Rates
+// are never negative, so unlike Amount it carries no sign.
+type Rate struct {
+	mag uint256
+}
+
+// NewAmountFromDecimal converts d into a fixed-point Amount, rounding to
+// AmountScale's precision with banker's rounding. It errors if d's
+// magnitude doesn't fit in 256 bits.
+func NewAmountFromDecimal(d decimal.Decimal) (Amount, error) {
+	neg := d.IsNegative()
+	scaled := d.Abs().Shift(4).RoundBank(0)
+	mag, ok := bigIntToUint256(scaled.BigInt())
+	if !ok {
+		return Amount{}, fmt.Errorf("money: amount %s overflows 256 bits", d)
+	}
+	return Amount{neg: neg && !mag.isZero(), mag: mag}, nil
+}
+
+// NewRateFromDecimal converts d into a fixed-point Rate, rounding to
+// RateScale's precision with banker's rounding. It errors if d is negative
+// or its magnitude doesn't fit in 256 bits.
+func NewRateFromDecimal(d decimal.Decimal) (Rate, error) {
+	if d.IsNegative() {
+		return Rate{}, fmt.Errorf("money: rate %s is negative", d)
+	}
+	scaled := d.Shift(8).RoundBank(0)
+	mag, ok := bigIntToUint256(scaled.BigInt())
+	if !ok {
+		return Rate{}, fmt.Errorf("money: rate %s overflows 256 bits", d)
+	}
+	return Rate{mag: mag}, nil
+}
+
+// Decimal converts a back to a decimal.Decimal, for interop with the rest
+// of the settlement pipeline, which is still decimal-based.
+func (a Amount) Decimal() decimal.Decimal {
+	d := decimal.NewFromBigInt(uint256ToBigInt(a.mag), -4)
+	if a.neg {
+		d = d.Neg()
+	}
+	return d
+}
+
+// Decimal converts r back to a decimal.Decimal.
+func (r Rate) Decimal() decimal.Decimal {
+	return decimal.NewFromBigInt(uint256ToBigInt(r.mag), -8)
+}
+
+// IsZero reports whether a is zero.
+func (a Amount) IsZero() bool {
+	return a.mag.isZero()
+}
+
+// Add returns a+b, erroring if the magnitude of the result overflows 256
+// bits.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.neg == b.neg {
+		sum, overflow := a.mag.add(b.mag)
+		if overflow {
+			return Amount{}, fmt.Errorf("money: %s + %s overflows 256 bits", a.Decimal(), b.Decimal())
+		}
+		return Amount{neg: a.neg && !sum.isZero(), mag: sum}, nil
+	}
+
+	switch a.mag.cmp(b.mag) {
+	case 0:
+		return Amount{}, nil
+	case 1:
+		diff, _ := a.mag.sub(b.mag)
+		return Amount{neg: a.neg, mag: diff}, nil
+	default:
+		diff, _ := b.mag.sub(a.mag)
+		return Amount{neg: b.neg, mag: diff}, nil
+	}
+}
+
+// Sub returns a-b, erroring if the magnitude of the result overflows 256
+// bits.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	negated := b
+	if !negated.mag.isZero() {
+		negated.neg = !negated.neg
+	}
+	return a.Add(negated)
+}
+
+// Mul returns a*rate, rescaled back down to AmountScale with banker's
+// rounding on the digits the rescale drops, and errors if the product
+// overflows 256 bits either before or after rescaling.
+func (a Amount) Mul(rate Rate) (Amount, error) {
+	product := mul512(a.mag, rate.mag)
+	quotient, remainder := divModSmall(product, RateScale)
+	rescaled, ok := bankerRound256(quotient, remainder, RateScale)
+	if !ok {
+		return Amount{}, fmt.Errorf("money: %s * %s overflows 256 bits", a.Decimal(), rate.Decimal())
+	}
+	return Amount{neg: a.neg && !rescaled.isZero(), mag: rescaled}, nil
+}
+
+// Convert re-denominates a from currency from to currency to at the given
+// rate, mirroring fxrate.Service.Convert's short-circuit: when from and to
+// are the same currency the rate is ignored entirely and a is returned
+// unchanged, since multiplying by a same-currency rate of 1 would otherwise
+// still round-trip through Mul's rescale for no reason.
+func (a Amount) Convert(from, to domain.Currency, rate Rate) (Amount, error) {
+	if from == to {
+		return a, nil
+	}
+	return a.Mul(rate)
+}
@@ -0,0 +1,154 @@
+package money
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// uint256 is a fixed-width 256-bit unsigned integer, stored as four 64-bit
+// words in little-endian word order (words[0] is the least significant).
+// It exists so Amount/Rate arithmetic can detect overflow deterministically
+// instead of growing without bound the way math/big.Int does - the whole
+// point of moving off decimal.Decimal at scale.
+type uint256 [4]uint64
+
+// add returns a+b and whether the addition overflowed 256 bits.
+func (a uint256) add(b uint256) (uint256, bool) {
+	var out uint256
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		out[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+	return out, carry != 0
+}
+
+// sub returns a-b and whether b was greater than a (i.e. the subtraction
+// borrowed past the top word).
+func (a uint256) sub(b uint256) (uint256, bool) {
+	var out uint256
+	var borrow uint64
+	for i := 0; i < 4; i++ {
+		out[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+	return out, borrow != 0
+}
+
+// cmp returns -1, 0, or 1 as a is less than, equal to, or greater than b.
+func (a uint256) cmp(b uint256) int {
+	for i := 3; i >= 0; i-- {
+		if a[i] != b[i] {
+			if a[i] > b[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+func (a uint256) isZero() bool {
+	return a == uint256{}
+}
+
+// addAt adds val into word idx of prod, rippling the carry forward through
+// as many subsequent words as needed. Used by mul512 to accumulate partial
+// products without losing carries.
+func addAt(prod *[8]uint64, idx int, val uint64) {
+	for val != 0 && idx < len(prod) {
+		sum, carry := bits.Add64(prod[idx], val, 0)
+		prod[idx] = sum
+		val = carry
+		idx++
+	}
+}
+
+// mul512 multiplies two uint256 values, returning the full 512-bit product
+// as 8 little-endian words so the caller can decide how to rescale it
+// (Amount.Mul divides back down by the rate scale) without silently losing
+// the high bits the way a truncating 256x256->256 multiply would.
+func mul512(a, b uint256) [8]uint64 {
+	var prod [8]uint64
+	for i := 0; i < 4; i++ {
+		if a[i] == 0 {
+			continue
+		}
+		for j := 0; j < 4; j++ {
+			if b[j] == 0 {
+				continue
+			}
+			hi, lo := bits.Mul64(a[i], b[j])
+			addAt(&prod, i+j, lo)
+			addAt(&prod, i+j+1, hi)
+		}
+	}
+	return prod
+}
+
+// divModSmall divides the 512-bit value prod by a uint64 divisor, returning
+// the quotient (still 512 bits wide - the caller checks the high words are
+// zero to confirm it fits back in a uint256) and the remainder, which is
+// what bankerRound256 uses to decide whether to round the quotient up.
+func divModSmall(prod [8]uint64, divisor uint64) (quotient [8]uint64, remainder uint64) {
+	for i := 7; i >= 0; i-- {
+		quotient[i], remainder = bits.Div64(remainder, prod[i], divisor)
+	}
+	return quotient, remainder
+}
+
+// fitsIn256 reports whether the top 4 words of a 512-bit value are all
+// zero, i.e. it fits back into a uint256 without losing precision.
+func fitsIn256(v [8]uint64) bool {
+	return v[4] == 0 && v[5] == 0 && v[6] == 0 && v[7] == 0
+}
+
+func uint256From512Low(v [8]uint64) uint256 {
+	return uint256{v[0], v[1], v[2], v[3]}
+}
+
+// bankerRound256 rounds the 512-bit quotient/remainder pair produced by
+// dividing by divisor to the nearest integer, ties to even (banker's
+// rounding), and reports whether the rounded result still fits in 256 bits.
+func bankerRound256(quotient [8]uint64, remainder, divisor uint64) (uint256, bool) {
+	roundUp := false
+	switch {
+	case remainder*2 > divisor:
+		roundUp = true
+	case remainder*2 == divisor:
+		roundUp = quotient[0]&1 == 1
+	}
+
+	if roundUp {
+		addAt(&quotient, 0, 1)
+	}
+
+	if !fitsIn256(quotient) {
+		return uint256{}, false
+	}
+	return uint256From512Low(quotient), true
+}
+
+// bigIntToUint256 converts a non-negative big.Int to a uint256, reporting
+// an error if it doesn't fit in 256 bits.
+func bigIntToUint256(v *big.Int) (uint256, bool) {
+	if v.Sign() < 0 || v.BitLen() > 256 {
+		return uint256{}, false
+	}
+	var buf [32]byte
+	v.FillBytes(buf[:])
+	var out uint256
+	for i := 0; i < 4; i++ {
+		start := 32 - 8*(i+1)
+		out[i] = binary.BigEndian.Uint64(buf[start : start+8])
+	}
+	return out, true
+}
+
+func uint256ToBigInt(u uint256) *big.Int {
+	var buf [32]byte
+	for i := 0; i < 4; i++ {
+		start := 32 - 8*(i+1)
+		binary.BigEndian.PutUint64(buf[start:start+8], u[i])
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
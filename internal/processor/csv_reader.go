@@ -1,10 +1,13 @@
 package processor
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
@@ -14,6 +17,11 @@ import (
 // CSVReader reads and parses transactions from CSV files
 type CSVReader struct {
 	expectedHeaders []string
+
+	// Workers is how many goroutines ReadStream/ReadBatches parse records
+	// with concurrently. Zero means runtime.NumCPU(), matching
+	// settlement.Pipeline.Workers' default.
+	Workers int
 }
 
 // NewCSVReader creates a new CSV reader with expected header validation
@@ -83,6 +91,258 @@ func (r *CSVReader) Read(reader io.Reader) ([]*domain.Transaction, error) {
 	return transactions, nil
 }
 
+// TransactionOrError pairs a parsed transaction with any error encountered
+// while parsing it, for use with Stream where callers process records
+// incrementally instead of loading the whole file into memory.
+type TransactionOrError struct {
+	Transaction *domain.Transaction
+	Err         error
+}
+
+// Stream reads transactions from an io.Reader one record at a time, sending
+// each parsed transaction (or parse error) on the returned channel as soon as
+// it's available, and closing the channel once the reader is exhausted or a
+// fatal error occurs (an invalid header, or a malformed record). Unlike Read,
+// Stream never holds more than one record in memory at a time, which keeps
+// RAM usage flat regardless of file size.
+func (r *CSVReader) Stream(reader io.Reader) <-chan TransactionOrError {
+	out := make(chan TransactionOrError)
+
+	go func() {
+		defer close(out)
+
+		csvReader := csv.NewReader(reader)
+		csvReader.TrimLeadingSpace = true
+
+		header, err := csvReader.Read()
+		if err != nil {
+			out <- TransactionOrError{Err: fmt.Errorf("failed to read header: %w", err)}
+			return
+		}
+
+		if err := r.validateHeader(header); err != nil {
+			out <- TransactionOrError{Err: err}
+			return
+		}
+
+		lineNum := 2 // Start at 2 (1 is header)
+		for {
+			record, err := csvReader.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				out <- TransactionOrError{Err: fmt.Errorf("failed to read line %d: %w", lineNum, err)}
+				return
+			}
+
+			tx, err := r.parseRecord(record, lineNum)
+			if err != nil {
+				out <- TransactionOrError{Err: fmt.Errorf("failed to parse line %d: %w", lineNum, err)}
+				return
+			}
+
+			out <- TransactionOrError{Transaction: tx}
+			lineNum++
+		}
+	}()
+
+	return out
+}
+
+// csvRawRecord pairs a raw CSV record with the line number it was read
+// from, for csvParseWorker to parse and for the fan-in stage to restore
+// file order from once workers finish.
+type csvRawRecord struct {
+	record  []string
+	lineNum int
+}
+
+// csvParseResult carries one csvParseWorker's output (or error) back to the
+// fan-in stage, tagged with lineNum so results can be re-sequenced
+// regardless of which worker finished first.
+type csvParseResult struct {
+	lineNum int
+	tx      *domain.Transaction
+	err     error
+}
+
+// ReadStream parses reader's records across a pool of worker goroutines
+// (CSVReader.Workers, or runtime.NumCPU() if unset) - decimal and timestamp
+// parsing is the dominant per-record cost, same as
+// settlement.Pipeline.process's validation/FX conversion, and is
+// independent across records - then calls fn once per transaction in
+// ascending line-number order, the same order Read would have appended
+// them to its slice. Unlike Read, ReadStream never buffers the
+// whole file: only as many records as there are workers are in flight at
+// once, so memory stays bounded regardless of file size. It returns as
+// soon as any record fails to parse, fn returns an error, or ctx is
+// cancelled, stopping in-flight workers promptly - though the goroutine
+// reading raw records off reader can still be blocked inside a single
+// csvReader.Read() call when that happens, the same limitation CSVReader.
+// Stream has, since neither can interrupt a blocking read on an arbitrary
+// io.Reader without the caller closing it themselves.
+func (r *CSVReader) ReadStream(ctx context.Context, reader io.Reader, fn func(*domain.Transaction) error) error {
+	workers := r.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	if err := r.validateHeader(header); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan csvRawRecord, workers)
+	out := make(chan csvParseResult, workers)
+
+	var workerWg sync.WaitGroup
+	workerWg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer workerWg.Done()
+			r.parseWorker(ctx, in, out)
+		}()
+	}
+	go func() {
+		workerWg.Wait()
+		close(out)
+	}()
+
+	var readErr error
+	go func() {
+		defer close(in)
+		lineNum := 2 // Start at 2 (1 is header)
+		for {
+			record, err := csvReader.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr = fmt.Errorf("failed to read line %d: %w", lineNum, err)
+				cancel()
+				return
+			}
+			select {
+			case in <- csvRawRecord{record: record, lineNum: lineNum}:
+			case <-ctx.Done():
+				return
+			}
+			lineNum++
+		}
+	}()
+
+	// pending buffers results that arrived before the line number fn is
+	// still waiting on, since workers can finish in any order; next is the
+	// line number fn needs delivered next.
+	pending := make(map[int]csvParseResult)
+	next := 2
+	var firstErr error
+
+	for result := range out {
+		if firstErr != nil {
+			continue // drain out so every worker can exit; see the leak this guards against in generate_testdata.go's drain helper
+		}
+
+		pending[result.lineNum] = result
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+
+			if ready.err != nil {
+				firstErr = fmt.Errorf("failed to parse line %d: %w", next, ready.err)
+				cancel()
+				break
+			}
+			if err := fn(ready.tx); err != nil {
+				firstErr = err
+				cancel()
+				break
+			}
+			next++
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if readErr != nil {
+		return readErr
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("csv stream cancelled: %w", err)
+	}
+
+	return nil
+}
+
+// parseWorker parses raw records from in, sending each result to out until
+// in is drained or ctx is cancelled.
+func (r *CSVReader) parseWorker(ctx context.Context, in <-chan csvRawRecord, out chan<- csvParseResult) {
+	for {
+		select {
+		case raw, ok := <-in:
+			if !ok {
+				return
+			}
+			tx, err := r.parseRecord(raw.record, raw.lineNum)
+			select {
+			case out <- csvParseResult{lineNum: raw.lineNum, tx: tx, err: err}:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ReadBatches is ReadStream with its transactions grouped into batches of
+// up to batchSize before fn is called, so a caller settling incrementally
+// (e.g. Engine.CalculateStream, fed via a channel that fn sends
+// each batch's transactions onto) can amortize per-call overhead across many transactions
+// instead of processing one at a time. The final batch may hold fewer than
+// batchSize transactions if the file doesn't divide evenly; fn is not
+// called at all if the file has no records. batchSize <= 0 is treated as 1.
+func (r *CSVReader) ReadBatches(ctx context.Context, reader io.Reader, batchSize int, fn func([]*domain.Transaction) error) error {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	batch := make([]*domain.Transaction, 0, batchSize)
+	err := r.ReadStream(ctx, reader, func(tx *domain.Transaction) error {
+		batch = append(batch, tx)
+		if len(batch) < batchSize {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		batch = make([]*domain.Transaction, 0, batchSize)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
 // validateHeader checks if the CSV header matches expected columns
 func (r *CSVReader) validateHeader(header []string) error {
 	if len(header) != len(r.expectedHeaders) {
@@ -1,7 +1,12 @@
 package processor
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
@@ -162,3 +167,150 @@ tx001,sup123,capture,100.123456789,USD,2024-01-15T10:30:00Z,completed`
 	expected, _ := decimal.NewFromString("100.123456789")
 	assert.True(t, transactions[0].OriginalAmount.Equal(expected))
 }
+
+func TestCSVReader_Stream_ValidData(t *testing.T) {
+	csvData := `transaction_id,supplier_id,type,original_amount,currency,timestamp,status
+tx001,sup123,capture,100.50,USD,2024-01-15T10:30:00Z,completed
+tx002,sup456,refund,50.25,BRL,2024-01-16T14:20:00Z,completed`
+
+	reader := NewCSVReader()
+
+	var transactions []*domain.Transaction
+	for result := range reader.Stream(strings.NewReader(csvData)) {
+		require.NoError(t, result.Err)
+		transactions = append(transactions, result.Transaction)
+	}
+
+	require.Equal(t, 2, len(transactions))
+	assert.Equal(t, "tx001", transactions[0].ID)
+	assert.Equal(t, "tx002", transactions[1].ID)
+}
+
+func TestCSVReader_Stream_InvalidHeader(t *testing.T) {
+	csvData := `wrong,header,format
+tx001,sup123,capture`
+
+	reader := NewCSVReader()
+
+	var gotErr bool
+	for result := range reader.Stream(strings.NewReader(csvData)) {
+		if result.Err != nil {
+			gotErr = true
+		}
+	}
+
+	assert.True(t, gotErr, "expected an error for an invalid header")
+}
+
+func TestCSVReader_Stream_StopsAtBadRecord(t *testing.T) {
+	csvData := `transaction_id,supplier_id,type,original_amount,currency,timestamp,status
+tx001,sup123,capture,100.50,USD,2024-01-15T10:30:00Z,completed
+tx002,sup456,refund,not-a-number,BRL,2024-01-16T14:20:00Z,completed`
+
+	reader := NewCSVReader()
+
+	var transactions []*domain.Transaction
+	var gotErr bool
+	for result := range reader.Stream(strings.NewReader(csvData)) {
+		if result.Err != nil {
+			gotErr = true
+			continue
+		}
+		transactions = append(transactions, result.Transaction)
+	}
+
+	assert.Equal(t, 1, len(transactions), "the valid record before the bad one should still be delivered")
+	assert.True(t, gotErr)
+}
+
+func TestCSVReader_ReadStream_PreservesLineOrderAcrossWorkers(t *testing.T) {
+	var csvData strings.Builder
+	csvData.WriteString("transaction_id,supplier_id,type,original_amount,currency,timestamp,status\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&csvData, "tx%03d,sup123,capture,%d.00,USD,2024-01-15T10:30:00Z,completed\n", i, i)
+	}
+
+	reader := NewCSVReader()
+	reader.Workers = 8
+
+	var mu sync.Mutex
+	var ids []string
+	err := reader.ReadStream(context.Background(), strings.NewReader(csvData.String()), func(tx *domain.Transaction) error {
+		mu.Lock()
+		ids = append(ids, tx.ID)
+		mu.Unlock()
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, ids, 50)
+	assert.True(t, sort.StringsAreSorted(ids), "transactions must arrive in file order despite concurrent parsing")
+	assert.Equal(t, "tx000", ids[0])
+	assert.Equal(t, "tx049", ids[49])
+}
+
+func TestCSVReader_ReadStream_StopsAtBadRecord(t *testing.T) {
+	csvData := `transaction_id,supplier_id,type,original_amount,currency,timestamp,status
+tx001,sup123,capture,100.50,USD,2024-01-15T10:30:00Z,completed
+tx002,sup456,refund,not-a-number,BRL,2024-01-16T14:20:00Z,completed`
+
+	reader := NewCSVReader()
+
+	var ids []string
+	err := reader.ReadStream(context.Background(), strings.NewReader(csvData), func(tx *domain.Transaction) error {
+		ids = append(ids, tx.ID)
+		return nil
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+	assert.Equal(t, []string{"tx001"}, ids)
+}
+
+func TestCSVReader_ReadStream_StopsOnCallbackError(t *testing.T) {
+	csvData := `transaction_id,supplier_id,type,original_amount,currency,timestamp,status
+tx001,sup123,capture,100.50,USD,2024-01-15T10:30:00Z,completed
+tx002,sup456,refund,50.25,BRL,2024-01-16T14:20:00Z,completed`
+
+	reader := NewCSVReader()
+	boom := errors.New("boom")
+
+	err := reader.ReadStream(context.Background(), strings.NewReader(csvData), func(tx *domain.Transaction) error {
+		return boom
+	})
+
+	require.ErrorIs(t, err, boom)
+}
+
+func TestCSVReader_ReadBatches_GroupsIntoFixedSizeBatches(t *testing.T) {
+	csvData := `transaction_id,supplier_id,type,original_amount,currency,timestamp,status
+tx001,sup123,capture,100.50,USD,2024-01-15T10:30:00Z,completed
+tx002,sup456,refund,50.25,BRL,2024-01-16T14:20:00Z,completed
+tx003,sup789,capture,10.00,USD,2024-01-17T10:30:00Z,completed`
+
+	reader := NewCSVReader()
+
+	var batchSizes []int
+	err := reader.ReadBatches(context.Background(), strings.NewReader(csvData), 2, func(batch []*domain.Transaction) error {
+		batchSizes = append(batchSizes, len(batch))
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, batchSizes, "the final partial batch must still be flushed")
+}
+
+func TestCSVReader_ReadBatches_EmptyFileCallsNothing(t *testing.T) {
+	csvData := `transaction_id,supplier_id,type,original_amount,currency,timestamp,status`
+
+	reader := NewCSVReader()
+
+	called := false
+	err := reader.ReadBatches(context.Background(), strings.NewReader(csvData), 10, func(batch []*domain.Transaction) error {
+		called = true
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.False(t, called)
+}
@@ -1,9 +1,18 @@
-// Package processor handles CSV transaction data ingestion and validation.
+// Package processor handles transaction data ingestion and validation.
 //
 // This package provides functionality to:
-//   - Parse CSV files containing transaction data
+//   - Parse CSV files, newline-delimited JSON, OFX 2.x bank/credit-card
+//     statements, and SWIFT MT940 customer statements containing
+//     transaction data, via the Reader interface (CSVReader, JSONLReader,
+//     OFXReader, MT940Reader); ReaderForFile picks one by extension or,
+//     failing that, by sniffing the file's content
+//   - Parse very large CSV files with bounded memory: CSVReader.ReadStream
+//     and ReadBatches parse records across a worker pool and deliver them
+//     (or fixed-size batches of them) to a callback in file order, composing
+//     with settlement.Engine.CalculateStream for incremental, per-supplier
+//     settlement as records are read rather than after a full load
 //   - Validate transaction fields (IDs, amounts, currencies, timestamps)
-//   - Transform CSV rows into domain.Transaction entities
+//   - Transform rows/statement entries into domain.Transaction entities
 //   - Handle data quality issues and provide clear error messages
 //
 // The processor ensures that only valid, well-formed transaction data
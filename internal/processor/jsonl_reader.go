@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// JSONLReader reads and parses transactions from newline-delimited JSON
+// (one transaction object per line), using the same column set as
+// CSVReader (transaction_id, supplier_id, type, original_amount, currency,
+// timestamp, status) expressed as JSON keys rather than CSV columns. Its
+// Stream method pairs naturally with Engine.CalculateStream, which wants
+// transactions one at a time rather than a fully-parsed slice.
+type JSONLReader struct{}
+
+// NewJSONLReader creates a new JSONL transaction reader.
+func NewJSONLReader() *JSONLReader {
+	return &JSONLReader{}
+}
+
+// jsonlRecord mirrors CSVReader's expected columns so the two formats parse
+// the same transaction shape; original_amount is a string (like the CSV
+// column) rather than a JSON number so precision isn't lost to float64.
+type jsonlRecord struct {
+	TransactionID  string `json:"transaction_id"`
+	SupplierID     string `json:"supplier_id"`
+	Type           string `json:"type"`
+	OriginalAmount string `json:"original_amount"`
+	Currency       string `json:"currency"`
+	Timestamp      string `json:"timestamp"`
+	Status         string `json:"status"`
+}
+
+// ReadFile reads transactions from a JSONL file.
+func (r *JSONLReader) ReadFile(filePath string) ([]*domain.Transaction, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return r.Read(file)
+}
+
+// Read reads transactions from an io.Reader, one JSON object per line.
+func (r *JSONLReader) Read(reader io.Reader) ([]*domain.Transaction, error) {
+	var transactions []*domain.Transaction
+
+	for result := range r.Stream(reader) {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		transactions = append(transactions, result.Transaction)
+	}
+
+	return transactions, nil
+}
+
+// Stream reads transactions from reader one line at a time, sending each
+// parsed transaction (or parse error) on the returned channel as soon as
+// it's available, and closing the channel once the reader is exhausted or a
+// malformed line is hit. Like CSVReader.Stream, it never holds more than one
+// record in memory at a time, so it composes with Engine.CalculateStream on
+// multi-GB inputs.
+func (r *JSONLReader) Stream(reader io.Reader) <-chan TransactionOrError {
+	out := make(chan TransactionOrError)
+
+	go func() {
+		defer close(out)
+
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+		lineNum := 1
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				lineNum++
+				continue
+			}
+
+			tx, err := r.parseLine(line, lineNum)
+			if err != nil {
+				out <- TransactionOrError{Err: err}
+				return
+			}
+
+			out <- TransactionOrError{Transaction: tx}
+			lineNum++
+		}
+
+		if err := scanner.Err(); err != nil {
+			out <- TransactionOrError{Err: fmt.Errorf("failed to read line %d: %w", lineNum, err)}
+		}
+	}()
+
+	return out
+}
+
+// parseLine converts one line of JSONL into a Transaction.
+func (r *JSONLReader) parseLine(line []byte, lineNum int) (*domain.Transaction, error) {
+	var record jsonlRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse line %d: %w", lineNum, err)
+	}
+
+	amount, err := decimal.NewFromString(record.OriginalAmount)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: invalid amount '%s': %w", lineNum, record.OriginalAmount, err)
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, record.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: invalid timestamp '%s': must be RFC3339 format: %w", lineNum, record.Timestamp, err)
+	}
+
+	return &domain.Transaction{
+		ID:             record.TransactionID,
+		SupplierID:     record.SupplierID,
+		Type:           domain.TransactionType(record.Type),
+		OriginalAmount: amount,
+		Currency:       domain.Currency(record.Currency),
+		Timestamp:      timestamp,
+		Status:         domain.TransactionStatus(record.Status),
+	}, nil
+}
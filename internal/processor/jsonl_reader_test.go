@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONLReader(t *testing.T) {
+	reader := NewJSONLReader()
+	assert.NotNil(t, reader)
+}
+
+func TestJSONLReader_Read_ValidData(t *testing.T) {
+	data := `{"transaction_id":"tx001","supplier_id":"sup123","type":"capture","original_amount":"100.50","currency":"USD","timestamp":"2024-01-15T10:30:00Z","status":"completed"}
+{"transaction_id":"tx002","supplier_id":"sup456","type":"refund","original_amount":"50.25","currency":"BRL","timestamp":"2024-01-16T14:20:00Z","status":"completed"}`
+
+	reader := NewJSONLReader()
+	transactions, err := reader.Read(strings.NewReader(data))
+
+	require.NoError(t, err)
+	require.Equal(t, 2, len(transactions))
+
+	tx1 := transactions[0]
+	assert.Equal(t, "tx001", tx1.ID)
+	assert.Equal(t, "sup123", tx1.SupplierID)
+	assert.Equal(t, domain.Capture, tx1.Type)
+	assert.True(t, tx1.OriginalAmount.Equal(decimal.NewFromFloat(100.50)))
+	assert.Equal(t, domain.USD, tx1.Currency)
+	assert.Equal(t, domain.Completed, tx1.Status)
+
+	tx2 := transactions[1]
+	assert.Equal(t, "tx002", tx2.ID)
+	assert.Equal(t, domain.Refund, tx2.Type)
+	assert.Equal(t, domain.BRL, tx2.Currency)
+}
+
+func TestJSONLReader_Read_SkipsBlankLines(t *testing.T) {
+	data := "{\"transaction_id\":\"tx001\",\"supplier_id\":\"sup123\",\"type\":\"capture\",\"original_amount\":\"100.50\",\"currency\":\"USD\",\"timestamp\":\"2024-01-15T10:30:00Z\",\"status\":\"completed\"}\n\n"
+
+	reader := NewJSONLReader()
+	transactions, err := reader.Read(strings.NewReader(data))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(transactions))
+}
+
+func TestJSONLReader_Read_InvalidAmount(t *testing.T) {
+	data := `{"transaction_id":"tx001","supplier_id":"sup123","type":"capture","original_amount":"invalid","currency":"USD","timestamp":"2024-01-15T10:30:00Z","status":"completed"}`
+
+	reader := NewJSONLReader()
+	_, err := reader.Read(strings.NewReader(data))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid amount")
+}
+
+func TestJSONLReader_Read_InvalidTimestamp(t *testing.T) {
+	data := `{"transaction_id":"tx001","supplier_id":"sup123","type":"capture","original_amount":"100.50","currency":"USD","timestamp":"2024-01-15","status":"completed"}`
+
+	reader := NewJSONLReader()
+	_, err := reader.Read(strings.NewReader(data))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid timestamp")
+}
+
+func TestJSONLReader_Read_MalformedLine(t *testing.T) {
+	data := `not json at all`
+
+	reader := NewJSONLReader()
+	_, err := reader.Read(strings.NewReader(data))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse line")
+}
+
+func TestJSONLReader_Stream_ValidData(t *testing.T) {
+	data := `{"transaction_id":"tx001","supplier_id":"sup123","type":"capture","original_amount":"100.50","currency":"USD","timestamp":"2024-01-15T10:30:00Z","status":"completed"}
+{"transaction_id":"tx002","supplier_id":"sup456","type":"refund","original_amount":"50.25","currency":"BRL","timestamp":"2024-01-16T14:20:00Z","status":"completed"}`
+
+	reader := NewJSONLReader()
+
+	var transactions []*domain.Transaction
+	for result := range reader.Stream(strings.NewReader(data)) {
+		require.NoError(t, result.Err)
+		transactions = append(transactions, result.Transaction)
+	}
+
+	require.Equal(t, 2, len(transactions))
+	assert.Equal(t, "tx001", transactions[0].ID)
+	assert.Equal(t, "tx002", transactions[1].ID)
+}
+
+func TestJSONLReader_Stream_StopsAtBadLine(t *testing.T) {
+	data := `{"transaction_id":"tx001","supplier_id":"sup123","type":"capture","original_amount":"100.50","currency":"USD","timestamp":"2024-01-15T10:30:00Z","status":"completed"}
+{"transaction_id":"tx002","supplier_id":"sup456","type":"refund","original_amount":"not-a-number","currency":"BRL","timestamp":"2024-01-16T14:20:00Z","status":"completed"}`
+
+	reader := NewJSONLReader()
+
+	var transactions []*domain.Transaction
+	var gotErr bool
+	for result := range reader.Stream(strings.NewReader(data)) {
+		if result.Err != nil {
+			gotErr = true
+			continue
+		}
+		transactions = append(transactions, result.Transaction)
+	}
+
+	assert.Equal(t, 1, len(transactions), "the valid record before the bad one should still be delivered")
+	assert.True(t, gotErr)
+}
@@ -0,0 +1,150 @@
+package processor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// mt940Line61Pattern matches SWIFT MT940 field :61: (statement line), per
+// the spec's "YYMMDD[MMDD]2!a[1!a]15d1!a3!c16x[//16x]" layout:
+//
+//	group 1: value date, YYMMDD
+//	group 2: debit/credit mark - D, C, RD (reversal of debit), or RC
+//	         (reversal of credit)
+//	group 3: amount, digits with a comma decimal separator
+//	group 4: customer reference (the field this reader uses as the
+//	         transaction ID); a following "//bank ref" is ignored
+//
+// The optional entry date (before the mark), funds code (after the mark),
+// and transaction-type identification (after the amount) sub-fields are
+// matched but not captured.
+var mt940Line61Pattern = regexp.MustCompile(`^:61:(\d{6})(?:\d{4})?(RD|RC|D|C)(?:[A-Z])?([0-9,]+)(?:[A-Z0-9]{1,4})?([^\n]*)$`)
+
+// MT940Reader reads transactions from SWIFT MT940 customer statement
+// messages, the format LATAM acquirers commonly hand back instead of a CSV
+// export. It implements Reader alongside CSVReader and OFXReader.
+type MT940Reader struct{}
+
+// NewMT940Reader creates a new MT940 reader.
+func NewMT940Reader() *MT940Reader {
+	return &MT940Reader{}
+}
+
+// ReadFile reads transactions from an MT940 file.
+func (r *MT940Reader) ReadFile(path string) ([]*domain.Transaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return r.Read(file)
+}
+
+// Read parses an MT940 message and returns one Transaction per :61:
+// statement line, using the :25: account identification field as the
+// SupplierID for every transaction in the message.
+func (r *MT940Reader) Read(reader io.Reader) ([]*domain.Transaction, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var transactions []*domain.Transaction
+	var supplierID string
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		switch {
+		case strings.HasPrefix(line, ":25:"):
+			supplierID = strings.TrimSpace(strings.TrimPrefix(line, ":25:"))
+		case strings.HasPrefix(line, ":61:"):
+			if supplierID == "" {
+				return nil, fmt.Errorf("line %d: :61: transaction line with no preceding :25: account field", lineNum)
+			}
+			tx, err := parseMT940Line61(supplierID, line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			transactions = append(transactions, tx)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MT940 message: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// parseMT940Line61 converts a single :61: statement line into a
+// domain.Transaction. The account's statement currency isn't carried on
+// :61: itself (it lives on the message's opening-balance field, :60F:/:60M:,
+// which this reader doesn't parse), so the resulting Transaction's Currency
+// is left as domain.USD; callers reading statements in another currency
+// should override it after Read returns.
+func parseMT940Line61(supplierID, line string) (*domain.Transaction, error) {
+	match := mt940Line61Pattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil, fmt.Errorf("malformed :61: line %q", line)
+	}
+
+	valueDate, mark, rawAmount, reference := match[1], match[2], match[3], match[4]
+
+	// time.Parse's "06" reference year already resolves a two-digit year to
+	// 2000-2068 (or 1969-1999 for 69-99), which is the same assumption MT940
+	// statements make since the field carries no century of its own.
+	timestamp, err := time.Parse("060102", valueDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value date %q: %w", valueDate, err)
+	}
+
+	amount, err := decimal.NewFromString(strings.Replace(rawAmount, ",", ".", 1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", rawAmount, err)
+	}
+
+	id := strings.TrimSpace(strings.SplitN(reference, "//", 2)[0])
+	if id == "" {
+		return nil, fmt.Errorf("missing customer reference")
+	}
+
+	txType, err := mt940TransactionType(mark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Transaction{
+		ID:             id,
+		SupplierID:     supplierID,
+		Type:           txType,
+		OriginalAmount: amount,
+		Currency:       domain.USD,
+		Timestamp:      timestamp,
+		Status:         domain.Completed,
+	}, nil
+}
+
+// mt940TransactionType maps a :61: debit/credit mark to a
+// domain.TransactionType by which direction money actually moved: "C"
+// (money received) and "RD" (reversal of a debit, which puts money back)
+// both become a Capture; "D" (money paid out) and "RC" (reversal of a
+// credit, which takes money back out) both become a Refund.
+func mt940TransactionType(mark string) (domain.TransactionType, error) {
+	switch mark {
+	case "C", "RD":
+		return domain.Capture, nil
+	case "D", "RC":
+		return domain.Refund, nil
+	default:
+		return "", fmt.Errorf("unsupported debit/credit mark %q", mark)
+	}
+}
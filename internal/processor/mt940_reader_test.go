@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMT940Statement = `:20:STMT0001
+:25:sup123
+:28C:00001/001
+:60F:C240101USD0,00
+:61:240115C1050,00NMSCtx001
+:61:240116RD525,75NDBTtx002
+:62F:C240131USD524,25
+`
+
+func TestNewMT940Reader(t *testing.T) {
+	reader := NewMT940Reader()
+	assert.NotNil(t, reader)
+}
+
+func TestMT940Reader_Read_ValidData(t *testing.T) {
+	reader := NewMT940Reader()
+	transactions, err := reader.Read(strings.NewReader(testMT940Statement))
+
+	require.NoError(t, err)
+	require.Equal(t, 2, len(transactions))
+
+	tx1 := transactions[0]
+	assert.Equal(t, "tx001", tx1.ID)
+	assert.Equal(t, "sup123", tx1.SupplierID)
+	assert.Equal(t, domain.Capture, tx1.Type)
+	assert.True(t, tx1.OriginalAmount.Equal(decimal.NewFromFloat(1050.00)), "got %s", tx1.OriginalAmount)
+	assert.Equal(t, domain.Completed, tx1.Status)
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), tx1.Timestamp)
+
+	tx2 := transactions[1]
+	assert.Equal(t, "tx002", tx2.ID)
+	assert.Equal(t, domain.Capture, tx2.Type, "a reversal-of-debit mark puts money back, so it settles as a Capture")
+	assert.True(t, tx2.OriginalAmount.Equal(decimal.NewFromFloat(525.75)), "got %s", tx2.OriginalAmount)
+}
+
+func TestMT940Reader_Read_ReversalOfCreditIsRefund(t *testing.T) {
+	data := ":25:sup123\n:61:240115RC1050,00NMSCtx001\n"
+
+	reader := NewMT940Reader()
+	transactions, err := reader.Read(strings.NewReader(data))
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, domain.Refund, transactions[0].Type)
+}
+
+func TestMT940Reader_Read_MissingAccountField(t *testing.T) {
+	data := ":61:240115C1050,00NMSCtx001\n"
+
+	reader := NewMT940Reader()
+	_, err := reader.Read(strings.NewReader(data))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ":25:")
+}
+
+func TestMT940Reader_Read_MalformedLine61(t *testing.T) {
+	data := ":25:sup123\n:61:not-a-valid-line\n"
+
+	reader := NewMT940Reader()
+	_, err := reader.Read(strings.NewReader(data))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "malformed")
+}
+
+func TestMT940Reader_Read_UnsupportedMark(t *testing.T) {
+	data := ":25:sup123\n:61:240115X1050,00NMSCtx001\n"
+
+	reader := NewMT940Reader()
+	_, err := reader.Read(strings.NewReader(data))
+
+	require.Error(t, err)
+}
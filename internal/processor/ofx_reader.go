@@ -0,0 +1,239 @@
+package processor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// ofxDocument mirrors the parts of an OFX 2.x document this reader cares
+// about: the bank and credit-card statement transaction response message
+// sets, each of which can carry one STMTTRNRS/CCSTMTTRNRS per account.
+type ofxDocument struct {
+	XMLName  xml.Name       `xml:"OFX"`
+	BankStmt []ofxStmtTrnRs `xml:"BANKMSGSRSV1>STMTTRNRS"`
+	CCStmt   []ofxStmtTrnRs `xml:"CREDITCARDMSGSRSV1>CCSTMTTRNRS"`
+}
+
+// ofxStmtTrnRs covers both STMTTRNRS (bank) and CCSTMTTRNRS (credit card)
+// blocks: both nest CURDEF and a BANKTRANLIST of STMTTRN under a statement
+// element named STMTRS or CCSTMTRS respectively, so a single struct with
+// both path variants tagged handles either.
+type ofxStmtTrnRs struct {
+	TrnUID   string       `xml:"TRNUID"`
+	CurDef   string       `xml:"STMTRS>CURDEF"`
+	CCCurDef string       `xml:"CCSTMTRS>CURDEF"`
+	Trns     []ofxStmtTrn `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+	CCTrns   []ofxStmtTrn `xml:"CCSTMTRS>BANKTRANLIST>STMTTRN"`
+}
+
+type ofxStmtTrn struct {
+	TrnType      string           `xml:"TRNTYPE"`
+	DtPosted     string           `xml:"DTPOSTED"`
+	TrnAmt       string           `xml:"TRNAMT"`
+	FitID        string           `xml:"FITID"`
+	Currency     string           `xml:"CURRENCY"`
+	OrigCurrency *ofxOrigCurrency `xml:"ORIGCURRENCY"`
+}
+
+type ofxOrigCurrency struct {
+	CurRate string `xml:"CURRATE"`
+	CurSym  string `xml:"CURSYM"`
+}
+
+// OFXReader reads transactions from OFX 2.x bank or credit-card statement
+// exports (STMTTRNRS / CCSTMTTRNRS), the format banks typically hand back
+// for ".ofx" and ".qfx" downloads. It implements Reader alongside CSVReader.
+type OFXReader struct{}
+
+// NewOFXReader creates a new OFX reader.
+func NewOFXReader() *OFXReader {
+	return &OFXReader{}
+}
+
+// ReadFile reads transactions from an OFX file.
+func (r *OFXReader) ReadFile(path string) ([]*domain.Transaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return r.Read(file)
+}
+
+// Read parses an OFX document and returns one Transaction per STMTTRN,
+// across every STMTTRNRS/CCSTMTTRNRS block the document contains.
+func (r *OFXReader) Read(reader io.Reader) ([]*domain.Transaction, error) {
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OFX document: %w", err)
+	}
+
+	var doc ofxDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OFX document: %w", err)
+	}
+
+	var transactions []*domain.Transaction
+
+	for _, stmt := range doc.BankStmt {
+		txs, err := stmt.transactions(stmt.CurDef, stmt.Trns)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, txs...)
+	}
+
+	for _, stmt := range doc.CCStmt {
+		txs, err := stmt.transactions(stmt.CCCurDef, stmt.CCTrns)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, txs...)
+	}
+
+	return transactions, nil
+}
+
+// transactions converts every STMTTRN in this STMTTRNRS/CCSTMTTRNRS block
+// into a domain.Transaction, using curDef as the statement-level currency
+// default and stmt.TrnUID as the supplier ID (mirroring how
+// reporter.OFXWriter writes TRNUID from SupplierID on the way out).
+func (stmt ofxStmtTrnRs) transactions(curDef string, trns []ofxStmtTrn) ([]*domain.Transaction, error) {
+	transactions := make([]*domain.Transaction, 0, len(trns))
+	for _, trn := range trns {
+		tx, err := parseOFXStmtTrn(stmt.TrnUID, curDef, trn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse STMTTRN %q: %w", trn.FitID, err)
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
+
+// parseOFXStmtTrn converts a single STMTTRN into a domain.Transaction.
+func parseOFXStmtTrn(supplierID, curDef string, trn ofxStmtTrn) (*domain.Transaction, error) {
+	if trn.FitID == "" {
+		return nil, fmt.Errorf("FITID is required")
+	}
+
+	txType, err := ofxTransactionType(trn.TrnType)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := decimal.NewFromString(trn.TrnAmt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRNAMT %q: %w", trn.TrnAmt, err)
+	}
+
+	timestamp, err := parseOFXDate(trn.DtPosted)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DTPOSTED %q: %w", trn.DtPosted, err)
+	}
+
+	currency := curDef
+	if trn.Currency != "" {
+		currency = trn.Currency
+	}
+	if trn.OrigCurrency != nil && trn.OrigCurrency.CurSym != "" {
+		currency = trn.OrigCurrency.CurSym
+	}
+
+	return &domain.Transaction{
+		ID:             trn.FitID,
+		SupplierID:     supplierID,
+		Type:           txType,
+		OriginalAmount: amount.Abs(),
+		Currency:       domain.Currency(currency),
+		Timestamp:      timestamp,
+		Status:         domain.Completed,
+	}, nil
+}
+
+// ofxTransactionType maps an OFX TRNTYPE to a domain.TransactionType. CREDIT
+// (money received) becomes a Capture; DEBIT, PAYMENT, and XFER (money paid
+// out) become a Refund. OFX's other TRNTYPE values (e.g. FEE, INT, CHECK)
+// don't correspond to a settleable transaction in this domain.
+func ofxTransactionType(trnType string) (domain.TransactionType, error) {
+	switch strings.ToUpper(trnType) {
+	case "CREDIT":
+		return domain.Capture, nil
+	case "DEBIT", "PAYMENT", "XFER":
+		return domain.Refund, nil
+	default:
+		return "", fmt.Errorf("unsupported OFX transaction type %q", trnType)
+	}
+}
+
+// parseOFXDate parses OFX 2.x's "YYYYMMDDHHMMSS[+HH:MM]" timestamp format
+// (as written by reporter.ofxDate), also accepting a bare "YYYYMMDDHHMMSS"
+// with no offset, which is treated as UTC.
+func parseOFXDate(s string) (time.Time, error) {
+	base := s
+	offset := ""
+
+	if start := strings.Index(s, "["); start != -1 {
+		end := strings.Index(s, "]")
+		if end < start {
+			return time.Time{}, fmt.Errorf("unterminated offset in %q", s)
+		}
+		base = s[:start]
+		offset = s[start+1 : end]
+	}
+
+	t, err := time.Parse("20060102150405", base)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if offset == "" {
+		return t.UTC(), nil
+	}
+
+	loc, err := parseOFXOffset(offset)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+}
+
+// parseOFXOffset parses the offset bracket of an OFX timestamp into a fixed
+// time.Location. It accepts this package's own "+HH:MM"/"-HH:MM" form as
+// well as the legacy OFX "H:TZ" form real bank exports use (e.g. "-5:EST"),
+// where the part after the colon is a timezone abbreviation to ignore
+// rather than minutes, since OFX never defines what those abbreviations
+// mean numerically.
+func parseOFXOffset(offset string) (*time.Location, error) {
+	sign := 1
+	rest := offset
+	switch {
+	case strings.HasPrefix(offset, "-"):
+		sign = -1
+		rest = offset[1:]
+	case strings.HasPrefix(offset, "+"):
+		rest = offset[1:]
+	}
+
+	parts := strings.SplitN(rest, ":", 2)
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid offset %q", offset)
+	}
+	minutes := 0
+	if len(parts) == 2 {
+		if m, err := strconv.Atoi(parts[1]); err == nil {
+			minutes = m
+		}
+	}
+
+	seconds := sign * (hours*3600 + minutes*60)
+	return time.FixedZone(fmt.Sprintf("UTC%s", offset), seconds), nil
+}
@@ -0,0 +1,197 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOFXBankStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<OFX>
+  <BANKMSGSRSV1>
+    <STMTTRNRS>
+      <TRNUID>sup123</TRNUID>
+      <STMTRS>
+        <CURDEF>USD</CURDEF>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>CREDIT</TRNTYPE>
+            <DTPOSTED>20240115103000[+00:00]</DTPOSTED>
+            <TRNAMT>100.50</TRNAMT>
+            <FITID>tx001</FITID>
+            <CURRENCY>USD</CURRENCY>
+          </STMTTRN>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20240116142000[+00:00]</DTPOSTED>
+            <TRNAMT>-50.25</TRNAMT>
+            <FITID>tx002</FITID>
+            <ORIGCURRENCY>
+              <CURRATE>0.2</CURRATE>
+              <CURSYM>BRL</CURSYM>
+            </ORIGCURRENCY>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </STMTRS>
+    </STMTTRNRS>
+  </BANKMSGSRSV1>
+</OFX>`
+
+func TestNewOFXReader(t *testing.T) {
+	reader := NewOFXReader()
+	assert.NotNil(t, reader)
+}
+
+func TestOFXReader_Read_ValidData(t *testing.T) {
+	reader := NewOFXReader()
+	transactions, err := reader.Read(strings.NewReader(testOFXBankStatement))
+
+	require.NoError(t, err)
+	require.Equal(t, 2, len(transactions))
+
+	tx1 := transactions[0]
+	assert.Equal(t, "tx001", tx1.ID)
+	assert.Equal(t, "sup123", tx1.SupplierID)
+	assert.Equal(t, domain.Capture, tx1.Type)
+	assert.True(t, tx1.OriginalAmount.Equal(decimal.NewFromFloat(100.50)))
+	assert.Equal(t, domain.USD, tx1.Currency)
+	assert.Equal(t, domain.Completed, tx1.Status)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), tx1.Timestamp.UTC())
+
+	// ORIGCURRENCY's CURSYM supersedes both the statement's CURDEF and a
+	// missing per-transaction CURRENCY, and TRNAMT's sign is dropped since
+	// domain.Transaction.OriginalAmount is always positive.
+	tx2 := transactions[1]
+	assert.Equal(t, "tx002", tx2.ID)
+	assert.Equal(t, domain.Refund, tx2.Type)
+	assert.True(t, tx2.OriginalAmount.Equal(decimal.NewFromFloat(50.25)))
+	assert.Equal(t, domain.BRL, tx2.Currency)
+}
+
+func TestOFXReader_Read_CreditCardStatement(t *testing.T) {
+	ccStatement := `<?xml version="1.0" encoding="UTF-8"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <TRNUID>sup456</TRNUID>
+      <CCSTMTRS>
+        <CURDEF>USD</CURDEF>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>PAYMENT</TRNTYPE>
+            <DTPOSTED>20240117080000</DTPOSTED>
+            <TRNAMT>25.00</TRNAMT>
+            <FITID>tx003</FITID>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>`
+
+	reader := NewOFXReader()
+	transactions, err := reader.Read(strings.NewReader(ccStatement))
+
+	require.NoError(t, err)
+	require.Equal(t, 1, len(transactions))
+
+	tx := transactions[0]
+	assert.Equal(t, "tx003", tx.ID)
+	assert.Equal(t, "sup456", tx.SupplierID)
+	assert.Equal(t, domain.Refund, tx.Type)
+	assert.Equal(t, domain.USD, tx.Currency)
+	// No offset in DTPOSTED is treated as UTC.
+	assert.Equal(t, time.Date(2024, 1, 17, 8, 0, 0, 0, time.UTC), tx.Timestamp.UTC())
+}
+
+func TestOFXReader_Read_UnsupportedTransactionType(t *testing.T) {
+	ofxData := `<?xml version="1.0" encoding="UTF-8"?>
+<OFX>
+  <BANKMSGSRSV1>
+    <STMTTRNRS>
+      <TRNUID>sup123</TRNUID>
+      <STMTRS>
+        <CURDEF>USD</CURDEF>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>FEE</TRNTYPE>
+            <DTPOSTED>20240115103000</DTPOSTED>
+            <TRNAMT>5.00</TRNAMT>
+            <FITID>tx004</FITID>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </STMTRS>
+    </STMTTRNRS>
+  </BANKMSGSRSV1>
+</OFX>`
+
+	reader := NewOFXReader()
+	_, err := reader.Read(strings.NewReader(ofxData))
+	assert.ErrorContains(t, err, "unsupported OFX transaction type")
+}
+
+func TestOFXReader_Read_MissingFitID(t *testing.T) {
+	ofxData := `<?xml version="1.0" encoding="UTF-8"?>
+<OFX>
+  <BANKMSGSRSV1>
+    <STMTTRNRS>
+      <TRNUID>sup123</TRNUID>
+      <STMTRS>
+        <CURDEF>USD</CURDEF>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>CREDIT</TRNTYPE>
+            <DTPOSTED>20240115103000</DTPOSTED>
+            <TRNAMT>5.00</TRNAMT>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </STMTRS>
+    </STMTTRNRS>
+  </BANKMSGSRSV1>
+</OFX>`
+
+	reader := NewOFXReader()
+	_, err := reader.Read(strings.NewReader(ofxData))
+	assert.ErrorContains(t, err, "FITID is required")
+}
+
+func TestOFXReader_Read_LegacyTimezoneAbbreviationOffset(t *testing.T) {
+	ofxData := `<?xml version="1.0" encoding="UTF-8"?>
+<OFX>
+  <BANKMSGSRSV1>
+    <STMTTRNRS>
+      <TRNUID>sup123</TRNUID>
+      <STMTRS>
+        <CURDEF>USD</CURDEF>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>CREDIT</TRNTYPE>
+            <DTPOSTED>20240115103000[-5:EST]</DTPOSTED>
+            <TRNAMT>5.00</TRNAMT>
+            <FITID>tx005</FITID>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </STMTRS>
+    </STMTTRNRS>
+  </BANKMSGSRSV1>
+</OFX>`
+
+	reader := NewOFXReader()
+	transactions, err := reader.Read(strings.NewReader(ofxData))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(transactions))
+
+	want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.FixedZone("", -5*3600))
+	assert.True(t, transactions[0].Timestamp.Equal(want))
+}
+
+func TestOFXReader_ReadFile_MissingFile(t *testing.T) {
+	reader := NewOFXReader()
+	_, err := reader.ReadFile("/nonexistent/statement.ofx")
+	assert.Error(t, err)
+}
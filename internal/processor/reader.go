@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+)
+
+// Reader parses transactions out of a specific input file format.
+// CSVReader, JSONLReader, OFXReader, and MT940Reader all implement it so
+// callers (notably cmd/settlement) can pick a reader by file extension
+// without hardcoding format-specific logic.
+type Reader interface {
+	// Read parses transactions from r.
+	Read(r io.Reader) ([]*domain.Transaction, error)
+	// ReadFile opens path and parses transactions from it.
+	ReadFile(path string) ([]*domain.Transaction, error)
+}
+
+// ReaderForFile resolves a Reader for path, first by extension
+// (".csv", ".jsonl"/".ndjson", ".ofx"/".qfx", ".sta"/".940"/".mt940") and,
+// for extensions this package doesn't recognize, by sniffing the file's
+// first ten lines for MT940's ":20:" transaction-reference tag or OFX's
+// "<OFX>" root element or "OFXHEADER:" header - acquirers sometimes hand back a bank statement
+// export with a generic ".txt" or ".dat" extension.
+func ReaderForFile(path string) (Reader, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return NewCSVReader(), nil
+	case ".jsonl", ".ndjson":
+		return NewJSONLReader(), nil
+	case ".ofx", ".qfx":
+		return NewOFXReader(), nil
+	case ".sta", ".940", ".mt940":
+		return NewMT940Reader(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	switch detectContentFormat(file) {
+	case formatOFX:
+		return NewOFXReader(), nil
+	case formatMT940:
+		return NewMT940Reader(), nil
+	default:
+		return nil, fmt.Errorf("unrecognized input file %q: unsupported extension and content sniff found neither an OFX nor MT940 document", path)
+	}
+}
+
+// contentFormat identifies a file format detected by sniffing its content
+// rather than its extension.
+type contentFormat int
+
+const (
+	formatUnknown contentFormat = iota
+	formatOFX
+	formatMT940
+)
+
+// detectContentFormat sniffs r's first few lines to tell an OFX document
+// from an MT940 message, without requiring the caller to read the whole
+// file first. It returns formatUnknown if neither pattern is found.
+func detectContentFormat(r io.Reader) contentFormat {
+	scanner := bufio.NewScanner(r)
+	for i := 0; i < 10 && scanner.Scan(); i++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		switch {
+		case bytes.Contains(line, []byte("<OFX>")), bytes.HasPrefix(line, []byte("OFXHEADER:")):
+			return formatOFX
+		case bytes.HasPrefix(line, []byte(":20:")):
+			return formatMT940
+		}
+	}
+	return formatUnknown
+}
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderForFile_DispatchesByExtension(t *testing.T) {
+	cases := map[string]Reader{
+		"transactions.csv":    NewCSVReader(),
+		"transactions.jsonl":  NewJSONLReader(),
+		"transactions.ndjson": NewJSONLReader(),
+		"statement.ofx":       NewOFXReader(),
+		"statement.qfx":       NewOFXReader(),
+		"statement.sta":       NewMT940Reader(),
+		"statement.940":       NewMT940Reader(),
+		"statement.mt940":     NewMT940Reader(),
+	}
+
+	dir := t.TempDir()
+	for name, want := range cases {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(""), 0o644))
+
+		got, err := ReaderForFile(path)
+		require.NoError(t, err)
+		assert.IsType(t, want, got, "extension %q", name)
+	}
+}
+
+func TestReaderForFile_SniffsOFXContentForUnknownExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.dat")
+	require.NoError(t, os.WriteFile(path, []byte("<?xml version=\"1.0\"?>\n<OFX>\n<BANKMSGSRSV1></BANKMSGSRSV1>\n</OFX>\n"), 0o644))
+
+	reader, err := ReaderForFile(path)
+	require.NoError(t, err)
+	assert.IsType(t, &OFXReader{}, reader)
+}
+
+func TestReaderForFile_SniffsMT940ContentForUnknownExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.txt")
+	require.NoError(t, os.WriteFile(path, []byte(":20:STMT0001\n:25:sup123\n:61:240115C1050,00NMSCtx001\n"), 0o644))
+
+	reader, err := ReaderForFile(path)
+	require.NoError(t, err)
+	assert.IsType(t, &MT940Reader{}, reader)
+}
+
+func TestReaderForFile_UnrecognizedContentErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.bin")
+	require.NoError(t, os.WriteFile(path, []byte("not a recognized format\n"), 0o644))
+
+	_, err := ReaderForFile(path)
+	require.Error(t, err)
+}
@@ -32,7 +32,7 @@ func (v *Validator) Validate(tx *domain.Transaction) error {
 		return err
 	}
 
-	if err := v.validateAmount(tx.OriginalAmount); err != nil {
+	if err := v.validateAmount(tx.OriginalAmount, tx.Currency); err != nil {
 		return err
 	}
 
@@ -73,16 +73,23 @@ func (v *Validator) validateCurrency(currency domain.Currency) error {
 	return nil
 }
 
-func (v *Validator) validateAmount(amount decimal.Decimal) error {
+func (v *Validator) validateAmount(amount decimal.Decimal, currency domain.Currency) error {
 	if amount.LessThanOrEqual(decimal.Zero) {
 		return fmt.Errorf("amount must be positive, got %s", amount)
 	}
+
+	if exp := currency.Exponent(); exp >= 0 {
+		if fractionalDigits := -amount.Exponent(); fractionalDigits > exp {
+			return fmt.Errorf("amount %s has more fractional digits than %s allows (max %d)", amount, currency, exp)
+		}
+	}
+
 	return nil
 }
 
 func (v *Validator) validateType(txType domain.TransactionType) error {
 	switch txType {
-	case domain.Capture, domain.Refund:
+	case domain.Capture, domain.Refund, domain.Authorization, domain.Chargeback, domain.ChargebackReversal:
 		return nil
 	default:
 		return fmt.Errorf("invalid transaction type: %s", txType)
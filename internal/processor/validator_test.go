@@ -85,6 +85,24 @@ func TestValidator_Validate_ZeroAmount(t *testing.T) {
 	assert.Contains(t, err.Error(), "amount must be positive")
 }
 
+func TestValidator_Validate_TooManyFractionalDigits(t *testing.T) {
+	validator := NewValidator()
+
+	tx := &domain.Transaction{
+		ID:             "tx001",
+		SupplierID:     "sup123",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(100.555),
+		Currency:       domain.USD,
+		Timestamp:      time.Now().Add(-1 * time.Hour),
+		Status:         domain.Completed,
+	}
+
+	err := validator.Validate(tx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "more fractional digits")
+}
+
 func TestValidator_Validate_InvalidType(t *testing.T) {
 	validator := NewValidator()
 
@@ -16,7 +16,14 @@ import (
 //
 // The CSV output includes columns for transaction details, FX rates, converted amounts,
 // and settlement totals, making it easy to import into spreadsheets or financial systems.
-type CSVWriter struct{}
+type CSVWriter struct {
+	// PresentationCurrencies, when non-empty, adds one converted-amount
+	// column per currency to detail rows and one total column per currency
+	// to summary rows, read from SettlementLine.ConvertedAmounts and
+	// SupplierSettlement.PresentationTotals. Empty for lines/settlements the
+	// engine didn't populate those currencies for.
+	PresentationCurrencies []domain.Currency
+}
 
 // NewCSVWriter creates a new CSV writer for settlement reports.
 func NewCSVWriter() *CSVWriter {
@@ -56,8 +63,18 @@ func (w *CSVWriter) Write(writer io.Writer, settlements []*domain.SupplierSettle
 		"usd_amount",
 		"total_captures_usd",
 		"total_refunds_usd",
+		"total_chargebacks_usd",
 		"net_amount_usd",
 		"transaction_count",
+		"realized_fx_gain_usd",
+		"unrealized_fx_gain_usd",
+	}
+
+	for _, currency := range w.PresentationCurrencies {
+		header = append(header, fmt.Sprintf("converted_amount_%s", currency))
+	}
+	for _, currency := range w.PresentationCurrencies {
+		header = append(header, fmt.Sprintf("total_%s", currency))
 	}
 
 	if err := csvWriter.Write(header); err != nil {
@@ -92,6 +109,20 @@ func (w *CSVWriter) writeSettlement(csvWriter *csv.Writer, settlement *domain.Su
 			"", // Empty for detail rows
 			"", // Empty for detail rows
 			"", // Empty for detail rows
+			"", // Empty for detail rows
+			"", // Empty for detail rows
+			"", // Empty for detail rows
+		}
+
+		for _, currency := range w.PresentationCurrencies {
+			if amount, ok := line.ConvertedAmounts[currency]; ok {
+				record = append(record, amount.StringFixed(currency.Exponent()))
+			} else {
+				record = append(record, "")
+			}
+		}
+		for range w.PresentationCurrencies {
+			record = append(record, "") // Empty for detail rows
 		}
 
 		if err := csvWriter.Write(record); err != nil {
@@ -103,17 +134,31 @@ func (w *CSVWriter) writeSettlement(csvWriter *csv.Writer, settlement *domain.Su
 	summaryRecord := []string{
 		settlement.SupplierID,
 		settlement.SupplierName,
-		"",      // No transaction ID for summary
+		"",        // No transaction ID for summary
 		"SUMMARY", // Type indicates summary row
-		"",      // No timestamp for summary
-		"",      // No original amount for summary
-		"",      // No currency for summary
-		"",      // No FX rate for summary
-		"",      // No individual USD amount for summary
+		"",        // No timestamp for summary
+		"",        // No original amount for summary
+		"",        // No currency for summary
+		"",        // No FX rate for summary
+		"",        // No individual USD amount for summary
 		settlement.TotalCapturesUSD.StringFixed(2),
 		settlement.TotalRefundsUSD.StringFixed(2),
+		settlement.TotalChargebacksUSD.StringFixed(2),
 		settlement.NetAmountUSD.StringFixed(2),
 		fmt.Sprintf("%d", settlement.TransactionCount),
+		settlement.RealizedFXGainUSD.StringFixed(2),
+		settlement.UnrealizedFXGainUSD.StringFixed(2),
+	}
+
+	for range w.PresentationCurrencies {
+		summaryRecord = append(summaryRecord, "") // Empty for summary rows
+	}
+	for _, currency := range w.PresentationCurrencies {
+		if total, ok := settlement.PresentationTotals[currency]; ok {
+			summaryRecord = append(summaryRecord, total.StringFixed(currency.Exponent()))
+		} else {
+			summaryRecord = append(summaryRecord, "")
+		}
 	}
 
 	if err := csvWriter.Write(summaryRecord); err != nil {
@@ -293,3 +293,71 @@ func TestCSVWriter_Write_TimestampFormat(t *testing.T) {
 	// Check RFC3339 format
 	assert.Contains(t, output, "2024-01-15T10:30:45Z")
 }
+
+func TestCSVWriter_Write_RealizedAndUnrealizedFXGainColumns(t *testing.T) {
+	writer := NewCSVWriter()
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tx := &domain.Transaction{
+		ID:             "tx001",
+		SupplierID:     "sup123",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(100),
+		Currency:       domain.ARS,
+		Timestamp:      validTime,
+		Status:         domain.Completed,
+	}
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: tx,
+		FXRate:      decimal.NewFromFloat(0.001),
+		USDAmount:   decimal.NewFromFloat(0.1),
+	})
+	settlement.RealizedFXGainUSD = decimal.NewFromFloat(1.23)
+	settlement.UnrealizedFXGainUSD = decimal.NewFromFloat(-0.45)
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[0], "realized_fx_gain_usd")
+	assert.Contains(t, lines[0], "unrealized_fx_gain_usd")
+	assert.Contains(t, lines[2], "1.23")
+	assert.Contains(t, lines[2], "-0.45")
+}
+
+func TestCSVWriter_Write_PresentationCurrencyColumns(t *testing.T) {
+	writer := NewCSVWriter()
+	writer.PresentationCurrencies = []domain.Currency{domain.BRL}
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tx := &domain.Transaction{
+		ID:             "tx001",
+		SupplierID:     "sup123",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(100),
+		Currency:       domain.USD,
+		Timestamp:      validTime,
+		Status:         domain.Completed,
+	}
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(domain.SettlementLine{
+		Transaction:      tx,
+		FXRate:           decimal.NewFromFloat(1.0),
+		USDAmount:        decimal.NewFromFloat(100),
+		ConvertedAmounts: map[domain.Currency]decimal.Decimal{domain.BRL: decimal.NewFromFloat(500)},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[0], "converted_amount_BRL")
+	assert.Contains(t, lines[0], "total_BRL")
+	assert.Contains(t, lines[1], "500.00") // Detail row shows the converted amount
+	assert.Contains(t, lines[2], "500.00") // Summary row shows the presentation total
+}
@@ -1,4 +1,4 @@
-// Package reporter generates CSV settlement reports from calculated settlement data.
+// Package reporter generates settlement reports from calculated settlement data.
 //
 // This package provides functionality to:
 //   - Format settlement data into CSV output
@@ -6,6 +6,13 @@
 //   - Generate summary rows showing total settlements per supplier
 //   - Write reports to files with proper CSV formatting
 //   - Handle decimal precision formatting for monetary values
+//   - Emit a balanced double-entry journal (JournalWriter) in text or NDJSON form
+//   - Emit an OFX 2.x statement (OFXWriter) or an ISO 20022 pain.001 credit-transfer
+//     initiation (ISO20022Writer), both behind the shared Writer interface
+//   - Emit a ledger-cli/hledger-compatible double-entry journal, or a
+//     Formance-style JSON postings stream (LedgerWriter), using
+//     Assets/Income/Liabilities account names rather than JournalWriter's
+//     flatter supplier/clearing accounts
 //
 // The CSV report format includes two types of rows:
 //
@@ -25,6 +32,8 @@
 //   - type: "SUMMARY"
 //   - total_usd: Net settlement amount in USD
 //   - transaction_count: Number of transactions
+//   - realized_fx_gain_usd: FX gain/loss realized on refunded FIFO lots (requires a LotTracker)
+//   - unrealized_fx_gain_usd: Mark-to-market FX gain/loss on still-open FIFO lots
 //
 // Usage:
 //
@@ -0,0 +1,175 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// iso20022Namespace is the XML namespace for the pain.001.001.09 customer
+// credit-transfer initiation schema.
+const iso20022Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"
+
+// ISO20022Document is the root of a pain.001.001.09 document.
+type ISO20022Document struct {
+	XMLName          xml.Name                 `xml:"Document"`
+	Xmlns            string                   `xml:"xmlns,attr"`
+	CstmrCdtTrfInitn ISO20022CstmrCdtTrfInitn `xml:"CstmrCdtTrfInitn"`
+}
+
+// ISO20022CstmrCdtTrfInitn is the CstmrCdtTrfInitn block: a group header
+// plus one PmtInf per supplier being paid.
+type ISO20022CstmrCdtTrfInitn struct {
+	GrpHdr  ISO20022GrpHdr   `xml:"GrpHdr"`
+	PmtInfs []ISO20022PmtInf `xml:"PmtInf"`
+}
+
+// ISO20022GrpHdr is the message-level group header.
+type ISO20022GrpHdr struct {
+	MsgID    string        `xml:"MsgId"`
+	CreDtTm  string        `xml:"CreDtTm"`
+	NbOfTxs  int           `xml:"NbOfTxs"`
+	CtrlSum  string        `xml:"CtrlSum"`
+	InitgPty ISO20022Party `xml:"InitgPty"`
+}
+
+// ISO20022Party names a party by its display name.
+type ISO20022Party struct {
+	Nm string `xml:"Nm"`
+}
+
+// ISO20022PmtInf is a single payment-information block: one net-positive
+// supplier settlement, paid as a single credit transfer.
+type ISO20022PmtInf struct {
+	PmtInfID    string                `xml:"PmtInfId"`
+	PmtMtd      string                `xml:"PmtMtd"`
+	NbOfTxs     int                   `xml:"NbOfTxs"`
+	CtrlSum     string                `xml:"CtrlSum"`
+	ReqdExctnDt ISO20022ExecutionDate `xml:"ReqdExctnDt"`
+	Dbtr        ISO20022Party         `xml:"Dbtr"`
+	CdtTrfTxInf ISO20022CdtTrfTxInf   `xml:"CdtTrfTxInf"`
+}
+
+// ISO20022ExecutionDate wraps the requested execution date.
+type ISO20022ExecutionDate struct {
+	Dt string `xml:"Dt"`
+}
+
+// ISO20022CdtTrfTxInf is the single credit-transfer transaction within a
+// PmtInf block: the net amount owed to one supplier.
+type ISO20022CdtTrfTxInf struct {
+	PmtID  ISO20022PaymentID `xml:"PmtId"`
+	Amt    ISO20022Amount    `xml:"Amt"`
+	Cdtr   ISO20022Party     `xml:"Cdtr"`
+	RmtInf ISO20022RemitInfo `xml:"RmtInf"`
+}
+
+// ISO20022PaymentID carries the end-to-end payment identifier.
+type ISO20022PaymentID struct {
+	EndToEndID string `xml:"EndToEndId"`
+}
+
+// ISO20022Amount is an InstdAmt block with its ISO 4217 currency attribute.
+type ISO20022Amount struct {
+	InstdAmt ISO20022InstdAmt `xml:"InstdAmt"`
+}
+
+// ISO20022InstdAmt is the instructed amount, denominated in Ccy.
+type ISO20022InstdAmt struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ISO20022RemitInfo carries unstructured remittance information.
+type ISO20022RemitInfo struct {
+	Ustrd string `xml:"Ustrd"`
+}
+
+// ISO20022Writer renders net-positive settlements as a pain.001.001.09
+// customer credit-transfer initiation document, one PmtInf block per
+// supplier. Suppliers with a zero or negative net amount owe nothing and
+// are omitted, since there's no credit transfer to initiate for them.
+type ISO20022Writer struct{}
+
+// NewISO20022Writer creates a new ISO 20022 pain.001 writer.
+func NewISO20022Writer() *ISO20022Writer {
+	return &ISO20022Writer{}
+}
+
+// WriteFile writes the pain.001 document to a file at the specified path,
+// creating or overwriting it as needed.
+func (w *ISO20022Writer) WriteFile(filePath string, settlements []*domain.SupplierSettlement) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return w.Write(file, settlements)
+}
+
+// Write renders settlements as a pain.001.001.09 document to writer.
+func (w *ISO20022Writer) Write(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	var pmtInfs []ISO20022PmtInf
+	controlSum := decimal.Zero
+	now := time.Now().UTC()
+
+	for _, settlement := range settlements {
+		if !settlement.NetAmountUSD.IsPositive() {
+			continue
+		}
+
+		pmtInfs = append(pmtInfs, iso20022PmtInfForSettlement(settlement, now))
+		controlSum = controlSum.Add(settlement.NetAmountUSD)
+	}
+
+	doc := ISO20022Document{
+		Xmlns: iso20022Namespace,
+		CstmrCdtTrfInitn: ISO20022CstmrCdtTrfInitn{
+			GrpHdr: ISO20022GrpHdr{
+				MsgID:    "SOLARA-SETTLEMENT",
+				CreDtTm:  now.Format(time.RFC3339),
+				NbOfTxs:  len(pmtInfs),
+				CtrlSum:  controlSum.StringFixed(2),
+				InitgPty: ISO20022Party{Nm: "Solara Settlement"},
+			},
+			PmtInfs: pmtInfs,
+		},
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode ISO 20022 document: %w", err)
+	}
+
+	return nil
+}
+
+func iso20022PmtInfForSettlement(settlement *domain.SupplierSettlement, execDate time.Time) ISO20022PmtInf {
+	return ISO20022PmtInf{
+		PmtInfID:    fmt.Sprintf("PMT-%s", settlement.SupplierID),
+		PmtMtd:      "TRF",
+		NbOfTxs:     1,
+		CtrlSum:     settlement.NetAmountUSD.StringFixed(2),
+		ReqdExctnDt: ISO20022ExecutionDate{Dt: execDate.Format("2006-01-02")},
+		Dbtr:        ISO20022Party{Nm: "Solara Settlement"},
+		CdtTrfTxInf: ISO20022CdtTrfTxInf{
+			PmtID: ISO20022PaymentID{EndToEndID: fmt.Sprintf("SETTLE-%s", settlement.SupplierID)},
+			Amt: ISO20022Amount{
+				InstdAmt: ISO20022InstdAmt{Ccy: domain.USD.String(), Value: settlement.NetAmountUSD.StringFixed(2)},
+			},
+			Cdtr:   ISO20022Party{Nm: settlement.SupplierName},
+			RmtInf: ISO20022RemitInfo{Ustrd: fmt.Sprintf("Settlement for %d transactions", settlement.TransactionCount)},
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewISO20022Writer(t *testing.T) {
+	writer := NewISO20022Writer()
+	assert.NotNil(t, writer)
+}
+
+// newTestSettlement builds a settlement whose txCount capture lines split
+// netAmount evenly, so AddLine's running totals settle on netAmount as the
+// settlement's NetAmountUSD regardless of how many lines produced it.
+func newTestSettlement(supplierID, supplierName string, netAmount decimal.Decimal, txCount int) *domain.SupplierSettlement {
+	settlement := domain.NewSupplierSettlement(supplierID, supplierName)
+	validTime := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	perLine := netAmount.Div(decimal.NewFromInt(int64(txCount)))
+	for i := 0; i < txCount; i++ {
+		settlement.AddLine(domain.SettlementLine{
+			Transaction: &domain.Transaction{
+				ID:             fmt.Sprintf("%s-tx%d", supplierID, i),
+				SupplierID:     supplierID,
+				Type:           domain.Capture,
+				OriginalAmount: perLine,
+				Currency:       domain.USD,
+				Timestamp:      validTime,
+				Status:         domain.Completed,
+			},
+			FXRate:    decimal.NewFromFloat(1),
+			USDAmount: perLine,
+		})
+	}
+	return settlement
+}
+
+func TestISO20022Writer_Write_RoundTrips(t *testing.T) {
+	writer := NewISO20022Writer()
+
+	settlements := []*domain.SupplierSettlement{
+		newTestSettlement("sup1", "Supplier One", decimal.NewFromFloat(150.25), 2),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, settlements))
+
+	var doc ISO20022Document
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc), "writer output must parse back as valid pain.001 XML")
+
+	assert.Equal(t, iso20022Namespace, doc.Xmlns)
+	assert.Equal(t, 1, doc.CstmrCdtTrfInitn.GrpHdr.NbOfTxs)
+	assert.Equal(t, "150.25", doc.CstmrCdtTrfInitn.GrpHdr.CtrlSum)
+
+	require.Len(t, doc.CstmrCdtTrfInitn.PmtInfs, 1)
+	pmtInf := doc.CstmrCdtTrfInitn.PmtInfs[0]
+	assert.Equal(t, "PMT-sup1", pmtInf.PmtInfID)
+	assert.Equal(t, "TRF", pmtInf.PmtMtd)
+	assert.Equal(t, "150.25", pmtInf.CtrlSum)
+	assert.Equal(t, "Supplier One", pmtInf.CdtTrfTxInf.Cdtr.Nm)
+	assert.Equal(t, "USD", pmtInf.CdtTrfTxInf.Amt.InstdAmt.Ccy)
+	assert.Equal(t, "150.25", pmtInf.CdtTrfTxInf.Amt.InstdAmt.Value)
+}
+
+func TestISO20022Writer_Write_OmitsNonPositiveSettlements(t *testing.T) {
+	writer := NewISO20022Writer()
+
+	negative := domain.NewSupplierSettlement("sup-owes", "Owes Money")
+	negative.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{ID: "tx1", SupplierID: "sup-owes", Type: domain.Refund, OriginalAmount: decimal.NewFromFloat(50), Currency: domain.USD, Timestamp: time.Now().Add(-time.Hour), Status: domain.Completed},
+		FXRate:      decimal.NewFromFloat(1),
+		USDAmount:   decimal.NewFromFloat(50),
+	})
+
+	positive := newTestSettlement("sup-paid", "Gets Paid", decimal.NewFromFloat(75), 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{negative, positive}))
+
+	var doc ISO20022Document
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	require.Len(t, doc.CstmrCdtTrfInitn.PmtInfs, 1, "only the net-positive settlement should produce a PmtInf block")
+	assert.Equal(t, "PMT-sup-paid", doc.CstmrCdtTrfInitn.PmtInfs[0].PmtInfID)
+}
@@ -0,0 +1,172 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+)
+
+// JournalFormat selects how JournalWriter renders postings.
+type JournalFormat string
+
+const (
+	// JournalFormatText renders a human-readable ledger, grouped by supplier.
+	JournalFormatText JournalFormat = "text"
+	// JournalFormatNDJSON renders one JSON object per posting, newline-delimited.
+	JournalFormatNDJSON JournalFormat = "ndjson"
+)
+
+const (
+	postingDebit  = "debit"
+	postingCredit = "credit"
+)
+
+// Posting is a single double-entry journal line. Every SettlementLine
+// produces exactly two postings with equal Amount and opposite Posting
+// sides; the debit's USDAmount is the line's stored USDAmount and the
+// credit's is recomputed from OriginalAmount*FXRate, so the two balance
+// whenever the stored USDAmount matches the line's FXRate.
+type Posting struct {
+	TxnID      string  `json:"txn_id"`
+	SupplierID string  `json:"supplier_id"`
+	Posting    string  `json:"posting"` // "debit" or "credit"
+	Account    string  `json:"account"`
+	Amount     float64 `json:"amount"`
+	Currency   string  `json:"currency"`
+	USDAmount  float64 `json:"usd_amount"`
+	FXRate     float64 `json:"fx_rate"`
+	Timestamp  string  `json:"timestamp"`
+}
+
+// JournalWriter formats settlement data as a balanced double-entry journal.
+//
+// For every capture, it posts a debit to the supplier's receivable account
+// and a credit to the currency's clearing account; refunds post the reverse.
+// Each posting carries the original-currency amount, the converted USD
+// amount, and the FX rate applied, so the sum of debits equals the sum of
+// credits for every supplier.
+type JournalWriter struct {
+	format JournalFormat
+}
+
+// NewJournalWriter creates a journal writer that renders in the given format.
+func NewJournalWriter(format JournalFormat) *JournalWriter {
+	return &JournalWriter{format: format}
+}
+
+// WriteFile writes the journal to a file at the specified path, creating or
+// overwriting it as needed.
+func (w *JournalWriter) WriteFile(filePath string, settlements []*domain.SupplierSettlement) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return w.Write(file, settlements)
+}
+
+// Write renders the journal to an io.Writer in the writer's configured format.
+func (w *JournalWriter) Write(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	switch w.format {
+	case JournalFormatNDJSON:
+		return w.writeNDJSON(writer, settlements)
+	default:
+		return w.writeText(writer, settlements)
+	}
+}
+
+// Postings builds the full list of double-entry postings for the given
+// settlements, in the same order they would be written. It's exposed
+// directly so callers (and tests) can verify balance without round-tripping
+// through a serialized format.
+func (w *JournalWriter) Postings(settlements []*domain.SupplierSettlement) []Posting {
+	var postings []Posting
+	for _, settlement := range settlements {
+		postings = append(postings, postingsForSettlement(settlement)...)
+	}
+	return postings
+}
+
+func postingsForSettlement(settlement *domain.SupplierSettlement) []Posting {
+	receivableAccount := fmt.Sprintf("suppliers:%s:receivable", settlement.SupplierID)
+
+	var postings []Posting
+	for _, line := range settlement.Lines {
+		tx := line.Transaction
+		clearingAccount := fmt.Sprintf("clearing:%s", tx.Currency)
+
+		debitAccount, creditAccount := receivableAccount, clearingAccount
+		if tx.Type == domain.Refund || tx.Type == domain.Chargeback {
+			debitAccount, creditAccount = clearingAccount, receivableAccount
+		}
+
+		base := Posting{
+			TxnID:      tx.ID,
+			SupplierID: settlement.SupplierID,
+			Amount:     line.Transaction.OriginalAmount.InexactFloat64(),
+			Currency:   tx.Currency.String(),
+			FXRate:     line.FXRate.InexactFloat64(),
+			Timestamp:  tx.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+		}
+
+		// The debit reads the line's stored USDAmount; the credit recomputes
+		// it independently from OriginalAmount*FXRate, rounded to USD's
+		// minor-unit precision the same way ConvertToUSDWithPath rounds
+		// USDAmount in the first place. The rounding keeps the two sides
+		// equal for any line whose USDAmount matches its own FXRate - which
+		// is every real line - while still letting validateBalance catch one
+		// whose stored USDAmount has drifted from what FXRate implies.
+		debit := base
+		debit.Posting = postingDebit
+		debit.Account = debitAccount
+		debit.USDAmount = line.USDAmount.InexactFloat64()
+
+		credit := base
+		credit.Posting = postingCredit
+		credit.Account = creditAccount
+		credit.USDAmount = tx.OriginalAmount.Mul(line.FXRate).RoundBank(domain.USD.Exponent()).InexactFloat64()
+
+		postings = append(postings, debit, credit)
+	}
+
+	return postings
+}
+
+func (w *JournalWriter) writeText(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	for _, settlement := range settlements {
+		if _, err := fmt.Fprintf(writer, "=== Supplier %s (%s) ===\n", settlement.SupplierID, settlement.SupplierName); err != nil {
+			return fmt.Errorf("failed to write supplier header: %w", err)
+		}
+
+		for _, posting := range postingsForSettlement(settlement) {
+			_, err := fmt.Fprintf(writer, "%s %-6s %-40s %12.2f %s (rate %.6f, usd %.2f)\n",
+				posting.TxnID,
+				posting.Posting,
+				posting.Account,
+				posting.Amount,
+				posting.Currency,
+				posting.FXRate,
+				posting.USDAmount,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to write posting: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *JournalWriter) writeNDJSON(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	encoder := json.NewEncoder(writer)
+	for _, posting := range w.Postings(settlements) {
+		if err := encoder.Encode(posting); err != nil {
+			return fmt.Errorf("failed to encode posting: %w", err)
+		}
+	}
+	return nil
+}
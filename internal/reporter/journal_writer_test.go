@@ -0,0 +1,120 @@
+package reporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTestSettlement() *domain.SupplierSettlement {
+	validTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+		FXRate:    decimal.NewFromInt(1),
+		USDAmount: decimal.NewFromFloat(100),
+	})
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{
+			ID:             "tx002",
+			SupplierID:     "sup123",
+			Type:           domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(20),
+			Currency:       domain.USD,
+			Timestamp:      validTime.Add(time.Hour),
+			Status:         domain.Completed,
+		},
+		FXRate:    decimal.NewFromInt(1),
+		USDAmount: decimal.NewFromFloat(20),
+	})
+
+	return settlement
+}
+
+func TestNewJournalWriter(t *testing.T) {
+	writer := NewJournalWriter(JournalFormatText)
+	assert.NotNil(t, writer)
+}
+
+func TestJournalWriter_Postings_CaptureAndRefundAreMirrored(t *testing.T) {
+	writer := NewJournalWriter(JournalFormatNDJSON)
+	postings := writer.Postings([]*domain.SupplierSettlement{buildTestSettlement()})
+
+	require.Len(t, postings, 4)
+
+	// Capture: debit receivable, credit clearing
+	assert.Equal(t, postingDebit, postings[0].Posting)
+	assert.Equal(t, "suppliers:sup123:receivable", postings[0].Account)
+	assert.Equal(t, postingCredit, postings[1].Posting)
+	assert.Equal(t, "clearing:USD", postings[1].Account)
+
+	// Refund: debit clearing, credit receivable
+	assert.Equal(t, postingDebit, postings[2].Posting)
+	assert.Equal(t, "clearing:USD", postings[2].Account)
+	assert.Equal(t, postingCredit, postings[3].Posting)
+	assert.Equal(t, "suppliers:sup123:receivable", postings[3].Account)
+}
+
+func TestJournalWriter_Postings_BalancesPerSupplier(t *testing.T) {
+	writer := NewJournalWriter(JournalFormatNDJSON)
+	postings := writer.Postings([]*domain.SupplierSettlement{buildTestSettlement()})
+
+	var debits, credits float64
+	for _, p := range postings {
+		switch p.Posting {
+		case postingDebit:
+			debits += p.USDAmount
+		case postingCredit:
+			credits += p.USDAmount
+		}
+	}
+
+	assert.InDelta(t, debits, credits, 0.0001)
+}
+
+func TestJournalWriter_Write_NDJSON(t *testing.T) {
+	writer := NewJournalWriter(JournalFormatNDJSON)
+
+	var buf bytes.Buffer
+	err := writer.Write(&buf, []*domain.SupplierSettlement{buildTestSettlement()})
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(&buf)
+	var txnIDs []string
+	for scanner.Scan() {
+		var posting Posting
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &posting))
+		txnIDs = append(txnIDs, posting.TxnID)
+	}
+	assert.Equal(t, []string{"tx001", "tx001", "tx002", "tx002"}, txnIDs)
+}
+
+func TestJournalWriter_Write_Text(t *testing.T) {
+	writer := NewJournalWriter(JournalFormatText)
+
+	var buf bytes.Buffer
+	err := writer.Write(&buf, []*domain.SupplierSettlement{buildTestSettlement()})
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.True(t, strings.Contains(output, "Supplier sup123"))
+	assert.True(t, strings.Contains(output, "suppliers:sup123:receivable"))
+	assert.True(t, strings.Contains(output, "clearing:USD"))
+}
@@ -0,0 +1,251 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerReporterFormat selects how LedgerReporter renders its postings.
+type LedgerReporterFormat string
+
+const (
+	// LedgerReporterFormatCSV renders one row per posting.
+	LedgerReporterFormatCSV LedgerReporterFormat = "csv"
+	// LedgerReporterFormatJSONL renders one JSON posting per line.
+	LedgerReporterFormatJSONL LedgerReporterFormat = "jsonl"
+)
+
+// balanceEpsilon bounds the float64 rounding slack tolerated when checking
+// that a transaction's postings sum to zero; Posting carries USDAmount as a
+// float64 (see journal_writer.go), so an exact-equality check would flag
+// otherwise-balanced transactions on rounding noise alone.
+const balanceEpsilon = 0.0001
+
+// UnbalancedPostingsError reports that one or more transactions' postings do
+// not net to zero. Returned by LedgerReporter.Write/WriteFile before
+// anything is written, so an invalid journal is never handed downstream.
+type UnbalancedPostingsError struct {
+	TxnIDs []string
+}
+
+func (e *UnbalancedPostingsError) Error() string {
+	return fmt.Sprintf("unbalanced postings for %d transaction(s): %s", len(e.TxnIDs), strings.Join(e.TxnIDs, ", "))
+}
+
+// LedgerReporter renders settlement data as a balanced double-entry journal,
+// built on the same Posting model as JournalWriter. Alongside each line's
+// receivable/clearing pair, it posts a separate debit/credit when a
+// capture's matched authorization shows FX variance (settlement.AuthCaptureMatches),
+// so auth-vs-capture FX movement is visible as its own ledger entry rather
+// than folded into the capture's amount. Write and WriteFile both validate
+// debits == credits per transaction before writing anything, returning an
+// *UnbalancedPostingsError naming the offending transaction IDs if the
+// invariant doesn't hold.
+type LedgerReporter struct {
+	format LedgerReporterFormat
+}
+
+// NewLedgerReporter creates a ledger reporter that renders in the given format.
+func NewLedgerReporter(format LedgerReporterFormat) *LedgerReporter {
+	return &LedgerReporter{format: format}
+}
+
+// WriteFile writes the journal to a file at the specified path, creating or
+// overwriting it as needed. Returns an *UnbalancedPostingsError without
+// touching the file if the postings don't balance.
+func (r *LedgerReporter) WriteFile(filePath string, settlements []*domain.SupplierSettlement) error {
+	postings := r.Postings(settlements)
+	if err := validateBalance(postings); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return r.writePostings(file, postings)
+}
+
+// Write renders the journal to an io.Writer in the reporter's configured
+// format. Returns an *UnbalancedPostingsError without writing anything if
+// the postings don't balance.
+func (r *LedgerReporter) Write(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	postings := r.Postings(settlements)
+	if err := validateBalance(postings); err != nil {
+		return err
+	}
+
+	return r.writePostings(writer, postings)
+}
+
+func (r *LedgerReporter) writePostings(writer io.Writer, postings []Posting) error {
+	switch r.format {
+	case LedgerReporterFormatCSV:
+		return writeLedgerCSV(writer, postings)
+	default:
+		return writeLedgerJSONL(writer, postings)
+	}
+}
+
+// Postings builds the full list of double-entry postings for the given
+// settlements: each SettlementLine's receivable/clearing pair (shared with
+// JournalWriter), plus one FX-variance pair per matched auth/capture whose
+// Variance is nonzero.
+func (r *LedgerReporter) Postings(settlements []*domain.SupplierSettlement) []Posting {
+	var postings []Posting
+	for _, settlement := range settlements {
+		postings = append(postings, postingsForSettlement(settlement)...)
+		postings = append(postings, fxVariancePostings(settlement)...)
+	}
+	return postings
+}
+
+// fxVariancePostings builds one balanced debit/credit pair per matched
+// auth/capture pair with nonzero FX variance, against a per-supplier
+// fx:variance account. AuthCaptureMatch.Variance is stored as an unsigned
+// percentage (see settlement.CalculateVolatility), so direction of the FX
+// move can't be recovered here; the posting always debits the variance
+// account and credits the supplier's receivable, reflecting variance as a
+// cost against the receivable rather than a signed gain/loss.
+func fxVariancePostings(settlement *domain.SupplierSettlement) []Posting {
+	if len(settlement.AuthCaptureMatches) == 0 {
+		return nil
+	}
+
+	lineByTxnID := make(map[string]domain.SettlementLine, len(settlement.Lines))
+	for _, line := range settlement.Lines {
+		lineByTxnID[line.Transaction.ID] = line
+	}
+
+	receivableAccount := fmt.Sprintf("suppliers:%s:receivable", settlement.SupplierID)
+	varianceAccount := fmt.Sprintf("fx:variance:%s", settlement.SupplierID)
+
+	var postings []Posting
+	for _, match := range settlement.AuthCaptureMatches {
+		if match.Variance.IsZero() {
+			continue
+		}
+
+		line, ok := lineByTxnID[match.CaptureID]
+		if !ok {
+			continue
+		}
+
+		varianceUSD := line.USDAmount.Mul(match.Variance).Div(decimal.NewFromInt(100))
+		txnID := fmt.Sprintf("fx-variance:%s", match.CaptureID)
+
+		base := Posting{
+			TxnID:      txnID,
+			SupplierID: settlement.SupplierID,
+			Amount:     varianceUSD.InexactFloat64(),
+			Currency:   domain.USD.String(),
+			USDAmount:  varianceUSD.InexactFloat64(),
+			FXRate:     line.FXRate.InexactFloat64(),
+			Timestamp:  line.Transaction.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+		}
+
+		debit := base
+		debit.Posting = postingDebit
+		debit.Account = varianceAccount
+
+		credit := base
+		credit.Posting = postingCredit
+		credit.Account = receivableAccount
+
+		postings = append(postings, debit, credit)
+	}
+
+	return postings
+}
+
+// validateBalance checks that every TxnID's postings net to zero (debits
+// positive, credits negative, summed in USDAmount), returning an
+// *UnbalancedPostingsError listing every TxnID that doesn't.
+func validateBalance(postings []Posting) error {
+	var order []string
+	balances := make(map[string]float64)
+
+	for _, p := range postings {
+		if _, seen := balances[p.TxnID]; !seen {
+			order = append(order, p.TxnID)
+		}
+
+		switch p.Posting {
+		case postingDebit:
+			balances[p.TxnID] += p.USDAmount
+		case postingCredit:
+			balances[p.TxnID] -= p.USDAmount
+		}
+	}
+
+	var violating []string
+	for _, txnID := range order {
+		balance := balances[txnID]
+		if balance > balanceEpsilon || balance < -balanceEpsilon {
+			violating = append(violating, txnID)
+		}
+	}
+
+	if len(violating) > 0 {
+		return &UnbalancedPostingsError{TxnIDs: violating}
+	}
+	return nil
+}
+
+func writeLedgerJSONL(writer io.Writer, postings []Posting) error {
+	encoder := json.NewEncoder(writer)
+	for _, posting := range postings {
+		if err := encoder.Encode(posting); err != nil {
+			return fmt.Errorf("failed to encode posting: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeLedgerCSV(writer io.Writer, postings []Posting) error {
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	header := []string{
+		"txn_id",
+		"supplier_id",
+		"posting",
+		"account",
+		"amount",
+		"currency",
+		"usd_amount",
+		"fx_rate",
+		"timestamp",
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, p := range postings {
+		record := []string{
+			p.TxnID,
+			p.SupplierID,
+			p.Posting,
+			p.Account,
+			fmt.Sprintf("%.2f", p.Amount),
+			p.Currency,
+			fmt.Sprintf("%.2f", p.USDAmount),
+			fmt.Sprintf("%.6f", p.FXRate),
+			p.Timestamp,
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return fmt.Errorf("failed to write posting row: %w", err)
+		}
+	}
+
+	return nil
+}
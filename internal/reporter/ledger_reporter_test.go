@@ -0,0 +1,149 @@
+package reporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLedgerReporter(t *testing.T) {
+	reporter := NewLedgerReporter(LedgerReporterFormatJSONL)
+	assert.NotNil(t, reporter)
+}
+
+func TestLedgerReporter_Postings_BalancesPerTransaction(t *testing.T) {
+	reporter := NewLedgerReporter(LedgerReporterFormatJSONL)
+	postings := reporter.Postings([]*domain.SupplierSettlement{buildTestSettlement()})
+
+	require.NoError(t, validateBalance(postings))
+}
+
+func TestLedgerReporter_Postings_IncludesFXVariancePosting(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AuthTransactions = []*domain.Transaction{
+		{ID: "auth1", SupplierID: "sup123", Type: domain.Authorization, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL, Timestamp: base, Status: domain.Completed},
+	}
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{ID: "cap1", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL, Timestamp: base.Add(time.Hour), Status: domain.Completed},
+		FXRate:      decimal.NewFromFloat(0.20),
+		USDAmount:   decimal.NewFromFloat(20),
+	})
+	settlement.AuthCaptureMatches = []domain.AuthCaptureMatch{
+		{AuthID: "auth1", CaptureID: "cap1", Variance: decimal.NewFromFloat(10)},
+	}
+
+	reporter := NewLedgerReporter(LedgerReporterFormatJSONL)
+	postings := reporter.Postings([]*domain.SupplierSettlement{settlement})
+
+	var variancePostings []Posting
+	for _, p := range postings {
+		if p.TxnID == "fx-variance:cap1" {
+			variancePostings = append(variancePostings, p)
+		}
+	}
+
+	require.Len(t, variancePostings, 2)
+	assert.Equal(t, postingDebit, variancePostings[0].Posting)
+	assert.Equal(t, "fx:variance:sup123", variancePostings[0].Account)
+	assert.Equal(t, postingCredit, variancePostings[1].Posting)
+	assert.Equal(t, "suppliers:sup123:receivable", variancePostings[1].Account)
+	assert.InDelta(t, 2.0, variancePostings[0].USDAmount, 0.0001)
+
+	require.NoError(t, validateBalance(postings))
+}
+
+func TestLedgerReporter_Postings_SkipsZeroVarianceMatches(t *testing.T) {
+	settlement := buildTestSettlement()
+	settlement.AuthCaptureMatches = []domain.AuthCaptureMatch{
+		{AuthID: "auth1", CaptureID: "tx001", Variance: decimal.Zero},
+	}
+
+	reporter := NewLedgerReporter(LedgerReporterFormatJSONL)
+	postings := reporter.Postings([]*domain.SupplierSettlement{settlement})
+
+	for _, p := range postings {
+		assert.NotEqual(t, "fx-variance:tx001", p.TxnID)
+	}
+}
+
+func TestValidateBalance_ReportsUnbalancedTxnIDs(t *testing.T) {
+	postings := []Posting{
+		{TxnID: "tx001", Posting: postingDebit, USDAmount: 100},
+		{TxnID: "tx001", Posting: postingCredit, USDAmount: 90},
+		{TxnID: "tx002", Posting: postingDebit, USDAmount: 50},
+		{TxnID: "tx002", Posting: postingCredit, USDAmount: 50},
+	}
+
+	err := validateBalance(postings)
+	require.Error(t, err)
+
+	var unbalanced *UnbalancedPostingsError
+	require.ErrorAs(t, err, &unbalanced)
+	assert.Equal(t, []string{"tx001"}, unbalanced.TxnIDs)
+}
+
+func TestLedgerReporter_Write_RejectsUnbalancedPostingsWithoutWriting(t *testing.T) {
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{ID: "tx001", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD, Timestamp: time.Now(), Status: domain.Completed},
+		FXRate:      decimal.NewFromInt(1),
+		USDAmount:   decimal.NewFromFloat(100),
+	})
+	// Corrupt the capture's USDAmount after AddLine so the settlement's totals
+	// stay consistent but the rebuilt postings for tx001 no longer balance.
+	settlement.Lines[0].USDAmount = decimal.NewFromFloat(90)
+
+	reporter := NewLedgerReporter(LedgerReporterFormatJSONL)
+
+	var buf bytes.Buffer
+	err := reporter.Write(&buf, []*domain.SupplierSettlement{settlement})
+
+	require.Error(t, err)
+	var unbalanced *UnbalancedPostingsError
+	require.ErrorAs(t, err, &unbalanced)
+	assert.Equal(t, []string{"tx001"}, unbalanced.TxnIDs)
+	assert.Empty(t, buf.String(), "nothing should be written when postings don't balance")
+}
+
+func TestLedgerReporter_Write_JSONL(t *testing.T) {
+	reporter := NewLedgerReporter(LedgerReporterFormatJSONL)
+
+	var buf bytes.Buffer
+	err := reporter.Write(&buf, []*domain.SupplierSettlement{buildTestSettlement()})
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(&buf)
+	var txnIDs []string
+	for scanner.Scan() {
+		var posting Posting
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &posting))
+		txnIDs = append(txnIDs, posting.TxnID)
+	}
+	assert.Equal(t, []string{"tx001", "tx001", "tx002", "tx002"}, txnIDs)
+}
+
+func TestLedgerReporter_Write_CSV(t *testing.T) {
+	reporter := NewLedgerReporter(LedgerReporterFormatCSV)
+
+	var buf bytes.Buffer
+	err := reporter.Write(&buf, []*domain.SupplierSettlement{buildTestSettlement()})
+	require.NoError(t, err)
+
+	reader := csv.NewReader(&buf)
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+
+	require.Len(t, records, 5) // header + 4 postings
+	assert.Equal(t, []string{"txn_id", "supplier_id", "posting", "account", "amount", "currency", "usd_amount", "fx_rate", "timestamp"}, records[0])
+	assert.Equal(t, "tx001", records[1][0])
+}
@@ -0,0 +1,327 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerFormat selects how LedgerWriter renders its double-entry postings.
+type LedgerFormat string
+
+const (
+	// LedgerFormatText renders a plain-text journal in ledger-cli/hledger
+	// syntax, parseable with `hledger -f - balance`.
+	LedgerFormatText LedgerFormat = "text"
+	// LedgerFormatJSONPostings renders one Formance-style posting envelope
+	// per settlement transaction, newline-delimited.
+	LedgerFormatJSONPostings LedgerFormat = "json-postings"
+)
+
+// FormancePosting is a single Formance-style ledger posting: a transfer of
+// amount (in the currency's minor units) from source to destination.
+type FormancePosting struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Amount      int64  `json:"amount"`
+	Asset       string `json:"asset"`
+}
+
+// FormanceTransaction groups the postings that make up one balanced
+// double-entry transaction, mirroring how Formance Ledger accepts a
+// transaction as an atomic set of postings.
+type FormanceTransaction struct {
+	Reference string            `json:"reference"`
+	Postings  []FormancePosting `json:"postings"`
+}
+
+// LedgerWriter renders settlement runs as a double-entry journal: every
+// SettlementLine becomes a balanced transfer between a per-supplier
+// receivable account and a per-currency income account, and each
+// supplier's run closes with a settlement transaction that zeroes the
+// receivable against a payable account for NetAmountUSD. When the
+// settlement carries FIFO lot-tracking data (RealizedFXGainUSD /
+// UnrealizedFXGainUSD), each nonzero figure also posts as its own
+// transaction against a separate Income:FXGains:Realized/Unrealized
+// account, so FX P&L stays visible apart from capture/refund income.
+//
+// Account names follow ledger-cli conventions (Assets:Receivable:<Supplier>,
+// Income:Captures:<Currency>, Liabilities:Payable:<Supplier>), so the text
+// format parses cleanly as a standard hledger journal.
+type LedgerWriter struct {
+	format LedgerFormat
+}
+
+// NewLedgerWriter creates a ledger writer that renders in the given format.
+func NewLedgerWriter(format LedgerFormat) *LedgerWriter {
+	return &LedgerWriter{format: format}
+}
+
+// WriteFile writes the journal to a file at the specified path, creating or
+// overwriting it as needed.
+func (w *LedgerWriter) WriteFile(filePath string, settlements []*domain.SupplierSettlement) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return w.Write(file, settlements)
+}
+
+// Write renders the journal to writer in the writer's configured format.
+func (w *LedgerWriter) Write(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	switch w.format {
+	case LedgerFormatJSONPostings:
+		return w.writeJSONPostings(writer, settlements)
+	default:
+		return w.writeText(writer, settlements)
+	}
+}
+
+func receivableAccount(supplierID string) string {
+	return fmt.Sprintf("Assets:Receivable:%s", supplierID)
+}
+
+func incomeAccount(currency domain.Currency) string {
+	return fmt.Sprintf("Income:Captures:%s", currency)
+}
+
+func payableAccount(supplierID string) string {
+	return fmt.Sprintf("Liabilities:Payable:%s", supplierID)
+}
+
+func realizedFXGainAccount(supplierID string) string {
+	return fmt.Sprintf("Income:FXGains:Realized:%s", supplierID)
+}
+
+func unrealizedFXGainAccount(supplierID string) string {
+	return fmt.Sprintf("Income:FXGains:Unrealized:%s", supplierID)
+}
+
+func (w *LedgerWriter) writeText(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	for _, settlement := range settlements {
+		for _, line := range settlement.Lines {
+			if err := writeTextPosting(writer, settlement.SupplierID, line); err != nil {
+				return err
+			}
+		}
+
+		if err := writeTextSettlement(writer, settlement); err != nil {
+			return err
+		}
+
+		if err := writeTextFXGains(writer, settlement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTextPosting writes one balanced transaction for a single settlement
+// line: a capture debits the supplier's receivable and credits the
+// currency's income account (a refund posts the mirror image), with the
+// non-USD side carrying a per-unit price so the transaction's USD cost is
+// recoverable from the journal alone.
+func writeTextPosting(writer io.Writer, supplierID string, line domain.SettlementLine) error {
+	tx := line.Transaction
+	receivable := receivableAccount(supplierID)
+	income := incomeAccount(tx.Currency)
+	amount := tx.OriginalAmount.StringFixed(tx.Currency.Exponent())
+
+	price := ""
+	if tx.Currency != domain.USD {
+		price = fmt.Sprintf(" @ %s USD", line.FXRate.StringFixed(6))
+	}
+
+	debit, credit := receivable, income
+	if tx.Type == domain.Refund || tx.Type == domain.Chargeback {
+		debit, credit = income, receivable
+	}
+
+	_, err := fmt.Fprintf(writer, "%s %s\n    %-40s %s %s%s\n    %-40s %s %s\n\n",
+		tx.Timestamp.Format("2006-01-02"), tx.ID,
+		debit, amount, tx.Currency, price,
+		credit, negate(amount), tx.Currency,
+	)
+	return err
+}
+
+// writeTextSettlement writes the run-level transaction that zeroes the
+// supplier's receivable against their payable account for NetAmountUSD,
+// i.e. the amount the supplier is now owed once captures and refunds net out.
+func writeTextSettlement(writer io.Writer, settlement *domain.SupplierSettlement) error {
+	if settlement.NetAmountUSD.IsZero() {
+		return nil
+	}
+
+	net := settlement.NetAmountUSD.StringFixed(domain.USD.Exponent())
+	receivable := receivableAccount(settlement.SupplierID)
+	payable := payableAccount(settlement.SupplierID)
+
+	_, err := fmt.Fprintf(writer, "%s settlement %s\n    %-40s %s USD\n    %-40s %s USD\n\n",
+		latestTimestamp(settlement).Format("2006-01-02"), settlement.SupplierID,
+		payable, negate(net),
+		receivable, net,
+	)
+	return err
+}
+
+// writeTextFXGains writes one P&L transaction per nonzero FX gain/loss
+// figure (realized and unrealized are independent), debiting or crediting
+// the supplier's receivable against the matching Income:FXGains account so
+// the journal's FX gains stay visible separately from capture/refund income.
+func writeTextFXGains(writer io.Writer, settlement *domain.SupplierSettlement) error {
+	if err := writeTextFXGain(writer, settlement, "fx-realized", settlement.RealizedFXGainUSD, realizedFXGainAccount(settlement.SupplierID)); err != nil {
+		return err
+	}
+	return writeTextFXGain(writer, settlement, "fx-unrealized", settlement.UnrealizedFXGainUSD, unrealizedFXGainAccount(settlement.SupplierID))
+}
+
+func writeTextFXGain(writer io.Writer, settlement *domain.SupplierSettlement, label string, gain decimal.Decimal, gainAccount string) error {
+	if gain.IsZero() {
+		return nil
+	}
+
+	amount := gain.Abs().StringFixed(domain.USD.Exponent())
+	receivable := receivableAccount(settlement.SupplierID)
+
+	debit, credit := receivable, gainAccount
+	if gain.IsNegative() {
+		debit, credit = gainAccount, receivable
+	}
+
+	_, err := fmt.Fprintf(writer, "%s %s %s\n    %-40s %s USD\n    %-40s %s USD\n\n",
+		latestTimestamp(settlement).Format("2006-01-02"), label, settlement.SupplierID,
+		debit, amount,
+		credit, negate(amount),
+	)
+	return err
+}
+
+func (w *LedgerWriter) writeJSONPostings(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	encoder := json.NewEncoder(writer)
+	for _, settlement := range settlements {
+		for _, line := range settlement.Lines {
+			if err := encoder.Encode(formanceTransactionForLine(settlement.SupplierID, line)); err != nil {
+				return fmt.Errorf("failed to encode posting: %w", err)
+			}
+		}
+
+		if !settlement.NetAmountUSD.IsZero() {
+			if err := encoder.Encode(formanceSettlementTransaction(settlement)); err != nil {
+				return fmt.Errorf("failed to encode settlement posting: %w", err)
+			}
+		}
+
+		for _, tx := range formanceFXGainTransactions(settlement) {
+			if err := encoder.Encode(tx); err != nil {
+				return fmt.Errorf("failed to encode FX gain posting: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func formanceTransactionForLine(supplierID string, line domain.SettlementLine) FormanceTransaction {
+	tx := line.Transaction
+	receivable := receivableAccount(supplierID)
+	income := incomeAccount(tx.Currency)
+	amount := minorUnits(tx.OriginalAmount, tx.Currency)
+
+	source, destination := income, receivable
+	if tx.Type == domain.Refund || tx.Type == domain.Chargeback {
+		source, destination = receivable, income
+	}
+
+	return FormanceTransaction{
+		Reference: tx.ID,
+		Postings: []FormancePosting{
+			{Source: source, Destination: destination, Amount: amount, Asset: tx.Currency.String()},
+		},
+	}
+}
+
+func formanceSettlementTransaction(settlement *domain.SupplierSettlement) FormanceTransaction {
+	receivable := receivableAccount(settlement.SupplierID)
+	payable := payableAccount(settlement.SupplierID)
+	amount := minorUnits(settlement.NetAmountUSD, domain.USD)
+
+	source, destination := receivable, payable
+	if amount < 0 {
+		source, destination = payable, receivable
+		amount = -amount
+	}
+
+	return FormanceTransaction{
+		Reference: fmt.Sprintf("settlement:%s", settlement.SupplierID),
+		Postings: []FormancePosting{
+			{Source: source, Destination: destination, Amount: amount, Asset: domain.USD.String()},
+		},
+	}
+}
+
+// formanceFXGainTransactions returns one Formance transaction per nonzero FX
+// gain/loss figure (realized, unrealized), each a posting between the
+// supplier's receivable and its matching Income:FXGains account.
+func formanceFXGainTransactions(settlement *domain.SupplierSettlement) []FormanceTransaction {
+	var txs []FormanceTransaction
+	if tx, ok := formanceFXGainTransaction(settlement, "fx-realized", settlement.RealizedFXGainUSD, realizedFXGainAccount(settlement.SupplierID)); ok {
+		txs = append(txs, tx)
+	}
+	if tx, ok := formanceFXGainTransaction(settlement, "fx-unrealized", settlement.UnrealizedFXGainUSD, unrealizedFXGainAccount(settlement.SupplierID)); ok {
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+func formanceFXGainTransaction(settlement *domain.SupplierSettlement, label string, gain decimal.Decimal, gainAccount string) (FormanceTransaction, bool) {
+	if gain.IsZero() {
+		return FormanceTransaction{}, false
+	}
+
+	receivable := receivableAccount(settlement.SupplierID)
+	amount := minorUnits(gain, domain.USD)
+
+	source, destination := gainAccount, receivable
+	if amount < 0 {
+		source, destination = receivable, gainAccount
+		amount = -amount
+	}
+
+	return FormanceTransaction{
+		Reference: fmt.Sprintf("%s:%s", label, settlement.SupplierID),
+		Postings: []FormancePosting{
+			{Source: source, Destination: destination, Amount: amount, Asset: domain.USD.String()},
+		},
+	}, true
+}
+
+func minorUnits(amount decimal.Decimal, currency domain.Currency) int64 {
+	return amount.Shift(currency.Exponent()).Round(0).IntPart()
+}
+
+func negate(amount string) string {
+	if len(amount) > 0 && amount[0] == '-' {
+		return amount[1:]
+	}
+	return "-" + amount
+}
+
+// latestTimestamp returns the most recent transaction timestamp in the
+// settlement, so the closing transaction dates after every line it settles.
+func latestTimestamp(settlement *domain.SupplierSettlement) time.Time {
+	var t time.Time
+	for _, line := range settlement.Lines {
+		if line.Transaction.Timestamp.After(t) {
+			t = line.Transaction.Timestamp
+		}
+	}
+	return t
+}
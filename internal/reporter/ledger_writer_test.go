@@ -0,0 +1,203 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLedgerWriter(t *testing.T) {
+	writer := NewLedgerWriter(LedgerFormatText)
+	assert.NotNil(t, writer)
+}
+
+func TestLedgerWriter_WriteText_BalancesPerTransaction(t *testing.T) {
+	writer := NewLedgerWriter(LedgerFormatText)
+
+	var buf bytes.Buffer
+	err := writer.Write(&buf, []*domain.SupplierSettlement{buildTestSettlement()})
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Contains(t, output, "Assets:Receivable:sup123")
+	assert.Contains(t, output, "Income:Captures:USD")
+	assert.Contains(t, output, "Liabilities:Payable:sup123")
+	// A capture debits receivable and credits income for the same amount.
+	assert.Contains(t, output, "100.00 USD")
+	assert.Contains(t, output, "-100.00 USD")
+}
+
+func TestLedgerWriter_WriteText_NonUSDCarriesPriceAnnotation(t *testing.T) {
+	settlement := domain.NewSupplierSettlement("sup999", "ARS Supplier")
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{
+			ID:             "tx500",
+			SupplierID:     "sup999",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			Status:         domain.Completed,
+		},
+		FXRate:    decimal.NewFromFloat(0.0027),
+		USDAmount: decimal.NewFromFloat(2.7),
+	})
+
+	writer := NewLedgerWriter(LedgerFormatText)
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+
+	output := buf.String()
+	assert.Contains(t, output, "@ 0.002700 USD")
+}
+
+func TestLedgerWriter_WriteText_SettlementZeroesReceivableAgainstPayable(t *testing.T) {
+	settlement := buildTestSettlement()
+	writer := NewLedgerWriter(LedgerFormatText)
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+
+	lines := strings.Split(buf.String(), "\n")
+	var settlementBlock []string
+	for i, line := range lines {
+		if strings.Contains(line, "settlement sup123") {
+			settlementBlock = lines[i : i+3]
+			break
+		}
+	}
+	require.Len(t, settlementBlock, 3)
+	assert.Contains(t, settlementBlock[1], "Liabilities:Payable:sup123")
+	assert.Contains(t, settlementBlock[2], "Assets:Receivable:sup123")
+	// NetAmountUSD is 100 capture - 20 refund = 80.
+	assert.Contains(t, settlementBlock[1], "-80.00")
+	assert.Contains(t, settlementBlock[2], "80.00")
+}
+
+func TestLedgerWriter_WriteText_SkipsSettlementWhenNetAmountIsZero(t *testing.T) {
+	settlement := domain.NewSupplierSettlement("sup777", "Fully Refunded Supplier")
+	validTime := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{
+			ID: "tx700", SupplierID: "sup777", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(50), Currency: domain.USD,
+			Timestamp: validTime, Status: domain.Completed,
+		},
+		FXRate: decimal.NewFromInt(1), USDAmount: decimal.NewFromFloat(50),
+	})
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{
+			ID: "tx701", SupplierID: "sup777", Type: domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(50), Currency: domain.USD,
+			Timestamp: validTime.Add(time.Hour), Status: domain.Completed,
+		},
+		FXRate: decimal.NewFromInt(1), USDAmount: decimal.NewFromFloat(50),
+	})
+	require.True(t, settlement.NetAmountUSD.IsZero())
+
+	writer := NewLedgerWriter(LedgerFormatText)
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+	assert.NotContains(t, buf.String(), "settlement sup777")
+
+	jsonWriter := NewLedgerWriter(LedgerFormatJSONPostings)
+	var jsonBuf bytes.Buffer
+	require.NoError(t, jsonWriter.Write(&jsonBuf, []*domain.SupplierSettlement{settlement}))
+	assert.NotContains(t, jsonBuf.String(), "settlement:sup777")
+}
+
+func TestLedgerWriter_WriteText_PostsFXGainsSeparatelyFromSettlement(t *testing.T) {
+	settlement := buildTestSettlement()
+	settlement.RealizedFXGainUSD = decimal.NewFromFloat(5)
+	settlement.UnrealizedFXGainUSD = decimal.NewFromFloat(-3)
+
+	writer := NewLedgerWriter(LedgerFormatText)
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+
+	output := buf.String()
+	assert.Contains(t, output, "fx-realized sup123")
+	assert.Contains(t, output, "Income:FXGains:Realized:sup123")
+	assert.Contains(t, output, "fx-unrealized sup123")
+	assert.Contains(t, output, "Income:FXGains:Unrealized:sup123")
+}
+
+func TestLedgerWriter_WriteText_SkipsFXGainPostingsWhenZero(t *testing.T) {
+	writer := NewLedgerWriter(LedgerFormatText)
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{buildTestSettlement()}))
+
+	output := buf.String()
+	assert.NotContains(t, output, "fx-realized")
+	assert.NotContains(t, output, "fx-unrealized")
+}
+
+func TestLedgerWriter_WriteJSONPostings_IncludesFXGainTransactions(t *testing.T) {
+	settlement := buildTestSettlement()
+	settlement.RealizedFXGainUSD = decimal.NewFromFloat(5)
+
+	writer := NewLedgerWriter(LedgerFormatJSONPostings)
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+
+	decoder := json.NewDecoder(&buf)
+	var transactions []FormanceTransaction
+	for {
+		var tx FormanceTransaction
+		if err := decoder.Decode(&tx); err != nil {
+			break
+		}
+		transactions = append(transactions, tx)
+	}
+
+	// Capture + refund + settlement + fx-realized (no fx-unrealized, it's zero).
+	require.Len(t, transactions, 4)
+	fxGain := transactions[3]
+	assert.Equal(t, "fx-realized:sup123", fxGain.Reference)
+	assert.Equal(t, int64(500), fxGain.Postings[0].Amount)
+}
+
+func TestLedgerWriter_WriteJSONPostings_OneTransactionPerLinePlusSettlement(t *testing.T) {
+	writer := NewLedgerWriter(LedgerFormatJSONPostings)
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{buildTestSettlement()}))
+
+	decoder := json.NewDecoder(&buf)
+	var transactions []FormanceTransaction
+	for {
+		var tx FormanceTransaction
+		if err := decoder.Decode(&tx); err != nil {
+			break
+		}
+		transactions = append(transactions, tx)
+	}
+
+	// Two settlement lines (capture + refund) plus one closing settlement transaction.
+	require.Len(t, transactions, 3)
+
+	capture := transactions[0]
+	require.Len(t, capture.Postings, 1)
+	assert.Equal(t, "Income:Captures:USD", capture.Postings[0].Source)
+	assert.Equal(t, "Assets:Receivable:sup123", capture.Postings[0].Destination)
+	assert.Equal(t, int64(10000), capture.Postings[0].Amount)
+	assert.Equal(t, "USD", capture.Postings[0].Asset)
+
+	refund := transactions[1]
+	assert.Equal(t, "Assets:Receivable:sup123", refund.Postings[0].Source)
+	assert.Equal(t, "Income:Captures:USD", refund.Postings[0].Destination)
+	assert.Equal(t, int64(2000), refund.Postings[0].Amount)
+
+	closing := transactions[2]
+	assert.Equal(t, "settlement:sup123", closing.Reference)
+	assert.Equal(t, "Assets:Receivable:sup123", closing.Postings[0].Source)
+	assert.Equal(t, "Liabilities:Payable:sup123", closing.Postings[0].Destination)
+	assert.Equal(t, int64(8000), closing.Postings[0].Amount)
+}
@@ -0,0 +1,148 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+)
+
+const (
+	ofxTrnTypeCredit = "CREDIT"
+	ofxTrnTypeDebit  = "DEBIT"
+)
+
+// OFXDocument is the root of an OFX 2.x document: a bank statement
+// transaction response wrapping one STMTTRNRS per supplier.
+type OFXDocument struct {
+	XMLName xml.Name       `xml:"OFX"`
+	Stmts   []OFXStmtTrnRs `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+// OFXStmtTrnRs is the STMTTRNRS block for a single supplier's statement.
+type OFXStmtTrnRs struct {
+	TrnUID   string       `xml:"TRNUID"`
+	Status   OFXStatus    `xml:"STATUS"`
+	BankTrns []OFXStmtTrn `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+}
+
+// OFXStatus is the boilerplate success status OFX expects on every response.
+type OFXStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+// OFXStmtTrn is a single STMTTRN entry: one settlement line.
+type OFXStmtTrn struct {
+	TrnType      string           `xml:"TRNTYPE"`
+	DtPosted     string           `xml:"DTPOSTED"`
+	TrnAmt       string           `xml:"TRNAMT"`
+	FitID        string           `xml:"FITID"`
+	Currency     string           `xml:"CURRENCY,omitempty"`
+	OrigCurrency *OFXOrigCurrency `xml:"ORIGCURRENCY,omitempty"`
+}
+
+// OFXOrigCurrency carries the FX rate a non-USD transaction was converted
+// at, present only when the transaction's currency isn't USD.
+type OFXOrigCurrency struct {
+	CurRate string `xml:"CURRATE"`
+	CurSym  string `xml:"CURSYM"`
+}
+
+// OFXWriter renders settlements as an OFX 2.x XML document, one STMTTRNRS
+// per supplier and one STMTTRN per settlement line.
+type OFXWriter struct{}
+
+// NewOFXWriter creates a new OFX writer.
+func NewOFXWriter() *OFXWriter {
+	return &OFXWriter{}
+}
+
+// WriteFile writes the OFX document to a file at the specified path,
+// creating or overwriting it as needed.
+func (w *OFXWriter) WriteFile(filePath string, settlements []*domain.SupplierSettlement) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return w.Write(file, settlements)
+}
+
+// Write renders settlements as an OFX 2.x XML document to writer.
+func (w *OFXWriter) Write(writer io.Writer, settlements []*domain.SupplierSettlement) error {
+	doc := OFXDocument{}
+	for _, settlement := range settlements {
+		doc.Stmts = append(doc.Stmts, ofxStmtTrnRsForSettlement(settlement))
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode OFX document: %w", err)
+	}
+
+	return nil
+}
+
+func ofxStmtTrnRsForSettlement(settlement *domain.SupplierSettlement) OFXStmtTrnRs {
+	stmt := OFXStmtTrnRs{
+		TrnUID: settlement.SupplierID,
+		Status: OFXStatus{Code: 0, Severity: "INFO"},
+	}
+
+	for _, line := range settlement.Lines {
+		stmt.BankTrns = append(stmt.BankTrns, ofxStmtTrnForLine(line))
+	}
+
+	return stmt
+}
+
+func ofxStmtTrnForLine(line domain.SettlementLine) OFXStmtTrn {
+	tx := line.Transaction
+
+	trnType := ofxTrnTypeCredit
+	if tx.Type == domain.Refund || tx.Type == domain.Chargeback {
+		trnType = ofxTrnTypeDebit
+	}
+
+	trn := OFXStmtTrn{
+		TrnType:  trnType,
+		DtPosted: ofxDate(tx.Timestamp),
+		TrnAmt:   tx.OriginalAmount.StringFixed(tx.Currency.Exponent()),
+		FitID:    tx.ID,
+		Currency: tx.Currency.String(),
+	}
+
+	if tx.Currency != domain.USD {
+		trn.OrigCurrency = &OFXOrigCurrency{
+			CurRate: line.FXRate.String(),
+			CurSym:  tx.Currency.String(),
+		}
+	}
+
+	return trn
+}
+
+// ofxDate converts a timestamp to OFX 2.x's "YYYYMMDDHHMMSS[+HH:MM]" form,
+// preserving the RFC3339 UTC offset rather than the "[tz abbrev]" suffix
+// OFX 1.x's SGML variant uses.
+func ofxDate(t time.Time) string {
+	_, offsetSeconds := t.Zone()
+	sign := "+"
+	if offsetSeconds < 0 {
+		sign = "-"
+		offsetSeconds = -offsetSeconds
+	}
+	hours := offsetSeconds / 3600
+	minutes := (offsetSeconds % 3600) / 60
+	return fmt.Sprintf("%s[%s%02d:%02d]", t.Format("20060102150405"), sign, hours, minutes)
+}
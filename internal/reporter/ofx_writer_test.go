@@ -0,0 +1,103 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOFXWriter(t *testing.T) {
+	writer := NewOFXWriter()
+	assert.NotNil(t, writer)
+}
+
+func TestOFXWriter_Write_RoundTrips(t *testing.T) {
+	writer := NewOFXWriter()
+
+	validTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	capture := &domain.Transaction{
+		ID:             "tx001",
+		SupplierID:     "sup123",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(100.50),
+		Currency:       domain.USD,
+		Timestamp:      validTime,
+		Status:         domain.Completed,
+	}
+	refund := &domain.Transaction{
+		ID:             "tx002",
+		SupplierID:     "sup123",
+		Type:           domain.Refund,
+		OriginalAmount: decimal.NewFromFloat(250),
+		Currency:       domain.BRL,
+		Timestamp:      validTime,
+		Status:         domain.Completed,
+	}
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: capture,
+		FXRate:      decimal.NewFromFloat(1.0),
+		USDAmount:   decimal.NewFromFloat(100.50),
+	})
+	settlement.AddLine(domain.SettlementLine{
+		Transaction: refund,
+		FXRate:      decimal.NewFromFloat(0.20),
+		USDAmount:   decimal.NewFromFloat(50),
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, []*domain.SupplierSettlement{settlement}))
+
+	var doc OFXDocument
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc), "writer output must parse back as valid OFX XML")
+
+	require.Len(t, doc.Stmts, 1)
+	stmt := doc.Stmts[0]
+	assert.Equal(t, "sup123", stmt.TrnUID)
+	require.Len(t, stmt.BankTrns, 2)
+
+	captureTrn := stmt.BankTrns[0]
+	assert.Equal(t, ofxTrnTypeCredit, captureTrn.TrnType)
+	assert.Equal(t, "tx001", captureTrn.FitID)
+	assert.Equal(t, "100.50", captureTrn.TrnAmt)
+	assert.Equal(t, "USD", captureTrn.Currency)
+	assert.Nil(t, captureTrn.OrigCurrency, "USD transactions carry no ORIGCURRENCY block")
+	assert.Equal(t, "20240115103000[+00:00]", captureTrn.DtPosted)
+
+	refundTrn := stmt.BankTrns[1]
+	assert.Equal(t, ofxTrnTypeDebit, refundTrn.TrnType)
+	assert.Equal(t, "tx002", refundTrn.FitID)
+	require.NotNil(t, refundTrn.OrigCurrency, "non-USD transactions must carry an ORIGCURRENCY block with CURRATE")
+	assert.Equal(t, "0.2", refundTrn.OrigCurrency.CurRate)
+	assert.Equal(t, "BRL", refundTrn.OrigCurrency.CurSym)
+}
+
+func TestOFXWriter_Write_MultipleSuppliers(t *testing.T) {
+	writer := NewOFXWriter()
+	validTime := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	settlements := []*domain.SupplierSettlement{
+		domain.NewSupplierSettlement("sup1", "Supplier One"),
+		domain.NewSupplierSettlement("sup2", "Supplier Two"),
+	}
+	settlements[0].AddLine(domain.SettlementLine{
+		Transaction: &domain.Transaction{ID: "tx1", SupplierID: "sup1", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(10), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed},
+		FXRate:      decimal.NewFromFloat(1),
+		USDAmount:   decimal.NewFromFloat(10),
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, writer.Write(&buf, settlements))
+
+	var doc OFXDocument
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Len(t, doc.Stmts, 2)
+}
@@ -0,0 +1,16 @@
+package reporter
+
+import (
+	"io"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+)
+
+// Writer renders calculated settlements to an output format. CSVWriter,
+// JournalWriter, OFXWriter, ISO20022Writer, and LedgerWriter all implement
+// it, so callers (e.g. the CLI's --format flag) can select an output format
+// without depending on the concrete writer type.
+type Writer interface {
+	// Write renders settlements to writer in the implementation's format.
+	Write(writer io.Writer, settlements []*domain.SupplierSettlement) error
+}
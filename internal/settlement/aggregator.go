@@ -1,13 +1,48 @@
 package settlement
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/ignacio/solara-settlement/internal/domain"
 )
 
+// DefaultFlushThreshold is the number of buffered transactions per supplier
+// at which StreamGroupBySupplier spills the batch to its Spiller, used when
+// Aggregator.FlushThreshold isn't set.
+const DefaultFlushThreshold = 1000
+
 // Aggregator groups transactions by supplier and filters them based on settlement rules.
 // Only settleable transactions (completed captures and refunds) are included in the grouping.
 // Pending, failed, and authorization transactions are automatically filtered out.
-type Aggregator struct{}
+type Aggregator struct {
+	// FlushThreshold is the number of transactions buffered in memory for a
+	// single supplier before StreamGroupBySupplier spills them via Spiller.
+	// Zero means DefaultFlushThreshold is used.
+	FlushThreshold int
+
+	// Spiller persists per-supplier batches outside of memory when streaming.
+	// Required for StreamGroupBySupplier; unused by the in-memory grouping methods.
+	Spiller Spiller
+
+	// DuplicateDetector, when set, is fed every transaction ID
+	// StreamGroupBySupplier observes, so duplicate-ID detection works on
+	// streaming inputs too large to dedupe with DetectDuplicateIDs's
+	// in-memory set. Nil skips duplicate detection entirely, matching
+	// StreamGroupBySupplier's pre-existing behavior.
+	DuplicateDetector *DuplicateIDDetector
+
+	// OrphanTracker, when set, is fed every settleable transaction
+	// StreamGroupBySupplier observes, so orphan-refund detection works the
+	// same way on streaming inputs. Nil skips orphan detection entirely.
+	OrphanTracker *OrphanRefundTracker
+
+	// FingerprintDetector, when set, is fed every settleable transaction
+	// StreamGroupBySupplier observes, so fingerprint-based duplicate
+	// detection (DetectDuplicateByFingerprint's streaming counterpart) works
+	// on inputs too large to buffer in memory. Nil skips it entirely.
+	FingerprintDetector *FingerprintDuplicateDetector
+}
 
 // NewAggregator creates a new transaction aggregator.
 func NewAggregator() *Aggregator {
@@ -62,3 +97,120 @@ func (a *Aggregator) GroupAllBySupplier(transactions []*domain.Transaction) map[
 
 	return grouped
 }
+
+// Spiller persists per-supplier transaction batches outside of memory so that
+// a streaming aggregation can bound RAM usage regardless of input size.
+// Implementations may write to disk, a temp database, or (as with
+// MemorySpiller) simply hold batches in memory for testing.
+type Spiller interface {
+	// Spill appends transactions to the supplier's backing store.
+	Spill(supplierID string, transactions []*domain.Transaction) error
+	// Load returns every transaction previously spilled for the supplier.
+	Load(supplierID string) ([]*domain.Transaction, error)
+	// Suppliers returns every supplier ID that has spilled data.
+	Suppliers() []string
+}
+
+// MemorySpiller is a Spiller that keeps spilled batches in memory rather than
+// on disk. It doesn't reduce memory usage itself, but it lets a two-pass
+// streaming aggregation be exercised in tests without touching the filesystem.
+type MemorySpiller struct {
+	mu   sync.Mutex
+	data map[string][]*domain.Transaction
+}
+
+// NewMemorySpiller creates an empty in-memory Spiller.
+func NewMemorySpiller() *MemorySpiller {
+	return &MemorySpiller{data: make(map[string][]*domain.Transaction)}
+}
+
+// Spill appends transactions to the supplier's in-memory batch.
+func (m *MemorySpiller) Spill(supplierID string, transactions []*domain.Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[supplierID] = append(m.data[supplierID], transactions...)
+	return nil
+}
+
+// Load returns every transaction spilled so far for the supplier.
+func (m *MemorySpiller) Load(supplierID string) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[supplierID], nil
+}
+
+// Suppliers returns every supplier ID that has spilled data, in no particular order.
+func (m *MemorySpiller) Suppliers() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.data))
+	for id := range m.data {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// StreamGroupBySupplier consumes transactions from the channel, buffering
+// per-supplier batches in memory until FlushThreshold is reached and then
+// spilling the batch via Spiller. It returns once the channel closes, after
+// spilling any transactions still buffered. Spiller must be set.
+//
+// This is pass one of a two-pass streaming strategy: pass two (driven by
+// Engine.CalculateStream) loads each supplier's spilled batch independently
+// and settles it, so overall memory usage stays bounded by FlushThreshold
+// rather than by the size of the input.
+//
+// If DuplicateDetector, OrphanTracker and/or FingerprintDetector are set,
+// every transaction is also fed through them as it's observed, so those
+// anomaly checks run incrementally during streaming instead of requiring
+// the full transaction set in memory the way
+// DetectDuplicateIDs/DetectOrphanedRefunds/DetectDuplicateByFingerprint do.
+func (a *Aggregator) StreamGroupBySupplier(transactions <-chan *domain.Transaction) error {
+	if a.Spiller == nil {
+		return fmt.Errorf("aggregator: Spiller must be set to use StreamGroupBySupplier")
+	}
+
+	threshold := a.FlushThreshold
+	if threshold <= 0 {
+		threshold = DefaultFlushThreshold
+	}
+
+	buffers := make(map[string][]*domain.Transaction)
+
+	for tx := range transactions {
+		if a.DuplicateDetector != nil {
+			if _, err := a.DuplicateDetector.Observe(tx.ID); err != nil {
+				return fmt.Errorf("failed to check transaction %s for duplicates: %w", tx.ID, err)
+			}
+		}
+		if a.OrphanTracker != nil && tx.IsSettleable() {
+			a.OrphanTracker.Observe(tx)
+		}
+		if a.FingerprintDetector != nil && tx.IsSettleable() {
+			if _, err := a.FingerprintDetector.Observe(tx); err != nil {
+				return fmt.Errorf("failed to check transaction %s for fingerprint duplicates: %w", tx.ID, err)
+			}
+		}
+
+		buffers[tx.SupplierID] = append(buffers[tx.SupplierID], tx)
+
+		if len(buffers[tx.SupplierID]) >= threshold {
+			if err := a.Spiller.Spill(tx.SupplierID, buffers[tx.SupplierID]); err != nil {
+				return fmt.Errorf("failed to spill supplier %s: %w", tx.SupplierID, err)
+			}
+			buffers[tx.SupplierID] = nil
+		}
+	}
+
+	for supplierID, batch := range buffers {
+		if len(batch) == 0 {
+			continue
+		}
+		if err := a.Spiller.Spill(supplierID, batch); err != nil {
+			return fmt.Errorf("failed to spill supplier %s: %w", supplierID, err)
+		}
+	}
+
+	return nil
+}
@@ -1,12 +1,15 @@
 package settlement
 
 import (
+	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewAggregator(t *testing.T) {
@@ -145,3 +148,118 @@ func TestAggregator_GroupBySupplier_MultipleSuppliers(t *testing.T) {
 	assert.Equal(t, 1, len(grouped["sup002"]))
 	assert.Equal(t, 1, len(grouped["sup003"]))
 }
+
+func TestMemorySpiller_SpillAndLoad(t *testing.T) {
+	spiller := NewMemorySpiller()
+	validTime := time.Now().Add(-1 * time.Hour)
+
+	tx1 := &domain.Transaction{ID: "tx001", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+	tx2 := &domain.Transaction{ID: "tx002", SupplierID: "sup123", Type: domain.Refund, OriginalAmount: decimal.NewFromFloat(10), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+
+	require.NoError(t, spiller.Spill("sup123", []*domain.Transaction{tx1}))
+	require.NoError(t, spiller.Spill("sup123", []*domain.Transaction{tx2}))
+
+	batch, err := spiller.Load("sup123")
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Transaction{tx1, tx2}, batch)
+	assert.Equal(t, []string{"sup123"}, spiller.Suppliers())
+}
+
+func TestAggregator_StreamGroupBySupplier_FlushesAtThreshold(t *testing.T) {
+	agg := NewAggregator()
+	spiller := NewMemorySpiller()
+	agg.Spiller = spiller
+	agg.FlushThreshold = 2
+
+	validTime := time.Now().Add(-1 * time.Hour)
+
+	ch := make(chan *domain.Transaction, 3)
+	ch <- &domain.Transaction{ID: "tx001", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+	ch <- &domain.Transaction{ID: "tx002", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(50), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+	ch <- &domain.Transaction{ID: "tx003", SupplierID: "sup123", Type: domain.Refund, OriginalAmount: decimal.NewFromFloat(10), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+	close(ch)
+
+	require.NoError(t, agg.StreamGroupBySupplier(ch))
+
+	batch, err := spiller.Load("sup123")
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(batch), "the threshold-triggered spill and the final flush should both land in the same batch")
+}
+
+func TestAggregator_StreamGroupBySupplier_RequiresSpiller(t *testing.T) {
+	agg := NewAggregator()
+
+	ch := make(chan *domain.Transaction)
+	close(ch)
+
+	err := agg.StreamGroupBySupplier(ch)
+	assert.Error(t, err)
+}
+
+func TestAggregator_StreamGroupBySupplier_FeedsDuplicateDetector(t *testing.T) {
+	agg := NewAggregator()
+	agg.Spiller = NewMemorySpiller()
+
+	detector, err := NewDuplicateIDDetector(10, filepath.Join(t.TempDir(), "duplicates.txt"))
+	require.NoError(t, err)
+	defer detector.Close()
+	agg.DuplicateDetector = detector
+
+	validTime := time.Now().Add(-1 * time.Hour)
+	ch := make(chan *domain.Transaction, 2)
+	ch <- &domain.Transaction{ID: "tx001", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+	ch <- &domain.Transaction{ID: "tx001", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+	close(ch)
+
+	require.NoError(t, agg.StreamGroupBySupplier(ch))
+	assert.Equal(t, []string{"tx001"}, detector.Duplicates())
+}
+
+func TestAggregator_StreamGroupBySupplier_FeedsOrphanTracker(t *testing.T) {
+	agg := NewAggregator()
+	agg.Spiller = NewMemorySpiller()
+	agg.OrphanTracker = NewOrphanRefundTracker()
+
+	validTime := time.Now().Add(-1 * time.Hour)
+	ch := make(chan *domain.Transaction, 1)
+	ch <- &domain.Transaction{ID: "ref1", SupplierID: "sup123", Type: domain.Refund, OriginalAmount: decimal.NewFromFloat(10), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed}
+	close(ch)
+
+	require.NoError(t, agg.StreamGroupBySupplier(ch))
+	assert.Equal(t, []string{"ref1"}, agg.OrphanTracker.Orphans())
+}
+
+// BenchmarkAggregator_StreamGroupBySupplier demonstrates that per-supplier
+// memory stays bounded by FlushThreshold regardless of how many transactions
+// flow through the channel, unlike GroupBySupplier which holds every
+// transaction in memory for the lifetime of the call.
+func BenchmarkAggregator_StreamGroupBySupplier(b *testing.B) {
+	validTime := time.Now().Add(-1 * time.Hour)
+	const txCount = 10000
+
+	for i := 0; i < b.N; i++ {
+		agg := NewAggregator()
+		agg.Spiller = NewMemorySpiller()
+		agg.FlushThreshold = 500
+
+		ch := make(chan *domain.Transaction)
+		go func() {
+			defer close(ch)
+			for j := 0; j < txCount; j++ {
+				ch <- &domain.Transaction{
+					ID:             fmt.Sprintf("tx%d", j),
+					SupplierID:     "sup123",
+					Type:           domain.Capture,
+					OriginalAmount: decimal.NewFromFloat(100),
+					Currency:       domain.USD,
+					Timestamp:      validTime,
+					Status:         domain.Completed,
+				}
+			}
+		}()
+
+		if err := agg.StreamGroupBySupplier(ch); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -7,16 +7,23 @@ import (
 
 // Anomaly type constants
 const (
-	AnomalyHighRefundRate = "HIGH_REFUND_RATE"   // Refund rate > 20% of captures
-	AnomalyVolatility     = "VOLATILITY_WARNING"  // FX rate variance > 5% between auth and capture
-	AnomalyOrphanedRefund = "ORPHANED_REFUND"     // Refund without matching capture
-	AnomalyDuplicateID    = "DUPLICATE_ID"        // Duplicate transaction ID
-	AnomalyNegativeNet    = "NEGATIVE_NET"        // Informational: supplier owes money back
+	AnomalyHighRefundRate       = "HIGH_REFUND_RATE"      // Refund rate > 20% of captures
+	AnomalyVolatility           = "VOLATILITY_WARNING"    // FX rate variance > 5% between auth and capture
+	AnomalyOrphanedRefund       = "ORPHANED_REFUND"       // Refund without matching capture
+	AnomalyDuplicateID          = "DUPLICATE_ID"          // Duplicate transaction ID
+	AnomalyNegativeNet          = "NEGATIVE_NET"          // Informational: supplier owes money back
+	AnomalyFXLotUnderflow       = "FX_LOT_UNDERFLOW"      // Refund exceeded the supplier's open FX lot balance
+	AnomalyFXRateCV             = "FX_RATE_CV"            // Per-currency FX rate coefficient of variation across a settlement window above threshold; see FXRateCVDetector
+	AnomalyHighChargebackRate   = "HIGH_CHARGEBACK_RATE"  // Chargeback rate > 1% of captures
+	AnomalyAmountZScoreOutlier  = "AMOUNT_ZSCORE_OUTLIER" // Capture USD amount is a statistical outlier against the supplier's own captures; see AmountZScoreDetector
+	AnomalyDuplicateFingerprint = "DUPLICATE_FINGERPRINT" // Same economic event (supplier/type/currency/amount) seen again under a different transaction ID within the fingerprint window
 )
 
-// DetectHighRefundRate checks if a supplier's refund rate exceeds 20% of captures
-// Returns true if the refund rate is above the threshold
-func DetectHighRefundRate(settlement *domain.SupplierSettlement) bool {
+// DetectHighRefundRate checks if a supplier's refund rate exceeds policy's
+// refund-rate threshold for that supplier (20% of captures by default). A
+// nil policy uses DefaultAnomalyPolicy. Returns true if the refund rate is
+// above the threshold.
+func DetectHighRefundRate(settlement *domain.SupplierSettlement, policy *AnomalyPolicy) bool {
 	if settlement.TotalCapturesUSD.IsZero() {
 		// If no captures, cannot calculate refund rate
 		return false
@@ -26,11 +33,28 @@ func DetectHighRefundRate(settlement *domain.SupplierSettlement) bool {
 	refundRate := settlement.TotalRefundsUSD.Div(settlement.TotalCapturesUSD).Mul(decimal.NewFromInt(100))
 	settlement.RefundRatePct = refundRate
 
-	// Flag if refund rate exceeds 20%
-	threshold := decimal.NewFromInt(20)
+	threshold := effectivePolicy(policy).RefundRateThreshold(settlement.SupplierID)
 	return refundRate.GreaterThan(threshold)
 }
 
+// DetectHighChargebackRate checks if a supplier's chargeback rate exceeds
+// policy's chargeback-rate threshold for that supplier (1% of captures by
+// default). A nil policy uses DefaultAnomalyPolicy. Returns true if the
+// chargeback rate is above the threshold.
+func DetectHighChargebackRate(settlement *domain.SupplierSettlement, policy *AnomalyPolicy) bool {
+	if settlement.TotalCapturesUSD.IsZero() {
+		// If no captures, cannot calculate chargeback rate
+		return false
+	}
+
+	// Calculate chargeback rate as percentage: (chargebacks / captures) * 100
+	chargebackRate := settlement.TotalChargebacksUSD.Div(settlement.TotalCapturesUSD).Mul(decimal.NewFromInt(100))
+	settlement.ChargebackRate = chargebackRate
+
+	threshold := effectivePolicy(policy).ChargebackRateThreshold(settlement.SupplierID)
+	return chargebackRate.GreaterThan(threshold)
+}
+
 // DetectOrphanedRefunds identifies refunds that don't have a matching capture
 // Returns a list of orphaned transaction IDs
 func DetectOrphanedRefunds(transactions []*domain.Transaction) []string {
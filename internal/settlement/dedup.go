@@ -0,0 +1,243 @@
+package settlement
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+)
+
+// DefaultDuplicateFalsePositiveRate is the BloomFilter false-positive rate
+// used by NewDuplicateIDDetector.
+const DefaultDuplicateFalsePositiveRate = 0.01
+
+// BloomFilter is a fixed-size probabilistic set-membership test: once Add
+// has been called for an item, MightContain always reports true for it (no
+// false negatives), but may also report true for an item never added (a
+// false positive) at a rate bounded by how the filter was sized - unlike a
+// map, its memory footprint is fixed at construction rather than growing
+// with the number of distinct items added. Bits are packed into a []uint64
+// (one bit per slot) rather than one bool per slot, so memory usage matches
+// the sizing formula's bit count rather than costing a byte per bit.
+type BloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint
+}
+
+// NewBloomFilter sizes a filter for expectedItems distinct items at
+// approximately falsePositiveRate, using the standard
+// m = ceil(-n*ln(p)/ln(2)^2) bit-count and k = round((m/n)*ln(2)) hash-count
+// formulas.
+func NewBloomFilter(expectedItems int, falsePositiveRate float64) *BloomFilter {
+	if expectedItems <= 0 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = DefaultDuplicateFalsePositiveRate
+	}
+
+	n := float64(expectedItems)
+	m := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k := uint(math.Round((float64(m) / n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &BloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// Add records item as present in the filter.
+func (f *BloomFilter) Add(item string) {
+	for _, idx := range f.indexes(item) {
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// MightContain reports whether item may have been added; false means it
+// definitely was not.
+func (f *BloomFilter) MightContain(item string) bool {
+	for _, idx := range f.indexes(item) {
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// indexes derives f.k bit positions for item via double hashing (combining
+// two independent hashes), the standard way to get k hash functions'-worth
+// of spread without running k separate hash passes per item.
+func (f *BloomFilter) indexes(item string) []uint64 {
+	h1 := fnv.New64a()
+	h1.Write([]byte(item))
+	sum1 := h1.Sum64()
+
+	h2 := fnv.New32a()
+	h2.Write([]byte(item))
+	sum2 := uint64(h2.Sum32())
+
+	idxs := make([]uint64, f.k)
+	for i := uint(0); i < f.k; i++ {
+		idxs[i] = (sum1 + uint64(i)*sum2) % f.m
+	}
+	return idxs
+}
+
+// DuplicateIDDetector flags duplicate transaction IDs across streaming
+// inputs too large to hold every ID seen in memory (see
+// Engine.CalculateStream). It prefilters with a bounded BloomFilter, whose
+// memory stays fixed regardless of input size, and only consults a
+// spill-to-disk exact set for IDs the filter flags as possible repeats, so
+// every reported duplicate is confirmed rather than probabilistic. Mirrors
+// DetectDuplicateIDs's behavior (a duplicate is reported exactly once, the
+// first time a repeat is confirmed) without that function's requirement of
+// keeping every ID seen so far in memory. Memory is bounded, but the
+// disk-confirmation step (see seenOnDisk) is a linear rescan of the spill
+// file, so per-collision cost still grows with total input volume.
+type DuplicateIDDetector struct {
+	bloom      *BloomFilter
+	spillPath  string
+	spillFile  *os.File
+	duplicates []string
+	reported   map[string]bool
+}
+
+// NewDuplicateIDDetector creates a detector sized for expectedItems distinct
+// IDs, spilling its exact confirmation set to a fresh file at spillPath
+// (truncating any existing file at that path). Callers are responsible for
+// calling Close and removing spillPath once done.
+func NewDuplicateIDDetector(expectedItems int, spillPath string) (*DuplicateIDDetector, error) {
+	file, err := os.Create(spillPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+
+	return &DuplicateIDDetector{
+		bloom:     NewBloomFilter(expectedItems, DefaultDuplicateFalsePositiveRate),
+		spillPath: spillPath,
+		spillFile: file,
+		reported:  make(map[string]bool),
+	}, nil
+}
+
+// Observe records id as seen and returns true if it's a confirmed
+// duplicate, i.e. this isn't the first time id has been observed.
+func (d *DuplicateIDDetector) Observe(id string) (bool, error) {
+	if !d.bloom.MightContain(id) {
+		d.bloom.Add(id)
+		return false, d.spill(id)
+	}
+
+	seen, err := d.seenOnDisk(id)
+	if err != nil {
+		return false, err
+	}
+	if !seen {
+		// A bloom false positive: id is genuinely new, but still needs
+		// spilling so a later real repeat of it is confirmed rather than
+		// mistaken for another false positive.
+		return false, d.spill(id)
+	}
+
+	if !d.reported[id] {
+		d.duplicates = append(d.duplicates, id)
+		d.reported[id] = true
+	}
+	return true, nil
+}
+
+func (d *DuplicateIDDetector) spill(id string) error {
+	_, err := fmt.Fprintln(d.spillFile, id)
+	return err
+}
+
+// seenOnDisk scans the spill file for an exact match of id. This is the
+// "spill-to-disk exact set" check: it only runs for IDs the bloom filter
+// flagged as possible repeats, so it's not on the hot path for most input,
+// but each call still does a full linear scan of everything spilled so far
+// (plus an fsync, so a concurrent spill can't be missed), so its cost grows
+// with total spilled volume, not just with how many collisions occur.
+func (d *DuplicateIDDetector) seenOnDisk(id string) (bool, error) {
+	if err := d.spillFile.Sync(); err != nil {
+		return false, fmt.Errorf("failed to sync spill file: %w", err)
+	}
+
+	read, err := os.Open(d.spillPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	defer read.Close()
+
+	scanner := bufio.NewScanner(read)
+	for scanner.Scan() {
+		if scanner.Text() == id {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// Duplicates returns every transaction ID confirmed as a duplicate so far,
+// each listed once, in the order its repeat was confirmed.
+func (d *DuplicateIDDetector) Duplicates() []string {
+	return d.duplicates
+}
+
+// Close releases the spill file handle. The file at spillPath is left on
+// disk for the caller to remove, matching how Spiller implementations leave
+// their own cleanup to the caller.
+func (d *DuplicateIDDetector) Close() error {
+	return d.spillFile.Close()
+}
+
+// OrphanRefundTracker incrementally flags refunds observed before any
+// capture has been seen for their supplier, mirroring DetectOrphanedRefunds
+// without buffering every transaction: memory is bounded by the number of
+// distinct suppliers plus refunds still pending a same-supplier capture,
+// not by total transaction volume.
+type OrphanRefundTracker struct {
+	suppliersWithCaptures map[string]bool
+	pendingRefunds        map[string][]string
+}
+
+// NewOrphanRefundTracker creates an empty tracker.
+func NewOrphanRefundTracker() *OrphanRefundTracker {
+	return &OrphanRefundTracker{
+		suppliersWithCaptures: make(map[string]bool),
+		pendingRefunds:        make(map[string][]string),
+	}
+}
+
+// Observe records a single settleable transaction. Call it for every
+// completed capture or refund in stream order; a capture resolves every
+// refund pending for its supplier so far, regardless of the order captures
+// and refunds for that supplier arrived in.
+func (t *OrphanRefundTracker) Observe(tx *domain.Transaction) {
+	switch {
+	case tx.Type == domain.Capture && tx.Status == domain.Completed:
+		t.suppliersWithCaptures[tx.SupplierID] = true
+		delete(t.pendingRefunds, tx.SupplierID)
+	case tx.Type == domain.Refund && tx.Status == domain.Completed:
+		if !t.suppliersWithCaptures[tx.SupplierID] {
+			t.pendingRefunds[tx.SupplierID] = append(t.pendingRefunds[tx.SupplierID], tx.ID)
+		}
+	}
+}
+
+// Orphans returns every refund transaction ID whose supplier never had a
+// capture observed for it. Call only once the stream has closed - a refund
+// isn't a confirmed orphan until no more captures can arrive for it.
+func (t *OrphanRefundTracker) Orphans() []string {
+	var orphans []string
+	for _, ids := range t.pendingRefunds {
+		orphans = append(orphans, ids...)
+	}
+	return orphans
+}
@@ -0,0 +1,138 @@
+package settlement
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter_MightContain_NoFalseNegatives(t *testing.T) {
+	filter := NewBloomFilter(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		filter.Add(fmt.Sprintf("tx%d", i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		assert.True(t, filter.MightContain(fmt.Sprintf("tx%d", i)))
+	}
+}
+
+func TestBloomFilter_MightContain_UnaddedItemUsuallyAbsent(t *testing.T) {
+	filter := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		filter.Add(fmt.Sprintf("tx%d", i))
+	}
+
+	falsePositives := 0
+	for i := 1000; i < 2000; i++ {
+		if filter.MightContain(fmt.Sprintf("tx%d", i)) {
+			falsePositives++
+		}
+	}
+
+	// At a configured 1% rate, 1000 lookups should produce nowhere near
+	// every lookup coming back positive.
+	assert.Less(t, falsePositives, 100)
+}
+
+func TestDuplicateIDDetector_ConfirmsDuplicates(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "duplicates.txt")
+	detector, err := NewDuplicateIDDetector(100, spillPath)
+	require.NoError(t, err)
+	defer detector.Close()
+
+	isDup, err := detector.Observe("tx001")
+	require.NoError(t, err)
+	assert.False(t, isDup)
+
+	isDup, err = detector.Observe("tx002")
+	require.NoError(t, err)
+	assert.False(t, isDup)
+
+	isDup, err = detector.Observe("tx001")
+	require.NoError(t, err)
+	assert.True(t, isDup)
+
+	assert.Equal(t, []string{"tx001"}, detector.Duplicates())
+}
+
+func TestDuplicateIDDetector_ReportsEachDuplicateOnlyOnce(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "duplicates.txt")
+	detector, err := NewDuplicateIDDetector(100, spillPath)
+	require.NoError(t, err)
+	defer detector.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := detector.Observe("tx001")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"tx001"}, detector.Duplicates())
+}
+
+func TestDuplicateIDDetector_ManyUniqueIDsNoFalseDuplicates(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "duplicates.txt")
+	detector, err := NewDuplicateIDDetector(500, spillPath)
+	require.NoError(t, err)
+	defer detector.Close()
+
+	for i := 0; i < 500; i++ {
+		isDup, err := detector.Observe(fmt.Sprintf("tx%d", i))
+		require.NoError(t, err)
+		assert.False(t, isDup, "no ID has been observed twice yet")
+	}
+
+	assert.Empty(t, detector.Duplicates())
+}
+
+func makeSettleableTx(id, supplierID string, txType domain.TransactionType) *domain.Transaction {
+	return &domain.Transaction{
+		ID: id, SupplierID: supplierID, Type: txType,
+		OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD,
+		Timestamp: time.Now(), Status: domain.Completed,
+	}
+}
+
+func TestOrphanRefundTracker_FlagsRefundWithNoCapture(t *testing.T) {
+	tracker := NewOrphanRefundTracker()
+
+	tracker.Observe(makeSettleableTx("ref1", "sup123", domain.Refund))
+
+	assert.Equal(t, []string{"ref1"}, tracker.Orphans())
+}
+
+func TestOrphanRefundTracker_CaptureResolvesPendingRefunds(t *testing.T) {
+	tracker := NewOrphanRefundTracker()
+
+	tracker.Observe(makeSettleableTx("ref1", "sup123", domain.Refund))
+	tracker.Observe(makeSettleableTx("cap1", "sup123", domain.Capture))
+
+	assert.Empty(t, tracker.Orphans())
+}
+
+func TestOrphanRefundTracker_CaptureOrderDoesNotMatter(t *testing.T) {
+	tracker := NewOrphanRefundTracker()
+
+	// Capture observed before the refund it would otherwise orphan.
+	tracker.Observe(makeSettleableTx("cap1", "sup123", domain.Capture))
+	tracker.Observe(makeSettleableTx("ref1", "sup123", domain.Refund))
+
+	assert.Empty(t, tracker.Orphans())
+}
+
+func TestOrphanRefundTracker_SeparatesSuppliers(t *testing.T) {
+	tracker := NewOrphanRefundTracker()
+
+	tracker.Observe(makeSettleableTx("cap1", "sup123", domain.Capture))
+	tracker.Observe(makeSettleableTx("ref1", "sup123", domain.Refund))
+	tracker.Observe(makeSettleableTx("ref2", "sup456", domain.Refund))
+
+	assert.Equal(t, []string{"ref2"}, tracker.Orphans())
+}
@@ -6,6 +6,11 @@
 //   - Calculate net settlement amounts (captures minus refunds)
 //   - Generate settlement line items for detailed reporting
 //   - Aggregate totals per supplier
+//   - Stream large transaction volumes through a bounded-memory pipeline
+//     (Engine.CalculateStream) instead of loading everything into a slice
+//   - Settle large transaction volumes concurrently with a worker pool
+//     (Pipeline.Run), producing the same lines and totals as Engine.Calculate
+//     faster, without its anomaly checks
 //
 // The settlement engine processes only "settleable" transactions:
 //   - Completed captures: Add to supplier's total
@@ -1,23 +1,160 @@
 package settlement
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
 	"github.com/ignacio/solara-settlement/internal/fxrate"
+	"github.com/ignacio/solara-settlement/internal/matching"
+	"github.com/ignacio/solara-settlement/internal/money"
+	"github.com/shopspring/decimal"
 )
 
 // Engine orchestrates the settlement calculation process by applying FX rates
 // and aggregating transactions per supplier. It coordinates between the FX rate
 // service and the transaction aggregator to produce comprehensive settlement reports.
 //
-// The engine processes only "settleable" transactions (completed captures and refunds),
-// applies historical FX rates based on transaction dates, and generates detailed
-// settlement breakdowns per supplier.
+// The engine processes only "settleable" transactions (completed captures,
+// refunds, chargebacks, and chargeback reversals), applies historical FX
+// rates based on transaction dates, and generates detailed settlement
+// breakdowns per supplier.
 type Engine struct {
 	fxService  *fxrate.Service
 	aggregator *Aggregator
+
+	// VolatilityDetector, when set, scores each transaction's FX rate
+	// against its currency's trailing rolling window and populates
+	// SettlementLine.FXVolatilityZScore / FXAnomaly. Nil leaves both fields
+	// at their zero values.
+	VolatilityDetector *fxrate.VolatilityDetector
+
+	// LotTracker, when set, assigns FIFO cost-basis to non-USD captures and
+	// refunds and populates SettlementLine.LotConsumption plus the
+	// settlement's RealizedFXGainUSD / UnrealizedFXGainUSD. Nil leaves all
+	// three at their zero values and skips lot tracking entirely.
+	LotTracker *LotTracker
+
+	// PresentationCurrencies, when non-empty, re-expresses every settlement
+	// line's USD amount (and each settlement's net total) in each listed
+	// currency via fxService.Convert, populating
+	// SettlementLine.ConvertedAmounts and SupplierSettlement.PresentationTotals.
+	// The engine still pivots internally through USD; this only adds
+	// additional presentation views on top. Nil/empty skips the conversion
+	// entirely, leaving both fields unset.
+	PresentationCurrencies []domain.Currency
+
+	// Policy configures the thresholds detectAnomalies checks high refund
+	// rates and FX volatility against. Nil uses DefaultAnomalyPolicy (20%
+	// refund rate, 5% FX variance, matching the engine's behavior before
+	// AnomalyPolicy existed). Set this directly for a static policy; for a
+	// policy that can change while the engine is in use, call WatchPolicy
+	// instead, which takes over from this field once it's running.
+	Policy *AnomalyPolicy
+
+	// RefundMatcher, when set, links each refund to the FIFO capture lot(s)
+	// that fund it (see internal/matching) and populates the
+	// AnomalyOrphanedRefund warning for any refund whose lots can't cover it,
+	// in place of DetectOrphanedRefunds's coarser "does this supplier have
+	// any capture at all" check. Nil skips matching entirely; the settlement
+	// still runs DetectOrphanedRefunds's own logging warning regardless,
+	// since the two aren't mutually exclusive. Retrieve a supplier's match
+	// results with RefundMatches.
+	RefundMatcher *matching.Matcher
+
+	// AnomalySink, when set, receives a structured AnomalyEvent the first
+	// time detectAnomalies appends a given rule's warning for a settlement
+	// (every rule it checks except AnomalyNegativeNet, plus each rule
+	// Detectors reports), in addition to the warning code it appends
+	// to SupplierSettlement.Warnings. Unlike Warnings (rebuilt from scratch
+	// on every detectAnomalies call), a rule already warned about on a prior
+	// call doesn't re-emit, so CalculateIncremental/CalculateStream
+	// recomputing the same settlement across rounds fires each event once
+	// rather than once per round. Nil skips event emission entirely.
+	AnomalySink AnomalySink
+
+	// FingerprintWindow bounds how far apart in time Calculate's
+	// DetectDuplicateByFingerprint check considers two transactions the same
+	// retried economic event. Zero means DefaultFingerprintWindow.
+	FingerprintWindow time.Duration
+
+	// Detectors, when set, runs after the checks above on every
+	// detectAnomalies call, letting an operator layer additional anomaly
+	// rules onto a settlement without an Engine code change - typically
+	// DefaultRegistry() for FXRateCVDetector/AmountZScoreDetector, plus any
+	// custom AnomalyDetector. Don't register this package's adapters for the
+	// checks above (HighRefundRateDetector and so on) here: those already run
+	// unconditionally, and doing so would report the same rule twice. Each
+	// detector's Anomaly.Rule is tracked and deduped the same way the checks
+	// above are: a rule's warning is stripped and recomputed every call, but
+	// AnomalySink only sees an event the round a rule first fires. Nil runs
+	// no additional checks, leaving Engine's behavior exactly as it was
+	// before Detectors existed.
+	Detectors *Registry
+
+	// policyMu guards hotPolicy against concurrent access: WatchPolicy's
+	// reload goroutine writes it while Calculate/CalculateIncremental/
+	// CalculateStream may be reading it from another goroutine. Policy itself
+	// needs no such guard since it's set once before use like the engine's
+	// other optional extension points (VolatilityDetector, LotTracker, ...).
+	policyMu sync.RWMutex
+	// hotPolicy is the policy most recently loaded by WatchPolicy, taking
+	// precedence over Policy once WatchPolicy has run at least once. Nil
+	// until then.
+	hotPolicy *AnomalyPolicy
+
+	// current holds the settlement set built by the most recent Calculate
+	// call, keyed by supplier ID, so CalculateIncremental can apply new
+	// transactions on top of it without recomputing from scratch, and
+	// Snapshot/Revert can capture and restore points in that history. Nil
+	// until Calculate has run once.
+	current map[string]*domain.SupplierSettlement
+
+	// UseMoneyArithmetic, when true, recomputes each transaction's USD amount
+	// with the internal/money package's uint256-backed fixed-point Amount/
+	// Rate instead of trusting fxService's decimal.Decimal result directly -
+	// see recomputeWithMoney. Set by NewEngineWithMoney; false leaves
+	// Calculate/CalculateIncremental's existing decimal-only behavior
+	// unchanged.
+	UseMoneyArithmetic bool
+
+	// lastFXRate tracks, per supplier, the most recent FX rate observed for
+	// each non-USD currency with capture/refund activity, persisted across
+	// CalculateIncremental calls so a later incremental round can still mark
+	// an earlier round's still-open lot to market even when its own batch
+	// doesn't mention that currency. Only populated when LotTracker is
+	// configured; nil otherwise. A full Calculate call resets it (alongside
+	// rebuilding every settlement from scratch), since Calculate has no
+	// "earlier round" of its own to remember rates from; CalculateIncremental
+	// relies on it surviving exactly because it builds on a prior Calculate's
+	// results instead of starting over.
+	lastFXRate map[string]map[domain.Currency]decimal.Decimal
+
+	// refundMatches holds the most recent RefundMatcher.Match results per
+	// supplier, keyed by supplier ID, exposed read-only via RefundMatches.
+	// Only populated when RefundMatcher is configured.
+	refundMatches map[string][]matching.MatchResult
+
+	// fingerprintDuplicates holds, per supplier, the DuplicateIDs() from the
+	// most recent Calculate/CalculateIncremental's DetectDuplicateByFingerprint
+	// pass - every transaction ID in a fingerprint group after its
+	// first-seen one - consumed by detectAnomalies to mark
+	// AnomalyDuplicateFingerprint.
+	fingerprintDuplicates map[string][]string
+}
+
+// RefundMatches returns the FIFO capture-lot linkage RefundMatcher computed
+// for supplierID's refunds during the most recent Calculate/
+// CalculateIncremental call, one matching.MatchResult per refund, oldest
+// first. Nil if RefundMatcher is not configured or the supplier has no
+// refunds.
+func (e *Engine) RefundMatches(supplierID string) []matching.MatchResult {
+	return e.refundMatches[supplierID]
 }
 
 // NewEngine creates a new settlement calculation engine with the provided FX rate service.
@@ -29,9 +166,28 @@ func NewEngine(fxService *fxrate.Service) *Engine {
 	}
 }
 
+// NewEngineWithMoney creates an Engine identical to NewEngine except that
+// every transaction's USD amount is recomputed through internal/money's
+// uint256-backed fixed-point Amount/Rate (see recomputeWithMoney) instead of
+// trusting fxService's decimal.Decimal conversion directly. This is the
+// highest-value place to exercise the fixed-point path: it's the one
+// multiply every settled transaction goes through, and it's where
+// decimal.Decimal's unbounded coefficient growth and the rounding drift a
+// long-running deployment accumulates both show up first. Aggregation,
+// anomaly detection, and the rest of the pipeline downstream of
+// SettlementLine.USDAmount are unchanged and still operate on
+// decimal.Decimal - rewriting every domain/settlement field to be generic
+// over two numeric representations isn't worth the tree churn for what
+// money buys here.
+func NewEngineWithMoney(fxService *fxrate.Service) *Engine {
+	e := NewEngine(fxService)
+	e.UseMoneyArithmetic = true
+	return e
+}
+
 // Calculate processes a list of transactions and generates settlement reports per supplier.
 // The method:
-//  1. Detects data anomalies (duplicate IDs, orphaned refunds)
+//  1. Detects data anomalies (duplicate IDs, fingerprint duplicates, orphaned refunds)
 //  2. Groups transactions by supplier ID
 //  3. Filters only settleable transactions (completed captures/refunds)
 //  4. Applies historical FX rates to convert amounts to USD
@@ -47,6 +203,20 @@ func (e *Engine) Calculate(transactions []*domain.Transaction) ([]*domain.Suppli
 		log.Printf("WARNING: Duplicate transaction IDs detected: %v", duplicates)
 	}
 
+	// STEP 1b: Detect the same economic event re-sent under a fresh
+	// transaction ID, which DetectDuplicateIDs' exact-ID check can't catch.
+	// Unlike that log-only check, this one also marks every affected
+	// supplier's settlement with AnomalyDuplicateFingerprint below, so
+	// downstream reconciliation has something to act on beyond the log line.
+	// Scoped to settleable transactions, matching CalculateIncremental and
+	// the streaming FingerprintDetector, so the same input reports the same
+	// duplicates regardless of which entry point processed it.
+	fingerprintGroups := DetectDuplicateByFingerprint(settleableTransactions(transactions), e.FingerprintWindow)
+	if len(fingerprintGroups) > 0 {
+		log.Printf("WARNING: Duplicate transactions detected by fingerprint: %v", fingerprintGroups)
+	}
+	e.fingerprintDuplicates = duplicateIDsBySupplier(fingerprintGroups)
+
 	// STEP 2: Detect orphaned refunds
 	orphans := DetectOrphanedRefunds(transactions)
 	if len(orphans) > 0 {
@@ -56,8 +226,19 @@ func (e *Engine) Calculate(transactions []*domain.Transaction) ([]*domain.Suppli
 	// STEP 3: Group transactions by supplier (including authorizations for volatility detection)
 	grouped := e.aggregator.GroupAllBySupplier(transactions)
 
+	// Calculate rebuilds every settlement from scratch, so any FX rates
+	// remembered from a prior Calculate/CalculateIncremental run must be
+	// dropped too - otherwise a currency this batch doesn't touch for a
+	// supplier would mark-to-market against a stale rate left over from an
+	// earlier run instead of being skipped.
+	e.lastFXRate = nil
+	e.refundMatches = nil
+	if e.RefundMatcher != nil {
+		e.RefundMatcher.Reset()
+	}
+
 	// STEP 4: Calculate settlements for each supplier
-	var settlements []*domain.SupplierSettlement
+	current := make(map[string]*domain.SupplierSettlement, len(grouped))
 
 	for supplierID, group := range grouped {
 		settlement, err := e.calculateSupplierSettlement(supplierID, group.Settleable, group.Authorizations)
@@ -68,10 +249,298 @@ func (e *Engine) Calculate(transactions []*domain.Transaction) ([]*domain.Suppli
 		// STEP 5: Run anomaly detection on this settlement
 		e.detectAnomalies(settlement)
 
+		current[supplierID] = settlement
+	}
+
+	e.current = current
+
+	return e.settlementSlice(), nil
+}
+
+// CalculateIncremental applies newTransactions on top of the engine's
+// existing settlement set, built by a prior Calculate call, converting and
+// appending each one exactly like Calculate would without reprocessing any
+// previously-settled transaction. Combined with Snapshot/Revert, this
+// supports speculative reprocessing: apply a batch of late-arriving
+// transactions, validate the result, then either keep it or roll it back -
+// without recomputing from scratch on a million-transaction ledger.
+//
+// Unlike Calculate, which only publishes to e.current after every supplier
+// in the batch succeeds, CalculateIncremental mutates e.current's
+// settlements supplier-by-supplier as it goes: if a later supplier in the
+// same batch fails, any supplier already applied stays applied. Callers
+// that need to undo a failed call, not just a rejected-but-successful one,
+// should Snapshot before calling it.
+func (e *Engine) CalculateIncremental(newTransactions []*domain.Transaction) ([]*domain.SupplierSettlement, error) {
+	if e.current == nil {
+		return nil, fmt.Errorf("settlement: CalculateIncremental requires a prior call to Calculate")
+	}
+
+	grouped := e.aggregator.GroupAllBySupplier(newTransactions)
+
+	for supplierID, group := range grouped {
+		settlement, ok := e.current[supplierID]
+		if !ok {
+			settlement = domain.NewSupplierSettlement(supplierID, fmt.Sprintf("Supplier %s", supplierID))
+			e.current[supplierID] = settlement
+		}
+		settlement.AuthTransactions = append(settlement.AuthTransactions, group.Authorizations...)
+
+		if err := e.appendTransactions(settlement, supplierID, group.Settleable); err != nil {
+			return nil, fmt.Errorf("failed to apply incremental transactions for supplier %s: %w", supplierID, err)
+		}
+
+		// Recompute fingerprint duplicates across this supplier's full
+		// settled history (not just this round's batch), the same way
+		// DetectHighRefundRate re-derives the refund rate from the
+		// settlement's running totals rather than just the new transactions.
+		if e.fingerprintDuplicates == nil {
+			e.fingerprintDuplicates = make(map[string][]string)
+		}
+		e.fingerprintDuplicates[supplierID] = flattenDuplicateIDs(DetectDuplicateByFingerprint(lineTransactions(settlement), e.FingerprintWindow))
+
+		e.detectAnomalies(settlement)
+	}
+
+	return e.settlementSlice(), nil
+}
+
+// lineTransactions returns the underlying *domain.Transaction behind each of
+// settlement's Lines, in line order.
+func lineTransactions(settlement *domain.SupplierSettlement) []*domain.Transaction {
+	transactions := make([]*domain.Transaction, len(settlement.Lines))
+	for i, line := range settlement.Lines {
+		transactions[i] = line.Transaction
+	}
+	return transactions
+}
+
+// settleableTransactions returns the subset of transactions IsSettleable
+// reports true for, in their original order.
+func settleableTransactions(transactions []*domain.Transaction) []*domain.Transaction {
+	settleable := make([]*domain.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		if tx.IsSettleable() {
+			settleable = append(settleable, tx)
+		}
+	}
+	return settleable
+}
+
+// settlementSlice flattens e.current into a slice, the same shape Calculate
+// and CalculateIncremental return.
+func (e *Engine) settlementSlice() []*domain.SupplierSettlement {
+	settlements := make([]*domain.SupplierSettlement, 0, len(e.current))
+	for _, settlement := range e.current {
 		settlements = append(settlements, settlement)
 	}
+	return settlements
+}
+
+// supplierSnapshot captures a supplier settlement's shape immediately before
+// a round of incremental transactions, just enough for Revert to detect
+// whether it was touched and to restore it. warnings is copied in full,
+// rather than just its length, because detectAnomalies strips and
+// re-appends the codes it owns on every call - a raw index truncation would
+// drop or keep the wrong entries once that reordering happens.
+type supplierSnapshot struct {
+	lineCount      int
+	warnings       []string
+	authCount      int
+	volatilityFlag bool
+}
 
-	return settlements, nil
+// SnapshotHandle is an opaque handle returned by Engine.Snapshot and
+// consumed by Engine.Revert to restore the engine's settlement state to the
+// point the snapshot was taken.
+type SnapshotHandle struct {
+	suppliers map[string]supplierSnapshot
+}
+
+// Snapshot captures the shape of every supplier settlement in the engine's
+// current state, so a following CalculateIncremental call can be applied
+// speculatively and rolled back via Revert if the new transactions turn out
+// to be invalid, without recomputing from scratch. Calling Snapshot before
+// any Calculate has run captures an empty state, so Revert will simply drop
+// everything added since.
+func (e *Engine) Snapshot() *SnapshotHandle {
+	suppliers := make(map[string]supplierSnapshot, len(e.current))
+	for supplierID, settlement := range e.current {
+		suppliers[supplierID] = supplierSnapshot{
+			lineCount:      len(settlement.Lines),
+			warnings:       append([]string{}, settlement.Warnings...),
+			authCount:      len(settlement.AuthTransactions),
+			volatilityFlag: settlement.VolatilityFlag,
+		}
+	}
+	return &SnapshotHandle{suppliers: suppliers}
+}
+
+// Revert restores the engine's settlement state to the point handle was
+// captured at, undoing any CalculateIncremental calls made since. It scans
+// every supplier in e.current, but only mutates the ones whose Lines or
+// AuthTransactions actually grew since the snapshot; for those it truncates
+// Lines and AuthTransactions back to their previous length, restores
+// Warnings from the snapshotted copy, and recalculates
+// totals/RefundRatePct/ChargebackRate/AuthCaptureMatches/MaxVariance/
+// MeanVariance from the retained lines rather than replaying from scratch.
+// Suppliers created
+// entirely after the snapshot are dropped.
+//
+// Revert does not undo LotTracker state: OpenLot/ConsumeRefund calls made by
+// the reverted transactions stay in effect, so UnrealizedFXGainUSD may not
+// match the restored Lines when a LotTracker is configured. Reverting the
+// tracker itself would need its own snapshot mechanism.
+func (e *Engine) Revert(handle *SnapshotHandle) {
+	for supplierID, settlement := range e.current {
+		snap, existed := handle.suppliers[supplierID]
+		if !existed {
+			delete(e.current, supplierID)
+			continue
+		}
+		if len(settlement.Lines) == snap.lineCount && len(settlement.AuthTransactions) == snap.authCount {
+			continue
+		}
+
+		settlement.Lines = settlement.Lines[:snap.lineCount]
+		settlement.AuthTransactions = settlement.AuthTransactions[:snap.authCount]
+		settlement.Warnings = append([]string{}, snap.warnings...)
+		settlement.RecalculateTotals()
+		policy := e.activePolicy()
+		DetectHighRefundRate(settlement, policy)
+		DetectHighChargebackRate(settlement, policy)
+
+		DetectVolatilityForSettlement(settlement, e.fxService, policy)
+		settlement.VolatilityFlag = snap.volatilityFlag
+	}
+}
+
+// ConfigureStreaming sets the Spiller and per-supplier flush threshold used
+// by CalculateStream. It must be called before CalculateStream; flushThreshold
+// <= 0 falls back to DefaultFlushThreshold.
+func (e *Engine) ConfigureStreaming(spiller Spiller, flushThreshold int) {
+	e.aggregator.Spiller = spiller
+	e.aggregator.FlushThreshold = flushThreshold
+}
+
+// ConfigureStreamingAnomalyDetection sets the duplicate-ID, orphan-refund
+// and fingerprint-duplicate detectors CalculateStream feeds transactions
+// through as they arrive, so that detection work DetectDuplicateIDs/
+// DetectOrphanedRefunds/DetectDuplicateByFingerprint otherwise do by
+// buffering the whole input in memory also runs on streaming inputs,
+// without that buffering. Any argument may be nil to skip that check; none
+// is required for CalculateStream to work.
+func (e *Engine) ConfigureStreamingAnomalyDetection(duplicates *DuplicateIDDetector, orphans *OrphanRefundTracker, fingerprints *FingerprintDuplicateDetector) {
+	e.aggregator.DuplicateDetector = duplicates
+	e.aggregator.OrphanTracker = orphans
+	e.aggregator.FingerprintDetector = fingerprints
+}
+
+// CalculateStream processes transactions from a channel instead of a slice,
+// so that memory usage stays bounded regardless of input size. It uses a
+// two-pass strategy: pass one (via Aggregator.StreamGroupBySupplier) drains
+// the channel, buffering each supplier's transactions in memory only up to
+// FlushThreshold before spilling them through the configured Spiller; pass
+// two loads each supplier's full spilled batch independently and settles it,
+// emitting settlements on the returned channel as soon as they're ready.
+//
+// ConfigureStreaming must be called first to set a Spiller. The returned
+// channel is closed once every supplier has been settled (or ctx is
+// cancelled); errors settling an individual supplier are logged and that
+// supplier is skipped rather than aborting the whole stream, so one bad
+// batch doesn't discard settlements already computed for others.
+func (e *Engine) CalculateStream(ctx context.Context, transactions <-chan *domain.Transaction) (<-chan *domain.SupplierSettlement, error) {
+	if e.aggregator.Spiller == nil {
+		return nil, fmt.Errorf("settlement: ConfigureStreaming must be called before CalculateStream")
+	}
+
+	if err := e.aggregator.StreamGroupBySupplier(transactions); err != nil {
+		return nil, fmt.Errorf("failed to spill transactions: %w", err)
+	}
+
+	if e.aggregator.DuplicateDetector != nil {
+		if duplicates := e.aggregator.DuplicateDetector.Duplicates(); len(duplicates) > 0 {
+			log.Printf("WARNING: Duplicate transaction IDs detected: %v", duplicates)
+		}
+	}
+	if e.aggregator.OrphanTracker != nil {
+		if orphans := e.aggregator.OrphanTracker.Orphans(); len(orphans) > 0 {
+			log.Printf("WARNING: Orphaned refunds detected (refunds without matching captures): %v", orphans)
+		}
+	}
+	if e.aggregator.FingerprintDetector != nil {
+		if duplicates := e.aggregator.FingerprintDetector.Duplicates(); len(duplicates) > 0 {
+			log.Printf("WARNING: Duplicate transactions detected by fingerprint: %v", duplicates)
+		}
+	}
+
+	out := make(chan *domain.SupplierSettlement)
+
+	go func() {
+		defer close(out)
+
+		for _, supplierID := range e.aggregator.Spiller.Suppliers() {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			settlement, err := e.calculateSpilledSupplier(supplierID)
+			if err != nil {
+				log.Printf("WARNING: failed to calculate settlement for supplier %s: %v", supplierID, err)
+				continue
+			}
+
+			select {
+			case out <- settlement:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// calculateSpilledSupplier loads a supplier's full spilled batch, splits it
+// into settleable transactions and authorizations, and settles it exactly
+// like the in-memory Calculate path would.
+func (e *Engine) calculateSpilledSupplier(supplierID string) (*domain.SupplierSettlement, error) {
+	batch, err := e.aggregator.Spiller.Load(supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spilled transactions: %w", err)
+	}
+
+	settleable := make([]*domain.Transaction, 0, len(batch))
+	var authorizations []*domain.Transaction
+
+	for _, tx := range batch {
+		switch {
+		case tx.IsSettleable():
+			settleable = append(settleable, tx)
+		case tx.Type == domain.Authorization && tx.Status == domain.Completed:
+			authorizations = append(authorizations, tx)
+		}
+	}
+
+	settlement, err := e.calculateSupplierSettlement(supplierID, settleable, authorizations)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recompute this supplier's fingerprint duplicates from its own spilled
+	// batch, the same way CalculateIncremental recomputes them from a
+	// supplier's accumulated Lines - CalculateStream's FingerprintDetector
+	// only logs, it doesn't know which supplier each duplicate belongs to.
+	if e.fingerprintDuplicates == nil {
+		e.fingerprintDuplicates = make(map[string][]string)
+	}
+	e.fingerprintDuplicates[supplierID] = flattenDuplicateIDs(DetectDuplicateByFingerprint(settleable, e.FingerprintWindow))
+
+	e.detectAnomalies(settlement)
+
+	return settlement, nil
 }
 
 // calculateSupplierSettlement calculates settlement for a single supplier
@@ -87,43 +556,493 @@ func (e *Engine) calculateSupplierSettlement(
 	// Store authorization transactions for volatility detection
 	settlement.AuthTransactions = authorizations
 
+	if err := e.appendTransactions(settlement, supplierID, transactions); err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// appendTransactions converts each transaction to USD and appends the
+// resulting SettlementLine to settlement, updating lot-tracking state along
+// the way. Shared by calculateSupplierSettlement (building a fresh
+// settlement) and CalculateIncremental (appending onto an existing one).
+func (e *Engine) appendTransactions(settlement *domain.SupplierSettlement, supplierID string, transactions []*domain.Transaction) error {
+	// lastRateByCurrency tracks the most recently observed FX rate for each
+	// non-USD currency, so any lots still open once the batch is done can be
+	// marked to market without a separate "current rate" fetch. It's backed
+	// by e.lastFXRate, which persists across CalculateIncremental rounds, so
+	// a lot opened in an earlier round is still priced correctly even if
+	// this round's batch never touches its currency.
+	var lastRateByCurrency map[domain.Currency]decimal.Decimal
+	if e.LotTracker != nil {
+		if e.lastFXRate == nil {
+			e.lastFXRate = make(map[string]map[domain.Currency]decimal.Decimal)
+		}
+		if e.lastFXRate[supplierID] == nil {
+			e.lastFXRate[supplierID] = make(map[domain.Currency]decimal.Decimal)
+		}
+		lastRateByCurrency = e.lastFXRate[supplierID]
+	}
+	lotUnderflow := false
+
+	var matchEntries []matching.Entry
+
+	if e.LotTracker != nil {
+		// FIFO cost-basis requires captures to be opened before the refunds
+		// that draw them down, so process chronologically rather than in
+		// whatever order the input happened to arrive in.
+		transactions = sortedByTimestamp(transactions)
+	}
+
 	// Process each transaction
 	for _, tx := range transactions {
-		// Convert to USD
-		usdAmount, fxRate, err := e.fxService.ConvertToUSD(tx)
+		// Convert to USD, following a multi-hop path if no direct rate exists
+		conversion, err := e.fxService.ConvertToUSDWithPath(tx)
+		if err != nil {
+			return fmt.Errorf("failed to convert transaction %s: %w", tx.ID, err)
+		}
+
+		if e.UseMoneyArithmetic {
+			usdAmount, err := recomputeWithMoney(tx.OriginalAmount, conversion.Rate)
+			if err != nil {
+				return fmt.Errorf("failed to convert transaction %s with money arithmetic: %w", tx.ID, err)
+			}
+			conversion.USDAmount = usdAmount
+		}
+
+		usdMoney, err := domain.NewMoney(conversion.USDAmount, domain.USD)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert transaction %s: %w", tx.ID, err)
+			return fmt.Errorf("failed to build settlement line for transaction %s: %w", tx.ID, err)
 		}
 
 		// Create settlement line
 		line := domain.SettlementLine{
-			Transaction: tx,
-			FXRate:      fxRate,
-			USDAmount:   usdAmount,
+			Transaction:    tx,
+			FXRate:         conversion.Rate,
+			USDAmount:      conversion.USDAmount,
+			USDMoney:       usdMoney,
+			ConversionPath: conversion.Path,
+			RateSource:     conversion.Source,
+		}
+
+		if e.VolatilityDetector != nil {
+			score, err := e.VolatilityDetector.Score(tx.Currency, tx.Timestamp)
+			if err != nil {
+				return fmt.Errorf("failed to score FX volatility for transaction %s: %w", tx.ID, err)
+			}
+			line.FXVolatilityZScore = score.ZScore
+			line.FXAnomaly = score.IsAnomalous
+		}
+
+		if e.LotTracker != nil && tx.Currency != domain.USD {
+			lastRateByCurrency[tx.Currency] = conversion.Rate
+
+			switch tx.Type {
+			case domain.Capture, domain.ChargebackReversal:
+				e.LotTracker.OpenLot(supplierID, tx.Currency, tx.ID, tx.OriginalAmount, conversion.Rate)
+			case domain.Refund, domain.Chargeback:
+				slices, underflow := e.LotTracker.ConsumeRefund(supplierID, tx.Currency, tx.OriginalAmount, conversion.Rate)
+				line.LotConsumption = slices
+				if underflow {
+					lotUnderflow = true
+				}
+			}
+		}
+
+		if len(e.PresentationCurrencies) > 0 {
+			converted, err := convertToPresentationCurrencies(e.fxService, e.PresentationCurrencies, conversion.USDAmount, tx.Timestamp)
+			if err != nil {
+				return fmt.Errorf("failed to convert transaction %s to presentation currencies: %w", tx.ID, err)
+			}
+			line.ConvertedAmounts = converted
+		}
+
+		if e.RefundMatcher != nil {
+			matchEntries = append(matchEntries, matching.Entry{Transaction: tx, USDAmount: conversion.USDAmount})
 		}
 
 		// Add to settlement
 		settlement.AddLine(line)
 	}
 
-	return settlement, nil
+	if e.RefundMatcher != nil {
+		results := e.RefundMatcher.Match(matchEntries)
+		if e.refundMatches == nil {
+			e.refundMatches = make(map[string][]matching.MatchResult)
+		}
+		e.refundMatches[supplierID] = append(e.refundMatches[supplierID], results...)
+	}
+
+	if e.LotTracker != nil {
+		// Guarded by containsWarning, not just lotUnderflow, since a later
+		// CalculateIncremental round can re-enter appendTransactions for a
+		// supplier that already underflowed in an earlier round; the code
+		// should be recorded once per settlement, not once per round.
+		if lotUnderflow && !containsWarning(settlement.Warnings, AnomalyFXLotUnderflow) {
+			settlement.Warnings = append(settlement.Warnings, AnomalyFXLotUnderflow)
+		}
+
+		// Recomputed from scratch (not accumulated) every call, since
+		// OpenCurrencies/UnrealizedGainUSD already reflect the tracker's full
+		// current state rather than just this batch - accumulating on top of
+		// the previous value would double-count on every incremental round.
+		settlement.UnrealizedFXGainUSD = decimal.Zero
+		for _, currency := range e.LotTracker.OpenCurrencies(supplierID) {
+			rate, ok := lastRateByCurrency[currency]
+			if !ok {
+				continue
+			}
+			settlement.UnrealizedFXGainUSD = settlement.UnrealizedFXGainUSD.Add(e.LotTracker.UnrealizedGainUSD(supplierID, currency, rate))
+		}
+	}
+
+	return nil
+}
+
+// recomputeWithMoney converts originalAmount to USD via internal/money's
+// fixed-point Amount.Mul instead of decimal.Decimal, so a settlement run
+// with UseMoneyArithmetic enabled gets the uint256-backed, overflow-checked
+// arithmetic that package guarantees rather than decimal.Decimal's
+// unbounded-coefficient multiply. rate is the same quote fxService already
+// resolved (including any multi-hop triangulation); only the multiply
+// itself moves to fixed-point. The result is rounded to USD's minor-unit
+// precision with banker's rounding, matching
+// fxService.ConvertToUSDWithPath's own RoundBank(domain.USD.Exponent()) -
+// otherwise Amount's wider 10^4 scale would leave sub-cent residue that the
+// decimal-only path never produces for the same input.
+func recomputeWithMoney(originalAmount, rate decimal.Decimal) (decimal.Decimal, error) {
+	amount, err := money.NewAmountFromDecimal(originalAmount)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	moneyRate, err := money.NewRateFromDecimal(rate)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	converted, err := amount.Mul(moneyRate)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return converted.Decimal().RoundBank(domain.USD.Exponent()), nil
 }
 
-// detectAnomalies runs all anomaly detection checks on a settlement
+// convertToPresentationCurrencies re-expresses a USD amount in each of the
+// given presentation currencies, triangulating through USD via
+// fxService.Convert when no direct rate is available. Shared by Engine and
+// Pipeline, both of which expose a PresentationCurrencies field.
+func convertToPresentationCurrencies(fxService *fxrate.Service, currencies []domain.Currency, usdAmount decimal.Decimal, date time.Time) (map[domain.Currency]decimal.Decimal, error) {
+	converted := make(map[domain.Currency]decimal.Decimal, len(currencies))
+
+	for _, currency := range currencies {
+		if currency == domain.USD {
+			continue
+		}
+
+		quote, err := fxService.Convert(domain.USD, currency, usdAmount, date)
+		if err != nil {
+			return nil, err
+		}
+		converted[currency] = quote.Amount
+	}
+
+	return converted, nil
+}
+
+// sortedByTimestamp returns a chronologically-sorted copy of transactions,
+// leaving the input slice untouched.
+func sortedByTimestamp(transactions []*domain.Transaction) []*domain.Transaction {
+	sorted := make([]*domain.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	return sorted
+}
+
+// detectAnomalies runs all anomaly detection checks on a settlement. It's
+// idempotent: CalculateIncremental may call it again on a supplier it has
+// already run checks on, so any codes it owns are stripped before
+// re-running the checks rather than appended on top of a prior run's.
 func (e *Engine) detectAnomalies(settlement *domain.SupplierSettlement) {
-	// Check for high refund rate (>20%)
-	if DetectHighRefundRate(settlement) {
+	// Captured before filterWarnings strips them, so AnomalySink only sees a
+	// rule fire once per supplier rather than re-emitting an event every
+	// round CalculateIncremental/CalculateStream happens to re-run this
+	// method against a settlement that was already warned about the same
+	// condition.
+	hadHighRefundRate := containsWarning(settlement.Warnings, AnomalyHighRefundRate)
+	hadVolatility := containsWarning(settlement.Warnings, AnomalyVolatility)
+	hadHighChargebackRate := containsWarning(settlement.Warnings, AnomalyHighChargebackRate)
+	hadOrphanedRefund := containsWarning(settlement.Warnings, AnomalyOrphanedRefund)
+	hadDuplicateFingerprint := containsWarning(settlement.Warnings, AnomalyDuplicateFingerprint)
+
+	settlement.Warnings = filterWarnings(settlement.Warnings, AnomalyHighRefundRate, AnomalyVolatility, AnomalyNegativeNet, AnomalyHighChargebackRate, AnomalyOrphanedRefund, AnomalyDuplicateFingerprint)
+	settlement.VolatilityFlag = false
+
+	policy := e.activePolicy()
+
+	// Check for high refund rate
+	if DetectHighRefundRate(settlement, policy) {
 		settlement.Warnings = append(settlement.Warnings, AnomalyHighRefundRate)
+		if !hadHighRefundRate {
+			e.emitAnomalyEvent(AnomalyHighRefundRate, settlement, settlement.RefundRatePct,
+				policy.RefundRateThreshold(settlement.SupplierID), refundLineIDs(settlement))
+		}
 	}
 
-	// Check for FX volatility (>5% variance between auth and capture)
-	if DetectVolatilityForSettlement(settlement, e.fxService) {
+	// Check for FX volatility
+	if DetectVolatilityForSettlement(settlement, e.fxService, policy) {
 		settlement.VolatilityFlag = true
 		settlement.Warnings = append(settlement.Warnings, AnomalyVolatility)
+		if !hadVolatility {
+			if observed, threshold, ids, ok := e.triggeringVolatility(settlement, policy); ok {
+				e.emitAnomalyEvent(AnomalyVolatility, settlement, observed, threshold, ids)
+			}
+		}
+	}
+
+	// Check for high chargeback rate
+	if DetectHighChargebackRate(settlement, policy) {
+		settlement.Warnings = append(settlement.Warnings, AnomalyHighChargebackRate)
+		if !hadHighChargebackRate {
+			e.emitAnomalyEvent(AnomalyHighChargebackRate, settlement, settlement.ChargebackRate,
+				policy.ChargebackRateThreshold(settlement.SupplierID), chargebackLineIDs(settlement))
+		}
 	}
 
 	// Check for negative net (informational warning)
 	if DetectNegativeNet(settlement) {
 		settlement.Warnings = append(settlement.Warnings, AnomalyNegativeNet)
 	}
+
+	// Check for refunds RefundMatcher couldn't fully fund from any open
+	// capture lot - a finer-grained replacement for the supplier-wide
+	// DetectOrphanedRefunds heuristic Calculate also runs and logs above.
+	if orphanIDs := orphanedRefundIDs(e.refundMatches[settlement.SupplierID]); len(orphanIDs) > 0 {
+		settlement.Warnings = append(settlement.Warnings, AnomalyOrphanedRefund)
+		if !hadOrphanedRefund {
+			e.emitAnomalyEvent(AnomalyOrphanedRefund, settlement, decimal.NewFromInt(int64(len(orphanIDs))), decimal.Zero, orphanIDs)
+		}
+	}
+
+	// Check for transactions DetectDuplicateByFingerprint considers a
+	// retried economic event under a fresh ID, so reconciliation can keep
+	// the first-seen transaction and treat the rest as the duplicates they are.
+	if dupIDs := e.fingerprintDuplicates[settlement.SupplierID]; len(dupIDs) > 0 {
+		settlement.Warnings = append(settlement.Warnings, AnomalyDuplicateFingerprint)
+		if !hadDuplicateFingerprint {
+			e.emitAnomalyEvent(AnomalyDuplicateFingerprint, settlement, decimal.NewFromInt(int64(len(dupIDs))), decimal.Zero, dupIDs)
+		}
+	}
+
+	e.detectAnomaliesFromRegistry(settlement, policy)
+}
+
+// detectAnomaliesFromRegistry runs e.Detectors, if configured, against
+// settlement and folds its results into Warnings/AnomalySink the same way
+// the hardcoded checks above do: each detector's rule is stripped before
+// re-running so the call stays idempotent, and AnomalySink only sees an
+// event the round a rule first appears. A nil e.Detectors is a no-op.
+func (e *Engine) detectAnomaliesFromRegistry(settlement *domain.SupplierSettlement, policy *AnomalyPolicy) {
+	if e.Detectors == nil {
+		return
+	}
+
+	rules := make([]string, 0, len(e.Detectors.Detectors()))
+	hadRule := make(map[string]bool, len(rules))
+	for _, d := range e.Detectors.Detectors() {
+		rules = append(rules, d.Name())
+		hadRule[d.Name()] = containsWarning(settlement.Warnings, d.Name())
+	}
+	settlement.Warnings = filterWarnings(settlement.Warnings, rules...)
+
+	sc := &SettlementContext{
+		Settlement:      settlement,
+		Policy:          policy,
+		FXService:       e.fxService,
+		OrphanRefundIDs: orphanedRefundIDs(e.refundMatches[settlement.SupplierID]),
+	}
+
+	for _, anomaly := range e.Detectors.Detect(context.Background(), sc) {
+		settlement.Warnings = append(settlement.Warnings, anomaly.Rule)
+		if !hadRule[anomaly.Rule] {
+			e.emitAnomalyEvent(anomaly.Rule, settlement, anomaly.Observed, anomaly.Threshold, anomaly.TransactionIDs)
+		}
+	}
+}
+
+// orphanedRefundIDs returns the RefundID of every MatchResult in results
+// flagged IsOrphan.
+func orphanedRefundIDs(results []matching.MatchResult) []string {
+	var ids []string
+	for _, result := range results {
+		if result.IsOrphan {
+			ids = append(ids, result.RefundID)
+		}
+	}
+	return ids
+}
+
+// activePolicy returns the policy detectAnomalies should check against:
+// hotPolicy if WatchPolicy has loaded one, else Policy, falling back to
+// DefaultAnomalyPolicy if neither is set - so callers can invoke its methods
+// directly without a nil check of their own.
+func (e *Engine) activePolicy() *AnomalyPolicy {
+	e.policyMu.RLock()
+	defer e.policyMu.RUnlock()
+	if e.hotPolicy != nil {
+		return effectivePolicy(e.hotPolicy)
+	}
+	return effectivePolicy(e.Policy)
+}
+
+// WatchPolicy loads provider's policy immediately, then reloads it every
+// time a signal arrives on reload, swapping it in atomically for subsequent
+// detectAnomalies calls. Once WatchPolicy has loaded a policy it takes
+// precedence over Policy. Returns the initial load's error, if any; reload
+// errors are logged and the previous policy stays in effect. Typical usage
+// wires reload to SIGHUP:
+//
+//	sighup := make(chan os.Signal, 1)
+//	signal.Notify(sighup, syscall.SIGHUP)
+//	engine.WatchPolicy(settlement.NewFilePolicyProvider("policy.json"), sighup)
+func (e *Engine) WatchPolicy(provider PolicyProvider, reload <-chan os.Signal) error {
+	policy, err := provider.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load initial anomaly policy: %w", err)
+	}
+
+	e.policyMu.Lock()
+	e.hotPolicy = policy
+	e.policyMu.Unlock()
+
+	go func() {
+		for range reload {
+			policy, err := provider.Load()
+			if err != nil {
+				log.Printf("WARNING: failed to reload anomaly policy, keeping previous policy: %v", err)
+				continue
+			}
+			e.policyMu.Lock()
+			e.hotPolicy = policy
+			e.policyMu.Unlock()
+		}
+	}()
+
+	return nil
+}
+
+// emitAnomalyEvent sends an AnomalyEvent to AnomalySink if one is
+// configured. Delivery is best-effort: an error is logged rather than
+// propagated, since a sink outage shouldn't fail settlement calculation.
+func (e *Engine) emitAnomalyEvent(rule string, settlement *domain.SupplierSettlement, observed, threshold decimal.Decimal, transactionIDs []string) {
+	if e.AnomalySink == nil {
+		return
+	}
+
+	event := AnomalyEvent{
+		SupplierID:     settlement.SupplierID,
+		Rule:           rule,
+		Observed:       observed,
+		Threshold:      threshold,
+		TransactionIDs: transactionIDs,
+		Timestamp:      time.Now(),
+	}
+	if err := e.AnomalySink.Emit(event); err != nil {
+		log.Printf("WARNING: failed to emit anomaly event (rule=%s, supplier=%s): %v", rule, settlement.SupplierID, err)
+	}
+}
+
+// refundLineIDs returns the transaction IDs of every refund line in
+// settlement, for attaching to an AnomalyHighRefundRate event.
+func refundLineIDs(settlement *domain.SupplierSettlement) []string {
+	return lineIDsByType(settlement, domain.Refund)
+}
+
+// chargebackLineIDs returns the transaction IDs of settlement's chargeback
+// lines, for attaching to an AnomalyHighChargebackRate event.
+func chargebackLineIDs(settlement *domain.SupplierSettlement) []string {
+	return lineIDsByType(settlement, domain.Chargeback)
+}
+
+// lineIDsByType returns the transaction IDs of settlement's lines matching
+// txType, in Lines order.
+func lineIDsByType(settlement *domain.SupplierSettlement, txType domain.TransactionType) []string {
+	var ids []string
+	for _, line := range settlement.Lines {
+		if line.Transaction.Type == txType {
+			ids = append(ids, line.Transaction.ID)
+		}
+	}
+	return ids
+}
+
+// triggeringVolatility finds every matched pair in settlement whose variance
+// actually exceeded policy's threshold for its currency - narrower than
+// settlement.MaxVariance/every matched pair, since different pairs can carry
+// different per-currency thresholds and DetectVolatilityForSettlement can
+// return true on the strength of one over-threshold pair while a larger but
+// still-under-its-own-threshold variance elsewhere holds MaxVariance. It
+// returns the most severe trigger (largest variance-minus-threshold excess)
+// as observed/threshold, plus every triggering pair's transaction IDs, for
+// attaching to an AnomalyVolatility event. ok is false if nothing triggered.
+func (e *Engine) triggeringVolatility(settlement *domain.SupplierSettlement, policy *AnomalyPolicy) (observed, threshold decimal.Decimal, ids []string, ok bool) {
+	authCurrency := make(map[string]domain.Currency, len(settlement.AuthTransactions))
+	for _, auth := range settlement.AuthTransactions {
+		authCurrency[auth.ID] = auth.Currency
+	}
+
+	var worstExcess decimal.Decimal
+	for _, match := range settlement.AuthCaptureMatches {
+		matchThreshold := policy.FXVarianceThreshold(authCurrency[match.AuthID])
+		if !match.Variance.GreaterThan(matchThreshold) {
+			continue
+		}
+		ids = append(ids, match.AuthID, match.CaptureID)
+
+		// excess (rather than a variance/threshold ratio) ranks severity
+		// without dividing by matchThreshold, which an operator-supplied
+		// policy can legitimately set to zero (CurrencyFXVarianceOverrides
+		// documents 0 as "flag all variance" for a currency) and which
+		// decimal.Div panics on.
+		excess := match.Variance.Sub(matchThreshold)
+		if !ok || excess.GreaterThan(worstExcess) {
+			observed = match.Variance
+			threshold = matchThreshold
+			worstExcess = excess
+			ok = true
+		}
+	}
+	return observed, threshold, ids, ok
+}
+
+// filterWarnings returns a copy of warnings with every occurrence of any
+// code in remove stripped out, preserving the order of what's left.
+func filterWarnings(warnings []string, remove ...string) []string {
+	if len(warnings) == 0 {
+		return warnings
+	}
+
+	skip := make(map[string]bool, len(remove))
+	for _, code := range remove {
+		skip[code] = true
+	}
+
+	filtered := make([]string, 0, len(warnings))
+	for _, code := range warnings {
+		if !skip[code] {
+			filtered = append(filtered, code)
+		}
+	}
+	return filtered
+}
+
+// containsWarning reports whether code is already present in warnings.
+func containsWarning(warnings []string, code string) bool {
+	for _, w := range warnings {
+		if w == code {
+			return true
+		}
+	}
+	return false
 }
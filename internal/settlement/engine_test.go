@@ -1,11 +1,18 @@
 package settlement
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/ignacio/solara-settlement/internal/domain"
 	"github.com/ignacio/solara-settlement/internal/fxrate"
+	"github.com/ignacio/solara-settlement/internal/processor"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,7 +20,7 @@ import (
 
 func TestNewEngine(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	assert.NotNil(t, engine)
@@ -23,7 +30,7 @@ func TestNewEngine(t *testing.T) {
 
 func TestEngine_Calculate_SingleSupplier(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -67,7 +74,7 @@ func TestEngine_Calculate_SingleSupplier(t *testing.T) {
 
 func TestEngine_Calculate_MultipleSuppliers(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -101,7 +108,7 @@ func TestEngine_Calculate_MultipleSuppliers(t *testing.T) {
 
 func TestEngine_Calculate_WithFXConversion(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -132,9 +139,157 @@ func TestEngine_Calculate_WithFXConversion(t *testing.T) {
 	assert.True(t, settlement.TotalCapturesUSD.LessThan(decimal.NewFromFloat(21)))
 }
 
+// TestEngine_Calculate_WithReplayProvider_MatchesGoldenDecimal replaces
+// MockProvider's date-based volatility with a fxrate.ReplayProvider fixture
+// of exact rates, so NetAmountUSD can be asserted against a precise golden
+// decimal instead of the range TestEngine_Calculate_WithFXConversion checks.
+func TestEngine_Calculate_WithReplayProvider_MatchesGoldenDecimal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	fixture := `[{"date":"2024-01-15","currency":"BRL","rate":"0.20"}]`
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0644))
+
+	provider, err := fxrate.NewReplayProvider(path)
+	require.NoError(t, err)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	engine := NewEngine(fxService)
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.BRL,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	// 100 BRL * exactly 0.20 = exactly 20 USD, no volatility band to allow for.
+	assert.True(t, settlements[0].NetAmountUSD.Equal(decimal.NewFromFloat(20)))
+}
+
+// TestEngine_Calculate_RecordingProviderFixtureReplaysIdenticalResult shows
+// the two providers working together: record a live/mock run once, then
+// rerun Calculate against the recorded fixture and assert it reproduces the
+// exact same settlement, so a later change to the upstream/mock provider
+// can't silently change what the fixture-driven regression test asserts.
+func TestEngine_Calculate_RecordingProviderFixtureReplaysIdenticalResult(t *testing.T) {
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.BRL,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	recording := fxrate.NewRecordingProvider(fxrate.NewMockProvider())
+	liveEngine := NewEngine(fxrate.NewService([]fxrate.Provider{recording}))
+	liveSettlements, err := liveEngine.Calculate(transactions)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "recorded.json")
+	require.NoError(t, recording.Save(path))
+
+	replayProvider, err := fxrate.NewReplayProvider(path)
+	require.NoError(t, err)
+	replayEngine := NewEngine(fxrate.NewService([]fxrate.Provider{replayProvider}))
+	replaySettlements, err := replayEngine.Calculate(transactions)
+	require.NoError(t, err)
+
+	require.Len(t, replaySettlements, 1)
+	assert.True(t, replaySettlements[0].NetAmountUSD.Equal(liveSettlements[0].NetAmountUSD))
+}
+
+// TestEngine_Calculate_WithMoneyArithmetic_MatchesDecimalPath shows
+// NewEngineWithMoney's internal/money-backed conversion agreeing with the
+// ordinary decimal.Decimal path on a clean-rate fixture - the two only
+// diverge in how they arrive at the number, not in what the number is.
+func TestEngine_Calculate_WithMoneyArithmetic_MatchesDecimalPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	fixture := `[{"date":"2024-01-15","currency":"BRL","rate":"0.20"}]`
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0644))
+
+	provider, err := fxrate.NewReplayProvider(path)
+	require.NoError(t, err)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	engine := NewEngineWithMoney(fxService)
+	assert.True(t, engine.UseMoneyArithmetic)
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.BRL,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	assert.True(t, settlements[0].NetAmountUSD.Equal(decimal.NewFromFloat(20)), "got %s", settlements[0].NetAmountUSD)
+	assert.True(t, settlements[0].Lines[0].USDAmount.Equal(decimal.NewFromFloat(20)))
+}
+
+// TestEngine_Calculate_WithMoneyArithmetic_RoundsToCentsLikeDecimalPath uses
+// a rate that doesn't terminate at 2 decimal places, so it only passes if
+// recomputeWithMoney rounds its wider 10^4-scale result down to USD's
+// minor-unit precision the same way fxService.ConvertToUSDWithPath's
+// decimal.Decimal path already does - otherwise the money-arithmetic
+// engine would leave sub-cent residue the decimal-only engine never
+// produces for this same input.
+func TestEngine_Calculate_WithMoneyArithmetic_RoundsToCentsLikeDecimalPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	fixture := `[{"date":"2024-01-15","currency":"BRL","rate":"0.333333"}]`
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0644))
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.BRL,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	decimalProvider, err := fxrate.NewReplayProvider(path)
+	require.NoError(t, err)
+	decimalSettlements, err := NewEngine(fxrate.NewService([]fxrate.Provider{decimalProvider})).Calculate(transactions)
+	require.NoError(t, err)
+
+	moneyProvider, err := fxrate.NewReplayProvider(path)
+	require.NoError(t, err)
+	moneySettlements, err := NewEngineWithMoney(fxrate.NewService([]fxrate.Provider{moneyProvider})).Calculate(transactions)
+	require.NoError(t, err)
+
+	assert.True(t, moneySettlements[0].Lines[0].USDAmount.Equal(decimalSettlements[0].Lines[0].USDAmount),
+		"money path %s should round to cents the same as the decimal path %s",
+		moneySettlements[0].Lines[0].USDAmount, decimalSettlements[0].Lines[0].USDAmount)
+}
+
 func TestEngine_Calculate_OnlyRefunds(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -172,9 +327,137 @@ func TestEngine_Calculate_OnlyRefunds(t *testing.T) {
 	assert.True(t, settlement.NetAmountUSD.Equal(decimal.NewFromFloat(-80)))
 }
 
+func TestEngine_Calculate_OnlyChargebacks(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	engine := NewEngine(fxService)
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Chargeback,
+			OriginalAmount: decimal.NewFromFloat(50),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "tx002",
+			SupplierID:     "sup123",
+			Type:           domain.Chargeback,
+			OriginalAmount: decimal.NewFromFloat(30),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, len(settlements))
+
+	settlement := settlements[0]
+	assert.Equal(t, 2, settlement.TransactionCount)
+	assert.True(t, settlement.TotalCapturesUSD.Equal(decimal.Zero))
+	assert.True(t, settlement.TotalChargebacksUSD.Equal(decimal.NewFromFloat(80)))
+	assert.True(t, settlement.NetAmountUSD.Equal(decimal.NewFromFloat(-80)))
+}
+
+func TestEngine_Calculate_MixedRefundsAndChargebacks(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	engine := NewEngine(fxService)
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "tx002",
+			SupplierID:     "sup123",
+			Type:           domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "tx003",
+			SupplierID:     "sup123",
+			Type:           domain.Chargeback,
+			OriginalAmount: decimal.NewFromFloat(50),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, len(settlements))
+
+	settlement := settlements[0]
+	assert.Equal(t, 3, settlement.TransactionCount)
+	assert.True(t, settlement.TotalCapturesUSD.Equal(decimal.NewFromFloat(1000)))
+	assert.True(t, settlement.TotalRefundsUSD.Equal(decimal.NewFromFloat(100)))
+	assert.True(t, settlement.TotalChargebacksUSD.Equal(decimal.NewFromFloat(50)))
+	assert.True(t, settlement.NetAmountUSD.Equal(decimal.NewFromFloat(850)))
+}
+
+func TestEngine_Calculate_HighChargebackRateWarning(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	engine := NewEngine(fxService)
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "tx002",
+			SupplierID:     "sup123",
+			Type:           domain.Chargeback,
+			OriginalAmount: decimal.NewFromFloat(50), // 5% of captures, above the 1% default threshold
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, len(settlements))
+
+	settlement := settlements[0]
+	assert.True(t, settlement.ChargebackRate.Equal(decimal.NewFromFloat(5)))
+	assert.Contains(t, settlement.Warnings, AnomalyHighChargebackRate)
+}
+
 func TestEngine_Calculate_NoCaptures(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -204,7 +487,7 @@ func TestEngine_Calculate_NoCaptures(t *testing.T) {
 
 func TestEngine_Calculate_SameDayTransactions(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	sameDay := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -246,7 +529,7 @@ func TestEngine_Calculate_SameDayTransactions(t *testing.T) {
 
 func TestEngine_Calculate_DifferentDayTransactions(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	day1 := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -289,7 +572,7 @@ func TestEngine_Calculate_DifferentDayTransactions(t *testing.T) {
 
 func TestEngine_Calculate_EmptyTransactions(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	settlements, err := engine.Calculate([]*domain.Transaction{})
@@ -300,7 +583,7 @@ func TestEngine_Calculate_EmptyTransactions(t *testing.T) {
 
 func TestEngine_Calculate_MultipleCurrencies(t *testing.T) {
 	provider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(provider)
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
 	engine := NewEngine(fxService)
 
 	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -346,3 +629,908 @@ func TestEngine_Calculate_MultipleCurrencies(t *testing.T) {
 	// Each currency should be converted appropriately
 	assert.True(t, settlement.TotalCapturesUSD.GreaterThan(decimal.Zero))
 }
+
+// noDirectARSProvider is a GraphProvider with no direct ARS→USD rate,
+// forcing the engine to settle ARS captures via the ARS→BRL→USD path.
+type noDirectARSProvider struct{}
+
+func (p *noDirectARSProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	switch currency {
+	case domain.USD:
+		return decimal.NewFromInt(1), nil
+	case domain.BRL:
+		return decimal.NewFromFloat(0.20), nil
+	default:
+		return decimal.Zero, fmt.Errorf("no direct rate for %s", currency)
+	}
+}
+
+func (p *noDirectARSProvider) Rates(date time.Time) (map[fxrate.CurrencyPair]decimal.Decimal, error) {
+	return map[fxrate.CurrencyPair]decimal.Decimal{
+		{From: domain.ARS, To: domain.BRL}: decimal.NewFromFloat(0.006),
+		{From: domain.BRL, To: domain.USD}: decimal.NewFromFloat(0.20),
+	}, nil
+}
+
+func TestEngine_Calculate_SettlesViaMultiHopPathWhenDirectRateMissing(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{&noDirectARSProvider{}})
+	engine := NewEngine(fxService)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "tx001",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(settlements))
+
+	line := settlements[0].Lines[0]
+	assert.Equal(t, []domain.Currency{domain.ARS, domain.BRL, domain.USD}, line.ConversionPath)
+	assert.True(t, line.USDAmount.GreaterThan(decimal.Zero))
+}
+
+func TestEngine_CalculateStream_SettlesFromChannel(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.ConfigureStreaming(NewMemorySpiller(), 2)
+
+	ch := make(chan *domain.Transaction, 3)
+	ch <- &domain.Transaction{
+		ID:             "tx001",
+		SupplierID:     "sup123",
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(100),
+		Currency:       domain.USD,
+		Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Status:         domain.Completed,
+	}
+	ch <- &domain.Transaction{
+		ID:             "tx002",
+		SupplierID:     "sup123",
+		Type:           domain.Refund,
+		OriginalAmount: decimal.NewFromFloat(20),
+		Currency:       domain.USD,
+		Timestamp:      time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC),
+		Status:         domain.Completed,
+	}
+	close(ch)
+
+	out, err := engine.CalculateStream(context.Background(), ch)
+	require.NoError(t, err)
+
+	var settlements []*domain.SupplierSettlement
+	for settlement := range out {
+		settlements = append(settlements, settlement)
+	}
+
+	require.Equal(t, 1, len(settlements))
+	assert.Equal(t, "sup123", settlements[0].SupplierID)
+	assert.Equal(t, 2, settlements[0].TransactionCount)
+}
+
+func TestEngine_CalculateStream_ConsumesCSVReaderReadStreamIncrementally(t *testing.T) {
+	// Demonstrates processor.CSVReader.ReadStream feeding CalculateStream
+	// directly, so per-supplier aggregation happens as records are parsed
+	// rather than after an input file is fully loaded into a slice.
+	csvData := `transaction_id,supplier_id,type,original_amount,currency,timestamp,status
+tx001,sup123,capture,100.00,USD,2024-01-15T10:00:00Z,completed
+tx002,sup123,refund,20.00,USD,2024-01-16T10:00:00Z,completed
+tx003,sup456,capture,50.00,USD,2024-01-17T10:00:00Z,completed`
+
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.ConfigureStreaming(NewMemorySpiller(), 2)
+
+	ch := make(chan *domain.Transaction)
+	var readErr error
+	go func() {
+		defer close(ch)
+		reader := processor.NewCSVReader()
+		readErr = reader.ReadStream(context.Background(), strings.NewReader(csvData), func(tx *domain.Transaction) error {
+			ch <- tx
+			return nil
+		})
+	}()
+
+	out, err := engine.CalculateStream(context.Background(), ch)
+	require.NoError(t, err)
+
+	settlements := make(map[string]*domain.SupplierSettlement)
+	for settlement := range out {
+		settlements[settlement.SupplierID] = settlement
+	}
+
+	require.NoError(t, readErr)
+	require.Len(t, settlements, 2)
+	assert.Equal(t, 2, settlements["sup123"].TransactionCount)
+	assert.Equal(t, 1, settlements["sup456"].TransactionCount)
+}
+
+func TestEngine_CalculateStream_RequiresConfigureStreaming(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+
+	ch := make(chan *domain.Transaction)
+	close(ch)
+
+	_, err := engine.CalculateStream(context.Background(), ch)
+	assert.Error(t, err)
+}
+
+func TestEngine_Calculate_LotTrackerRealizesGainOnFullRefund(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+
+	captureDate := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	refundDate := time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      captureDate,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "ref1",
+			SupplierID:     "sup123",
+			Type:           domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      refundDate,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	settlement := settlements[0]
+	require.Len(t, settlement.Lines[1].LotConsumption, 1)
+	assert.Equal(t, "cap1", settlement.Lines[1].LotConsumption[0].CaptureID)
+	assert.True(t, settlement.RealizedFXGainUSD.Equal(settlement.Lines[1].LotConsumption[0].GainUSD))
+	// The refund fully consumed the only lot, so nothing is left to mark to market.
+	assert.True(t, settlement.UnrealizedFXGainUSD.IsZero())
+	assert.NotContains(t, settlement.Warnings, AnomalyFXLotUnderflow)
+}
+
+func TestEngine_Calculate_LotTrackerRealizesGainOnChargebackAndReopensLotOnReversal(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+
+	captureDate := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	chargebackDate := time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC)
+	reversalDate := time.Date(2024, 1, 25, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      captureDate,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "cb1",
+			SupplierID:     "sup123",
+			Type:           domain.Chargeback,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      chargebackDate,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "cbr1",
+			SupplierID:     "sup123",
+			Type:           domain.ChargebackReversal,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      reversalDate,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	settlement := settlements[0]
+	// The chargeback draws down cap1's lot exactly as a refund would.
+	require.Len(t, settlement.Lines[1].LotConsumption, 1)
+	assert.Equal(t, "cap1", settlement.Lines[1].LotConsumption[0].CaptureID)
+	assert.NotContains(t, settlement.Warnings, AnomalyFXLotUnderflow)
+
+	// The reversal reopens a lot rather than consuming one, so a later
+	// refund/chargeback against this supplier/currency has a lot to draw
+	// from instead of underflowing.
+	assert.Empty(t, settlement.Lines[2].LotConsumption)
+}
+
+func TestEngine_Calculate_LotTrackerProcessesOutOfOrderTransactionsChronologically(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+
+	captureDate := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	refundDate := time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC)
+
+	// The refund appears before its capture in the input slice; the engine
+	// must still process the capture first so the refund has a lot to draw from.
+	transactions := []*domain.Transaction{
+		{
+			ID:             "ref1",
+			SupplierID:     "sup123",
+			Type:           domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      refundDate,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(1000),
+			Currency:       domain.ARS,
+			Timestamp:      captureDate,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	settlement := settlements[0]
+	assert.NotContains(t, settlement.Warnings, AnomalyFXLotUnderflow)
+	assert.False(t, settlement.RealizedFXGainUSD.IsZero())
+
+	var refundLine *domain.SettlementLine
+	for i := range settlement.Lines {
+		if settlement.Lines[i].Transaction.ID == "ref1" {
+			refundLine = &settlement.Lines[i]
+		}
+	}
+	require.NotNil(t, refundLine)
+	require.Len(t, refundLine.LotConsumption, 1)
+	assert.Equal(t, "cap1", refundLine.LotConsumption[0].CaptureID)
+}
+
+func TestEngine_Calculate_LotTrackerWarnsOnUnderflow(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.BRL,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+		{
+			ID:             "ref1",
+			SupplierID:     "sup123",
+			Type:           domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(500),
+			Currency:       domain.BRL,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	assert.Contains(t, settlements[0].Warnings, AnomalyFXLotUnderflow)
+}
+
+func TestEngine_Calculate_LotTrackerWarnsOnlyOncePerSettlementDespiteMultipleUnderflows(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID: "ref1", SupplierID: "sup123", Type: domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL,
+			Timestamp: validTime, Status: domain.Completed,
+		},
+		{
+			ID: "ref2", SupplierID: "sup123", Type: domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL,
+			Timestamp: validTime.Add(time.Hour), Status: domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	count := 0
+	for _, w := range settlements[0].Warnings {
+		if w == AnomalyFXLotUnderflow {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestEngine_Calculate_LotTrackerMarksRemainingBalanceToMarket(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.MXN,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	settlement := settlements[0]
+	// Only one transaction occurred, so the mark-to-market rate is the same
+	// rate the lot was opened at and the unrealized gain is zero; the point
+	// of this test is that it's computed (via OpenCurrencies) rather than
+	// left at its zero value by omission.
+	assert.True(t, settlement.UnrealizedFXGainUSD.IsZero())
+	assert.Equal(t, []domain.Currency{domain.MXN}, engine.LotTracker.OpenCurrencies("sup123"))
+}
+
+func TestEngine_Calculate_PresentationCurrenciesPopulatesConvertedAmountsAndTotals(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.PresentationCurrencies = []domain.Currency{domain.BRL, domain.USD}
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	settlement := settlements[0]
+	require.Len(t, settlement.Lines, 1)
+
+	// USD is always excluded from conversion even though it was listed, since
+	// NetAmountUSD/USDAmount already cover it.
+	_, hasUSD := settlement.Lines[0].ConvertedAmounts[domain.USD]
+	assert.False(t, hasUSD)
+
+	brlAmount, ok := settlement.Lines[0].ConvertedAmounts[domain.BRL]
+	require.True(t, ok)
+	assert.True(t, brlAmount.GreaterThan(decimal.Zero))
+
+	brlTotal, ok := settlement.PresentationTotals[domain.BRL]
+	require.True(t, ok)
+	assert.True(t, brlTotal.Equal(brlAmount))
+}
+
+func TestEngine_Calculate_NoPresentationCurrenciesLeavesConvertedAmountsNil(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.USD,
+			Timestamp:      validTime,
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	assert.Nil(t, settlements[0].Lines[0].ConvertedAmounts)
+	assert.Empty(t, settlements[0].PresentationTotals)
+}
+
+func TestEngine_Calculate_PopulatesRateSource(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.BRL,
+			Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			Status:         domain.Completed,
+		},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	require.Len(t, settlements[0].Lines, 1)
+
+	assert.Equal(t, "mock", settlements[0].Lines[0].RateSource)
+}
+
+func TestEngine_CalculateIncremental_RequiresPriorCalculate(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+
+	transactions := []*domain.Transaction{
+		{
+			ID:             "cap1",
+			SupplierID:     "sup123",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100),
+			Currency:       domain.USD,
+			Timestamp:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			Status:         domain.Completed,
+		},
+	}
+
+	_, err := engine.CalculateIncremental(transactions)
+	assert.Error(t, err)
+}
+
+func TestEngine_CalculateIncremental_MatchesCalculateOverCombinedTransactions(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	first := []*domain.Transaction{
+		{
+			ID: "cap1", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL,
+			Timestamp: baseTime, Status: domain.Completed,
+		},
+	}
+	second := []*domain.Transaction{
+		{
+			ID: "cap2", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(50), Currency: domain.BRL,
+			Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+		},
+		{
+			ID: "cap3", SupplierID: "sup456", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(75), Currency: domain.USD,
+			Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+		},
+	}
+
+	incremental := NewEngine(fxService)
+	_, err := incremental.Calculate(first)
+	require.NoError(t, err)
+	gotSettlements, err := incremental.CalculateIncremental(second)
+	require.NoError(t, err)
+
+	combined := NewEngine(fxService)
+	wantSettlements, err := combined.Calculate(append(append([]*domain.Transaction{}, first...), second...))
+	require.NoError(t, err)
+
+	wantBySupplier := make(map[string]*domain.SupplierSettlement)
+	for _, s := range wantSettlements {
+		wantBySupplier[s.SupplierID] = s
+	}
+
+	require.Len(t, gotSettlements, len(wantSettlements))
+	for _, got := range gotSettlements {
+		want, ok := wantBySupplier[got.SupplierID]
+		require.True(t, ok, "unexpected supplier %s", got.SupplierID)
+		assert.True(t, want.NetAmountUSD.Equal(got.NetAmountUSD))
+		assert.Equal(t, want.TransactionCount, got.TransactionCount)
+	}
+}
+
+func TestEngine_SnapshotRevert_UndoesIncrementalBatch(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	initial := []*domain.Transaction{
+		{
+			ID: "cap1", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD,
+			Timestamp: baseTime, Status: domain.Completed,
+		},
+	}
+	settlements, err := engine.Calculate(initial)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	handle := engine.Snapshot()
+
+	speculative := []*domain.Transaction{
+		{
+			ID: "cap2", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(500), Currency: domain.USD,
+			Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+		},
+		{
+			ID: "cap3", SupplierID: "sup999", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(200), Currency: domain.USD,
+			Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+		},
+	}
+	_, err = engine.CalculateIncremental(speculative)
+	require.NoError(t, err)
+
+	engine.Revert(handle)
+
+	reverted := engine.settlementSlice()
+	bySupplier := make(map[string]*domain.SupplierSettlement)
+	for _, s := range reverted {
+		bySupplier[s.SupplierID] = s
+	}
+
+	_, stillExists := bySupplier["sup999"]
+	assert.False(t, stillExists, "supplier created entirely after the snapshot should be dropped on revert")
+
+	sup123 := bySupplier["sup123"]
+	require.NotNil(t, sup123)
+	assert.Len(t, sup123.Lines, 1)
+	assert.True(t, sup123.NetAmountUSD.Equal(decimal.NewFromFloat(100)))
+	assert.Equal(t, 1, sup123.TransactionCount)
+}
+
+func TestEngine_CalculateIncremental_DoesNotDuplicateWarningsAcrossRounds(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// A capture with a refund larger than it trips both AnomalyHighRefundRate
+	// (refund rate over captures exceeds the 20% threshold) and
+	// AnomalyNegativeNet (captures minus refunds goes negative) on the very
+	// first Calculate; CalculateIncremental re-runs detectAnomalies on this
+	// supplier on every subsequent round, and must not re-append the same
+	// codes each time.
+	initial := []*domain.Transaction{
+		{
+			ID: "cap1", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD,
+			Timestamp: baseTime, Status: domain.Completed,
+		},
+		{
+			ID: "ref1", SupplierID: "sup123", Type: domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(150), Currency: domain.USD,
+			Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+		},
+	}
+	settlements, err := engine.Calculate(initial)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	more := []*domain.Transaction{
+		{
+			ID: "ref2", SupplierID: "sup123", Type: domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(50), Currency: domain.USD,
+			Timestamp: baseTime.Add(2 * time.Hour), Status: domain.Completed,
+		},
+	}
+	for i := 0; i < 3; i++ {
+		_, err := engine.CalculateIncremental(more)
+		require.NoError(t, err)
+	}
+
+	var sup123 *domain.SupplierSettlement
+	for _, s := range engine.settlementSlice() {
+		if s.SupplierID == "sup123" {
+			sup123 = s
+		}
+	}
+	require.NotNil(t, sup123)
+
+	counts := make(map[string]int)
+	for _, w := range sup123.Warnings {
+		counts[w]++
+	}
+	assert.Equal(t, 1, counts[AnomalyHighRefundRate])
+	assert.Equal(t, 1, counts[AnomalyNegativeNet])
+}
+
+func TestEngine_Revert_RestoresAuthTransactionsAndVolatilityMatches(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	initialAuth := &domain.Transaction{
+		ID: "auth1", SupplierID: "sup123", Type: domain.Authorization,
+		OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL,
+		Timestamp: baseTime, Status: domain.Completed,
+	}
+	initialCapture := &domain.Transaction{
+		ID: "cap1", SupplierID: "sup123", Type: domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL,
+		Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+	}
+	settlements, err := engine.Calculate([]*domain.Transaction{initialAuth, initialCapture})
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	require.Len(t, settlements[0].AuthCaptureMatches, 1)
+
+	handle := engine.Snapshot()
+
+	speculativeAuth := &domain.Transaction{
+		ID: "auth2", SupplierID: "sup123", Type: domain.Authorization,
+		OriginalAmount: decimal.NewFromFloat(300), Currency: domain.BRL,
+		Timestamp: baseTime.Add(2 * time.Hour), Status: domain.Completed,
+	}
+	speculativeCapture := &domain.Transaction{
+		ID: "cap2", SupplierID: "sup123", Type: domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(300), Currency: domain.BRL,
+		Timestamp: baseTime.Add(3 * time.Hour), Status: domain.Completed,
+	}
+	_, err = engine.CalculateIncremental([]*domain.Transaction{speculativeAuth, speculativeCapture})
+	require.NoError(t, err)
+
+	engine.Revert(handle)
+
+	var sup123 *domain.SupplierSettlement
+	for _, s := range engine.settlementSlice() {
+		if s.SupplierID == "sup123" {
+			sup123 = s
+		}
+	}
+	require.NotNil(t, sup123)
+
+	assert.Len(t, sup123.AuthTransactions, 1)
+	assert.Equal(t, "auth1", sup123.AuthTransactions[0].ID)
+	require.Len(t, sup123.AuthCaptureMatches, 1)
+	assert.Equal(t, "auth1", sup123.AuthCaptureMatches[0].AuthID)
+}
+
+func TestEngine_CalculateIncremental_DoesNotDoubleCountUnrealizedFXGain(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	initial := []*domain.Transaction{
+		{
+			ID: "cap1", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.MXN,
+			Timestamp: baseTime, Status: domain.Completed,
+		},
+	}
+	settlements, err := engine.Calculate(initial)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	wantGain := settlements[0].UnrealizedFXGainUSD
+
+	// Each round adds an unrelated USD transaction for sup123, which re-runs
+	// appendTransactions (and so the unrealized-gain recomputation) without
+	// itself touching the open MXN lot; the gain should re-derive to the
+	// same value every round, not accumulate on top of the previous round's.
+	for i := 0; i < 3; i++ {
+		more := []*domain.Transaction{
+			{
+				ID: fmt.Sprintf("cap-usd-%d", i), SupplierID: "sup123", Type: domain.Capture,
+				OriginalAmount: decimal.NewFromFloat(10), Currency: domain.USD,
+				Timestamp: baseTime.Add(time.Duration(i+1) * time.Hour), Status: domain.Completed,
+			},
+		}
+		_, err := engine.CalculateIncremental(more)
+		require.NoError(t, err)
+	}
+
+	var sup123 *domain.SupplierSettlement
+	for _, s := range engine.settlementSlice() {
+		if s.SupplierID == "sup123" {
+			sup123 = s
+		}
+	}
+	require.NotNil(t, sup123)
+	assert.True(t, wantGain.Equal(sup123.UnrealizedFXGainUSD))
+}
+
+func TestEngine_CalculateIncremental_DoesNotDuplicateLotUnderflowWarningAcrossRounds(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// A refund with no matching capture lot underflows immediately.
+	initial := []*domain.Transaction{
+		{
+			ID: "ref1", SupplierID: "sup123", Type: domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL,
+			Timestamp: baseTime, Status: domain.Completed,
+		},
+	}
+	settlements, err := engine.Calculate(initial)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	more := []*domain.Transaction{
+		{
+			ID: "ref2", SupplierID: "sup123", Type: domain.Refund,
+			OriginalAmount: decimal.NewFromFloat(50), Currency: domain.BRL,
+			Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+		},
+	}
+	for i := 0; i < 3; i++ {
+		_, err := engine.CalculateIncremental(more)
+		require.NoError(t, err)
+	}
+
+	var sup123 *domain.SupplierSettlement
+	for _, s := range engine.settlementSlice() {
+		if s.SupplierID == "sup123" {
+			sup123 = s
+		}
+	}
+	require.NotNil(t, sup123)
+
+	count := 0
+	for _, w := range sup123.Warnings {
+		if w == AnomalyFXLotUnderflow {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestEngine_Calculate_DoesNotReuseStaleFXRateAcrossRuns(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.LotTracker = NewLotTracker()
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// First run opens a BRL lot for sup123 and records its mark-to-market rate.
+	first := []*domain.Transaction{
+		{
+			ID: "cap1", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(100), Currency: domain.BRL,
+			Timestamp: baseTime, Status: domain.Completed,
+		},
+	}
+	settlements, err := engine.Calculate(first)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	firstRunGain := settlements[0].UnrealizedFXGainUSD
+
+	// Second run is a fresh full Calculate over a batch with no BRL activity
+	// for sup123 at all; the engine should behave as if it had never seen
+	// BRL before, not mark the still-open BRL lot to market using the first
+	// run's remembered rate.
+	second := []*domain.Transaction{
+		{
+			ID: "cap2", SupplierID: "sup123", Type: domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(10), Currency: domain.USD,
+			Timestamp: baseTime.Add(time.Hour), Status: domain.Completed,
+		},
+	}
+	settlements, err = engine.Calculate(second)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	assert.True(t, settlements[0].UnrealizedFXGainUSD.IsZero(),
+		"second run's batch never touched BRL, so it should carry no mark-to-market gain rather than reusing run 1's rate: got %s (run 1 had %s)",
+		settlements[0].UnrealizedFXGainUSD, firstRunGain)
+}
+
+// capturingSink is an AnomalySink test double that records every event it
+// receives, in order.
+type capturingSink struct {
+	events []AnomalyEvent
+}
+
+func (s *capturingSink) Emit(event AnomalyEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestEngine_Calculate_HighRefundRateUsesSupplierPolicyOverride(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	engine.Policy = DefaultAnomalyPolicy()
+	engine.Policy.SupplierRefundRateOverrides = map[string]decimal.Decimal{
+		"high-risk-supplier": decimal.NewFromInt(10),
+	}
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	transactions := []*domain.Transaction{
+		{ID: "cap1", SupplierID: "high-risk-supplier", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed},
+		{ID: "ref1", SupplierID: "high-risk-supplier", Type: domain.Refund, OriginalAmount: decimal.NewFromFloat(15), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed},
+	}
+
+	settlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	// 15% refund rate is below the default 20% threshold but above this
+	// supplier's 10% override.
+	assert.Contains(t, settlements[0].Warnings, AnomalyHighRefundRate)
+}
+
+func TestEngine_Calculate_EmitsAnomalyEventToSink(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+	sink := &capturingSink{}
+	engine.AnomalySink = sink
+
+	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	transactions := []*domain.Transaction{
+		{ID: "cap1", SupplierID: "sup123", Type: domain.Capture, OriginalAmount: decimal.NewFromFloat(100), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed},
+		{ID: "ref1", SupplierID: "sup123", Type: domain.Refund, OriginalAmount: decimal.NewFromFloat(25), Currency: domain.USD, Timestamp: validTime, Status: domain.Completed},
+	}
+
+	_, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	assert.Equal(t, "sup123", event.SupplierID)
+	assert.Equal(t, AnomalyHighRefundRate, event.Rule)
+	assert.True(t, event.Observed.Equal(decimal.NewFromInt(25)))
+	assert.True(t, event.Threshold.Equal(decimal.NewFromInt(20)))
+	assert.Equal(t, []string{"ref1"}, event.TransactionIDs)
+}
+
+func TestEngine_WatchPolicy_ReloadsOnSignal(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	engine := NewEngine(fxService)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"refund_rate_threshold_pct": "20", "fx_variance_threshold_pct": "5"}`), 0644))
+
+	reload := make(chan os.Signal, 1)
+	require.NoError(t, engine.WatchPolicy(NewFilePolicyProvider(path), reload))
+
+	assert.True(t, engine.activePolicy().RefundRateThreshold("sup123").Equal(decimal.NewFromInt(20)))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"refund_rate_threshold_pct": "10", "fx_variance_threshold_pct": "5"}`), 0644))
+	reload <- syscall.SIGHUP
+
+	require.Eventually(t, func() bool {
+		return engine.activePolicy().RefundRateThreshold("sup123").Equal(decimal.NewFromInt(10))
+	}, time.Second, time.Millisecond, "engine should pick up the reloaded policy after the signal")
+}
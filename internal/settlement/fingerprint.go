@@ -0,0 +1,272 @@
+package settlement
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+)
+
+// DefaultFingerprintWindow is how far apart in time two transactions sharing
+// the same economic fingerprint can be and still be considered the same
+// economic event, used by DetectDuplicateByFingerprint and
+// NewFingerprintDuplicateDetector when no window is given.
+const DefaultFingerprintWindow = 60 * time.Second
+
+// Fingerprint returns the SHA-256 hex digest of tx's economic identity -
+// SupplierID, Type, Currency, OriginalAmount, and Timestamp truncated to the
+// second - so two transactions the acquirer reports as distinct IDs but
+// that otherwise describe the same event hash identically.
+func Fingerprint(tx *domain.Transaction) string {
+	sum := sha256.Sum256([]byte(fingerprintContent(tx)))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprintContent builds the string Fingerprint hashes.
+func fingerprintContent(tx *domain.Transaction) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%d",
+		tx.SupplierID, tx.Type, tx.Currency, tx.OriginalAmount.String(),
+		tx.Timestamp.Truncate(time.Second).Unix())
+}
+
+// economicKey is fingerprintContent without the timestamp component, used to
+// find candidate repeats of the same economic event regardless of how far
+// apart in time they land - DetectDuplicateByFingerprint and
+// FingerprintDuplicateDetector then decide whether candidates sharing a key
+// are close enough in time to be the same retried send.
+func economicKey(tx *domain.Transaction) string {
+	return fmt.Sprintf("%s|%s|%s|%s", tx.SupplierID, tx.Type, tx.Currency, tx.OriginalAmount.String())
+}
+
+// DuplicateGroup is a set of transactions DetectDuplicateByFingerprint (or
+// FingerprintDuplicateDetector) considers the same economic event sent more
+// than once, ordered chronologically.
+type DuplicateGroup struct {
+	// Fingerprint is the Fingerprint of the group's first-seen transaction,
+	// identifying the economic event the group's members all repeat.
+	Fingerprint string
+	// SupplierID is the group's members' shared SupplierID.
+	SupplierID string
+	// TransactionIDs lists every transaction in the group, first-seen first.
+	TransactionIDs []string
+}
+
+// FirstSeenID returns the transaction ID reconciliation should keep.
+func (g DuplicateGroup) FirstSeenID() string {
+	return g.TransactionIDs[0]
+}
+
+// DuplicateIDs returns every transaction ID after the first-seen one,
+// reconciliation should mark AnomalyDuplicateFingerprint.
+func (g DuplicateGroup) DuplicateIDs() []string {
+	return g.TransactionIDs[1:]
+}
+
+// DetectDuplicateByFingerprint groups transactions describing the same
+// economic event (same SupplierID, Type, Currency and OriginalAmount)
+// whose timestamps all fall within window of the earliest one in the group,
+// catching a retried send that arrived under a fresh transaction ID -
+// unlike DetectDuplicateIDs, which only catches an exact ID repeated.
+// window <= 0 means DefaultFingerprintWindow. Only groups with two or more
+// members are returned; a lone transaction is never reported.
+func DetectDuplicateByFingerprint(transactions []*domain.Transaction, window time.Duration) []DuplicateGroup {
+	if window <= 0 {
+		window = DefaultFingerprintWindow
+	}
+
+	candidates := make(map[string][]*domain.Transaction)
+	for _, tx := range transactions {
+		key := economicKey(tx)
+		candidates[key] = append(candidates[key], tx)
+	}
+
+	keys := make([]string, 0, len(candidates))
+	for key := range candidates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var groups []DuplicateGroup
+	for _, key := range keys {
+		txs := candidates[key]
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].Timestamp.Before(txs[j].Timestamp)
+		})
+		groups = append(groups, clusterByWindow(txs, window)...)
+	}
+	return groups
+}
+
+// duplicateIDsBySupplier flattens groups' DuplicateIDs() into a map keyed by
+// SupplierID, for Engine to fold into each supplier's settlement Warnings.
+func duplicateIDsBySupplier(groups []DuplicateGroup) map[string][]string {
+	if len(groups) == 0 {
+		return nil
+	}
+	bySupplier := make(map[string][]string)
+	for _, group := range groups {
+		bySupplier[group.SupplierID] = append(bySupplier[group.SupplierID], group.DuplicateIDs()...)
+	}
+	return bySupplier
+}
+
+// flattenDuplicateIDs concatenates every group's DuplicateIDs(), for a
+// caller (e.g. CalculateIncremental) that already knows groups all belong to
+// a single supplier and doesn't need them keyed.
+func flattenDuplicateIDs(groups []DuplicateGroup) []string {
+	var ids []string
+	for _, group := range groups {
+		ids = append(ids, group.DuplicateIDs()...)
+	}
+	return ids
+}
+
+// clusterByWindow splits txs (already sorted by Timestamp) into
+// DuplicateGroups whose members all fall within window of the cluster's
+// first transaction, emitting only clusters with two or more members.
+func clusterByWindow(txs []*domain.Transaction, window time.Duration) []DuplicateGroup {
+	var groups []DuplicateGroup
+
+	clusterStart := 0
+	flush := func(end int) {
+		if end-clusterStart < 2 {
+			return
+		}
+		ids := make([]string, 0, end-clusterStart)
+		for _, tx := range txs[clusterStart:end] {
+			ids = append(ids, tx.ID)
+		}
+		groups = append(groups, DuplicateGroup{
+			Fingerprint:    Fingerprint(txs[clusterStart]),
+			SupplierID:     txs[clusterStart].SupplierID,
+			TransactionIDs: ids,
+		})
+	}
+
+	for i := 1; i < len(txs); i++ {
+		if txs[i].Timestamp.Sub(txs[clusterStart].Timestamp) > window {
+			flush(i)
+			clusterStart = i
+		}
+	}
+	flush(len(txs))
+
+	return groups
+}
+
+// SeenStore records the most recent sighting of a fingerprinted economic
+// event, so FingerprintDuplicateDetector's idempotency survives across
+// separate Observe batches - e.g. one CSV file per run in a multi-run
+// pipeline - rather than only catching repeats within a single call.
+// Implementations must be safe for concurrent use.
+type SeenStore interface {
+	// Lookup returns the most recent timestamp recorded for key and whether
+	// any sighting has been recorded at all.
+	Lookup(key string) (time.Time, bool, error)
+	// Record stores ts as the most recent sighting of key, superseding
+	// whatever Lookup would have returned for it before.
+	Record(key string, ts time.Time) error
+}
+
+// InMemorySeenStore is a SeenStore backed by a map, suitable for a
+// single-process pipeline; a multi-process deployment wanting idempotency
+// across instances should implement SeenStore against Redis or a SQL table
+// instead.
+type InMemorySeenStore struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewInMemorySeenStore creates an empty store.
+func NewInMemorySeenStore() *InMemorySeenStore {
+	return &InMemorySeenStore{seen: make(map[string]time.Time)}
+}
+
+// Lookup returns the timestamp last recorded for key.
+func (s *InMemorySeenStore) Lookup(key string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts, ok := s.seen[key]
+	return ts, ok, nil
+}
+
+// Record stores ts as key's most recent sighting.
+func (s *InMemorySeenStore) Record(key string, ts time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen[key] = ts
+	return nil
+}
+
+// FingerprintDuplicateDetector flags a streamed transaction as a duplicate
+// if Store already has a sighting of the same economic event within Window,
+// mirroring DetectDuplicateByFingerprint's grouping logic one transaction at
+// a time instead of over a buffered slice - for Aggregator.FingerprintDetector
+// (wired in via Engine.ConfigureStreamingAnomalyDetection) and other
+// pipelines that can't hold the whole batch in memory.
+type FingerprintDuplicateDetector struct {
+	store      SeenStore
+	window     time.Duration
+	duplicates []string
+}
+
+// NewFingerprintDuplicateDetector creates a detector backed by store,
+// flagging economic events seen again within window. A nil store uses a
+// fresh InMemorySeenStore; window <= 0 means DefaultFingerprintWindow.
+func NewFingerprintDuplicateDetector(store SeenStore, window time.Duration) *FingerprintDuplicateDetector {
+	if store == nil {
+		store = NewInMemorySeenStore()
+	}
+	if window <= 0 {
+		window = DefaultFingerprintWindow
+	}
+	return &FingerprintDuplicateDetector{store: store, window: window}
+}
+
+// Observe records tx's economic event and reports whether it's a duplicate:
+// Store already holds a sighting of the same economic key within Window of
+// tx's timestamp. The store's sighting for this key is advanced to the
+// later of the two timestamps, so a chain of retries keeps extending the
+// window from whichever sighting is most recent rather than always
+// comparing back to the first.
+func (d *FingerprintDuplicateDetector) Observe(tx *domain.Transaction) (bool, error) {
+	key := economicKey(tx)
+
+	last, ok, err := d.store.Lookup(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up fingerprint seen-store entry: %w", err)
+	}
+
+	isDuplicate := ok && absDuration(tx.Timestamp.Sub(last)) <= d.window
+
+	latest := tx.Timestamp
+	if ok && last.After(latest) {
+		latest = last
+	}
+	if err := d.store.Record(key, latest); err != nil {
+		return false, fmt.Errorf("failed to record fingerprint seen-store entry: %w", err)
+	}
+
+	if isDuplicate {
+		d.duplicates = append(d.duplicates, tx.ID)
+	}
+	return isDuplicate, nil
+}
+
+// Duplicates returns every transaction ID Observe has confirmed a duplicate
+// so far, in the order each was confirmed.
+func (d *FingerprintDuplicateDetector) Duplicates() []string {
+	return d.duplicates
+}
+
+// absDuration returns d's absolute value.
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,127 @@
+package settlement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeFingerprintTx(id, supplierID string, amount float64, ts time.Time) *domain.Transaction {
+	return &domain.Transaction{
+		ID:             id,
+		SupplierID:     supplierID,
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(amount),
+		Currency:       domain.USD,
+		Timestamp:      ts,
+		Status:         domain.Completed,
+	}
+}
+
+func TestFingerprint_SameEventSameSecondMatches(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tx1 := makeFingerprintTx("tx1", "sup123", 100, base)
+	tx2 := makeFingerprintTx("tx2", "sup123", 100, base)
+
+	assert.Equal(t, Fingerprint(tx1), Fingerprint(tx2))
+}
+
+func TestFingerprint_DifferentAmountDiffers(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tx1 := makeFingerprintTx("tx1", "sup123", 100, base)
+	tx2 := makeFingerprintTx("tx2", "sup123", 101, base)
+
+	assert.NotEqual(t, Fingerprint(tx1), Fingerprint(tx2))
+}
+
+func TestDetectDuplicateByFingerprint_GroupsRetriesWithinWindow(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	original := makeFingerprintTx("tx1", "sup123", 100, base)
+	retry := makeFingerprintTx("tx2", "sup123", 100, base.Add(30*time.Second))
+
+	groups := DetectDuplicateByFingerprint([]*domain.Transaction{original, retry}, 60*time.Second)
+
+	require.Len(t, groups, 1)
+	assert.Equal(t, []string{"tx1", "tx2"}, groups[0].TransactionIDs)
+	assert.Equal(t, "tx1", groups[0].FirstSeenID())
+	assert.Equal(t, []string{"tx2"}, groups[0].DuplicateIDs())
+}
+
+func TestDetectDuplicateByFingerprint_OutsideWindowNotGrouped(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	original := makeFingerprintTx("tx1", "sup123", 100, base)
+	later := makeFingerprintTx("tx2", "sup123", 100, base.Add(2*time.Minute))
+
+	groups := DetectDuplicateByFingerprint([]*domain.Transaction{original, later}, 60*time.Second)
+
+	assert.Empty(t, groups)
+}
+
+func TestDetectDuplicateByFingerprint_DifferentSuppliersNotGrouped(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tx1 := makeFingerprintTx("tx1", "sup123", 100, base)
+	tx2 := makeFingerprintTx("tx2", "sup456", 100, base)
+
+	groups := DetectDuplicateByFingerprint([]*domain.Transaction{tx1, tx2}, 60*time.Second)
+
+	assert.Empty(t, groups)
+}
+
+func TestDetectDuplicateByFingerprint_DefaultWindowAppliedWhenZero(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	original := makeFingerprintTx("tx1", "sup123", 100, base)
+	retry := makeFingerprintTx("tx2", "sup123", 100, base.Add(59*time.Second))
+
+	groups := DetectDuplicateByFingerprint([]*domain.Transaction{original, retry}, 0)
+
+	require.Len(t, groups, 1)
+}
+
+func TestFingerprintDuplicateDetector_FlagsRetryWithinWindow(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	detector := NewFingerprintDuplicateDetector(nil, 60*time.Second)
+
+	isDup, err := detector.Observe(makeFingerprintTx("tx1", "sup123", 100, base))
+	require.NoError(t, err)
+	assert.False(t, isDup)
+
+	isDup, err = detector.Observe(makeFingerprintTx("tx2", "sup123", 100, base.Add(30*time.Second)))
+	require.NoError(t, err)
+	assert.True(t, isDup)
+
+	assert.Equal(t, []string{"tx2"}, detector.Duplicates())
+}
+
+func TestFingerprintDuplicateDetector_DoesNotFlagOutsideWindow(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	detector := NewFingerprintDuplicateDetector(nil, 60*time.Second)
+
+	_, err := detector.Observe(makeFingerprintTx("tx1", "sup123", 100, base))
+	require.NoError(t, err)
+
+	isDup, err := detector.Observe(makeFingerprintTx("tx2", "sup123", 100, base.Add(2*time.Minute)))
+	require.NoError(t, err)
+	assert.False(t, isDup)
+	assert.Empty(t, detector.Duplicates())
+}
+
+func TestFingerprintDuplicateDetector_SharedStoreSpansDetectors(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	store := NewInMemorySeenStore()
+
+	first := NewFingerprintDuplicateDetector(store, 60*time.Second)
+	_, err := first.Observe(makeFingerprintTx("tx1", "sup123", 100, base))
+	require.NoError(t, err)
+
+	// A second detector sharing store sees tx1's sighting even though it
+	// never Observed tx1 itself - this is what lets idempotency survive
+	// across separate CSV files in a multi-run pipeline.
+	second := NewFingerprintDuplicateDetector(store, 60*time.Second)
+	isDup, err := second.Observe(makeFingerprintTx("tx2", "sup123", 100, base.Add(30*time.Second)))
+	require.NoError(t, err)
+	assert.True(t, isDup)
+}
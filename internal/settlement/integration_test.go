@@ -37,7 +37,7 @@ tx005,sup789,capture,100000.00,COP,2024-01-18T16:30:00Z,completed`
 
 	// Step 4: Calculate settlements
 	fxProvider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(fxProvider)
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 	engine := NewEngine(fxService)
 
 	settlements, err := engine.Calculate(transactions)
@@ -72,6 +72,28 @@ tx005,sup789,capture,100000.00,COP,2024-01-18T16:30:00Z,completed`
 	assert.True(t, sup123Settlement.TotalCapturesUSD.GreaterThan(decimal.Zero))
 	assert.True(t, sup123Settlement.TotalRefundsUSD.GreaterThan(decimal.Zero))
 	assert.True(t, sup123Settlement.NetAmountUSD.GreaterThan(decimal.Zero))
+
+	// Step 7: Generate the double-entry journal and verify it balances per supplier
+	journalWriter := reporter.NewJournalWriter(reporter.JournalFormatNDJSON)
+	postings := journalWriter.Postings(settlements)
+
+	debitsBySupplier := make(map[string]decimal.Decimal)
+	creditsBySupplier := make(map[string]decimal.Decimal)
+	for _, p := range postings {
+		amount := decimal.NewFromFloat(p.USDAmount)
+		if p.Posting == "debit" {
+			debitsBySupplier[p.SupplierID] = debitsBySupplier[p.SupplierID].Add(amount)
+		} else {
+			creditsBySupplier[p.SupplierID] = creditsBySupplier[p.SupplierID].Add(amount)
+		}
+	}
+
+	for _, s := range settlements {
+		debits := debitsBySupplier[s.SupplierID]
+		credits := creditsBySupplier[s.SupplierID]
+		assert.True(t, debits.Sub(credits).Abs().LessThan(decimal.NewFromFloat(0.01)),
+			"supplier %s: debits %s should equal credits %s", s.SupplierID, debits, credits)
+	}
 }
 
 // TestEdgeCases tests various edge cases
@@ -87,7 +109,7 @@ tx003,sup123,capture,30.00,USD,2024-01-17T11:45:00Z,failed`
 		require.NoError(t, err)
 
 		fxProvider := fxrate.NewMockProvider()
-		fxService := fxrate.NewService(fxProvider)
+		fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 		engine := NewEngine(fxService)
 
 		settlements, err := engine.Calculate(transactions)
@@ -109,7 +131,7 @@ tx002,sup123,refund,50.00,USD,2024-01-16T14:20:00Z,completed`
 		require.NoError(t, err)
 
 		fxProvider := fxrate.NewMockProvider()
-		fxService := fxrate.NewService(fxProvider)
+		fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 		engine := NewEngine(fxService)
 
 		settlements, err := engine.Calculate(transactions)
@@ -122,7 +144,7 @@ tx002,sup123,refund,50.00,USD,2024-01-16T14:20:00Z,completed`
 
 	t.Run("empty transaction list", func(t *testing.T) {
 		fxProvider := fxrate.NewMockProvider()
-		fxService := fxrate.NewService(fxProvider)
+		fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 		engine := NewEngine(fxService)
 
 		settlements, err := engine.Calculate([]*domain.Transaction{})
@@ -141,7 +163,7 @@ tx003,sup003,capture,300.00,USD,2024-01-17T11:45:00Z,completed`
 		require.NoError(t, err)
 
 		fxProvider := fxrate.NewMockProvider()
-		fxService := fxrate.NewService(fxProvider)
+		fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 		engine := NewEngine(fxService)
 
 		settlements, err := engine.Calculate(transactions)
@@ -157,7 +179,7 @@ tx003,sup003,capture,300.00,USD,2024-01-17T11:45:00Z,completed`
 // TestMultiCurrencyConversion tests FX conversion accuracy
 func TestMultiCurrencyConversion(t *testing.T) {
 	fxProvider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(fxProvider)
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 	engine := NewEngine(fxService)
 
 	validTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -226,7 +248,7 @@ func TestMultiCurrencyConversion(t *testing.T) {
 // TestDateSpecificRates tests that different dates produce different rates
 func TestDateSpecificRates(t *testing.T) {
 	fxProvider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(fxProvider)
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 	engine := NewEngine(fxService)
 
 	day1 := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
@@ -294,7 +316,7 @@ tx011,despegar,capture,75000.00,ARS,2024-01-22T11:10:00Z,completed`
 
 	// Calculate
 	fxProvider := fxrate.NewMockProvider()
-	fxService := fxrate.NewService(fxProvider)
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
 	engine := NewEngine(fxService)
 
 	settlements, err := engine.Calculate(transactions)
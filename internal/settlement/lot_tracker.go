@@ -0,0 +1,125 @@
+package settlement
+
+import (
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// openLot is a single capture's unconsumed balance, costed at the FX rate in
+// effect when the capture settled.
+type openLot struct {
+	captureID string
+	openRate  decimal.Decimal
+	remaining decimal.Decimal
+}
+
+// LotTracker assigns FIFO cost-basis to non-USD captures and refunds, per
+// supplier and currency, so realized and unrealized FX gains can be reported
+// the way a brokerage tracks FIFO-by-ISIN tax lots: each Capture opens a lot
+// at that day's FX rate, and each Refund draws down the oldest open lot(s)
+// first, realizing the FX movement between the two rates.
+//
+// Lots are indexed by supplier first so OpenCurrencies can look up a
+// supplier's open currencies in constant time rather than scanning every
+// supplier's lots on every call.
+type LotTracker struct {
+	lots map[string]map[domain.Currency][]*openLot
+}
+
+// NewLotTracker creates an empty LotTracker.
+func NewLotTracker() *LotTracker {
+	return &LotTracker{lots: make(map[string]map[domain.Currency][]*openLot)}
+}
+
+// OpenLot records a non-USD Capture as a new FIFO lot for supplierID and
+// currency, costed at fxRate.
+func (t *LotTracker) OpenLot(supplierID string, currency domain.Currency, captureID string, nativeAmount, fxRate decimal.Decimal) {
+	if t.lots[supplierID] == nil {
+		t.lots[supplierID] = make(map[domain.Currency][]*openLot)
+	}
+	t.lots[supplierID][currency] = append(t.lots[supplierID][currency], &openLot{
+		captureID: captureID,
+		openRate:  fxRate,
+		remaining: nativeAmount,
+	})
+}
+
+// ConsumeRefund draws nativeAmount down from supplierID's oldest open lots
+// for currency, splitting across lots when the refund exceeds the oldest
+// lot's remaining balance. It returns one LotSlice per lot drawn from, with
+// GainUSD computed as (refundRate - lot's open rate) * amount consumed from
+// that lot, rounded to USD's minor-unit precision. underflow is true when
+// the refund exceeds the supplier's total open balance in that currency, in
+// which case the excess is left unconsumed (no lot to charge it against).
+func (t *LotTracker) ConsumeRefund(supplierID string, currency domain.Currency, nativeAmount, refundRate decimal.Decimal) (slices []domain.LotSlice, underflow bool) {
+	open := t.lots[supplierID][currency]
+
+	remaining := nativeAmount
+	consumed := 0
+	for _, lot := range open {
+		if remaining.IsZero() {
+			break
+		}
+
+		take := lot.remaining
+		if take.GreaterThan(remaining) {
+			take = remaining
+		}
+
+		gain := refundRate.Sub(lot.openRate).Mul(take).RoundBank(domain.USD.Exponent())
+		slices = append(slices, domain.LotSlice{
+			CaptureID:            lot.captureID,
+			ConsumedNativeAmount: take,
+			LotOpenFXRate:        lot.openRate,
+			RefundFXRate:         refundRate,
+			GainUSD:              gain,
+		})
+
+		lot.remaining = lot.remaining.Sub(take)
+		remaining = remaining.Sub(take)
+		if lot.remaining.IsZero() {
+			consumed++
+		}
+	}
+
+	if t.lots[supplierID] != nil {
+		t.lots[supplierID][currency] = open[consumed:]
+	}
+
+	return slices, remaining.IsPositive()
+}
+
+// RemainingBalance returns the total native-currency amount still open
+// across supplierID's lots in currency.
+func (t *LotTracker) RemainingBalance(supplierID string, currency domain.Currency) decimal.Decimal {
+	total := decimal.Zero
+	for _, lot := range t.lots[supplierID][currency] {
+		total = total.Add(lot.remaining)
+	}
+	return total
+}
+
+// UnrealizedGainUSD marks every lot still open for supplierID/currency to
+// currentRate, returning the total USD gain/loss if they were refunded today
+// at that rate.
+func (t *LotTracker) UnrealizedGainUSD(supplierID string, currency domain.Currency, currentRate decimal.Decimal) decimal.Decimal {
+	gain := decimal.Zero
+	for _, lot := range t.lots[supplierID][currency] {
+		gain = gain.Add(currentRate.Sub(lot.openRate).Mul(lot.remaining).RoundBank(domain.USD.Exponent()))
+	}
+	return gain
+}
+
+// OpenCurrencies returns the distinct currencies in which supplierID still
+// holds an open (non-fully-consumed) lot balance, so callers can mark those
+// to market without scanning the whole currency registry.
+func (t *LotTracker) OpenCurrencies(supplierID string) []domain.Currency {
+	var currencies []domain.Currency
+	for currency, lots := range t.lots[supplierID] {
+		if len(lots) == 0 {
+			continue
+		}
+		currencies = append(currencies, currency)
+	}
+	return currencies
+}
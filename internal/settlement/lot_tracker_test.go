@@ -0,0 +1,97 @@
+package settlement
+
+import (
+	"testing"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLotTracker(t *testing.T) {
+	tracker := NewLotTracker()
+	assert.NotNil(t, tracker)
+}
+
+func TestLotTracker_ConsumeRefund_SingleLotPartialConsumption(t *testing.T) {
+	tracker := NewLotTracker()
+	tracker.OpenLot("sup1", domain.ARS, "cap1", decimal.NewFromInt(1000), decimal.NewFromFloat(0.0010))
+
+	slices, underflow := tracker.ConsumeRefund("sup1", domain.ARS, decimal.NewFromInt(400), decimal.NewFromFloat(0.0012))
+
+	require.False(t, underflow)
+	require.Len(t, slices, 1)
+	assert.Equal(t, "cap1", slices[0].CaptureID)
+	assert.True(t, slices[0].ConsumedNativeAmount.Equal(decimal.NewFromInt(400)))
+	// gain = (0.0012 - 0.0010) * 400 = 0.08
+	assert.True(t, slices[0].GainUSD.Equal(decimal.NewFromFloat(0.08)), "got %s", slices[0].GainUSD)
+
+	assert.True(t, tracker.RemainingBalance("sup1", domain.ARS).Equal(decimal.NewFromInt(600)))
+}
+
+func TestLotTracker_ConsumeRefund_SplitsAcrossMultipleLotsFIFO(t *testing.T) {
+	tracker := NewLotTracker()
+	tracker.OpenLot("sup1", domain.BRL, "cap1", decimal.NewFromInt(100), decimal.NewFromFloat(0.20))
+	tracker.OpenLot("sup1", domain.BRL, "cap2", decimal.NewFromInt(100), decimal.NewFromFloat(0.22))
+
+	slices, underflow := tracker.ConsumeRefund("sup1", domain.BRL, decimal.NewFromInt(150), decimal.NewFromFloat(0.25))
+
+	require.False(t, underflow)
+	require.Len(t, slices, 2)
+
+	// The oldest lot (cap1) is drawn down first and fully consumed.
+	assert.Equal(t, "cap1", slices[0].CaptureID)
+	assert.True(t, slices[0].ConsumedNativeAmount.Equal(decimal.NewFromInt(100)))
+
+	// The remainder comes from the second lot (cap2).
+	assert.Equal(t, "cap2", slices[1].CaptureID)
+	assert.True(t, slices[1].ConsumedNativeAmount.Equal(decimal.NewFromInt(50)))
+
+	assert.True(t, tracker.RemainingBalance("sup1", domain.BRL).Equal(decimal.NewFromInt(50)))
+}
+
+func TestLotTracker_ConsumeRefund_UnderflowWhenExceedingOpenBalance(t *testing.T) {
+	tracker := NewLotTracker()
+	tracker.OpenLot("sup1", domain.COP, "cap1", decimal.NewFromInt(100), decimal.NewFromFloat(0.00025))
+
+	slices, underflow := tracker.ConsumeRefund("sup1", domain.COP, decimal.NewFromInt(150), decimal.NewFromFloat(0.00026))
+
+	assert.True(t, underflow)
+	require.Len(t, slices, 1)
+	assert.True(t, slices[0].ConsumedNativeAmount.Equal(decimal.NewFromInt(100)))
+	assert.True(t, tracker.RemainingBalance("sup1", domain.COP).IsZero())
+}
+
+func TestLotTracker_ConsumeRefund_PartitionsBySupplierAndCurrency(t *testing.T) {
+	tracker := NewLotTracker()
+	tracker.OpenLot("sup1", domain.ARS, "cap1", decimal.NewFromInt(100), decimal.NewFromFloat(0.001))
+
+	// A refund for a different supplier or currency must not draw from sup1's ARS lot.
+	_, underflowOtherSupplier := tracker.ConsumeRefund("sup2", domain.ARS, decimal.NewFromInt(50), decimal.NewFromFloat(0.001))
+	_, underflowOtherCurrency := tracker.ConsumeRefund("sup1", domain.MXN, decimal.NewFromInt(50), decimal.NewFromFloat(0.05))
+
+	assert.True(t, underflowOtherSupplier)
+	assert.True(t, underflowOtherCurrency)
+	assert.True(t, tracker.RemainingBalance("sup1", domain.ARS).Equal(decimal.NewFromInt(100)))
+}
+
+func TestLotTracker_UnrealizedGainUSD_MarksOpenLotsToCurrentRate(t *testing.T) {
+	tracker := NewLotTracker()
+	tracker.OpenLot("sup1", domain.MXN, "cap1", decimal.NewFromInt(100), decimal.NewFromFloat(0.05))
+
+	gain := tracker.UnrealizedGainUSD("sup1", domain.MXN, decimal.NewFromFloat(0.06))
+	assert.True(t, gain.Equal(decimal.NewFromFloat(1)), "got %s", gain)
+}
+
+func TestLotTracker_OpenCurrencies_OmitsFullyConsumedLots(t *testing.T) {
+	tracker := NewLotTracker()
+	tracker.OpenLot("sup1", domain.ARS, "cap1", decimal.NewFromInt(100), decimal.NewFromFloat(0.001))
+	tracker.OpenLot("sup1", domain.BRL, "cap2", decimal.NewFromInt(100), decimal.NewFromFloat(0.20))
+
+	_, underflow := tracker.ConsumeRefund("sup1", domain.ARS, decimal.NewFromInt(100), decimal.NewFromFloat(0.001))
+	require.False(t, underflow)
+
+	currencies := tracker.OpenCurrencies("sup1")
+	assert.ElementsMatch(t, []domain.Currency{domain.BRL}, currencies)
+}
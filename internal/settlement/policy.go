@@ -0,0 +1,216 @@
+package settlement
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultRefundRateThresholdPct and DefaultFXVarianceThresholdPct are the
+// thresholds DetectHighRefundRate and DetectVolatilityForSettlement used
+// before AnomalyPolicy existed, kept as the defaults DefaultAnomalyPolicy
+// builds so a nil policy behaves exactly as the old hardcoded checks did.
+// DefaultChargebackRateThresholdPct is the equivalent default for
+// DetectHighChargebackRate; chargebacks carry scheme penalties even at low
+// volume, so its default is tighter than the refund-rate one.
+// DefaultFXRateCVThresholdPct and DefaultAmountZScoreThreshold are the
+// defaults FXRateCVDetector and AmountZScoreDetector use when a policy
+// leaves their threshold at its zero value: AnomalyFXRateCV and
+// AnomalyAmountZScoreOutlier existed as constants before either detector
+// did, so there's no prior hardcoded behavior to preserve the way the three
+// above do - these are simply the thresholds that seemed reasonable to ship.
+const (
+	DefaultRefundRateThresholdPct     = 20
+	DefaultFXVarianceThresholdPct     = 5
+	DefaultChargebackRateThresholdPct = 1
+	DefaultFXRateCVThresholdPct       = 5
+	DefaultAmountZScoreThreshold      = 3
+)
+
+// AnomalyPolicy configures the thresholds Engine's anomaly checks flag
+// against, with optional per-supplier and per-currency overrides layered on
+// top of a run-wide default (e.g. a 10% refund-rate threshold for a
+// high-risk supplier, or an 8% FX variance threshold for a more volatile
+// LATAM currency). A nil *AnomalyPolicy anywhere one is accepted
+// (Engine.Policy, DetectHighRefundRate, CalculateVolatility, ...) is
+// shorthand for DefaultAnomalyPolicy.
+type AnomalyPolicy struct {
+	// RefundRateThresholdPct is the refund-rate-over-captures percentage that
+	// triggers AnomalyHighRefundRate for any supplier without an entry in
+	// SupplierRefundRateOverrides. Zero (including an unset field when
+	// loaded from JSON) means DefaultRefundRateThresholdPct.
+	RefundRateThresholdPct decimal.Decimal `json:"refund_rate_threshold_pct"`
+
+	// FXVarianceThresholdPct is the auth/capture FX rate variance percentage
+	// that triggers AnomalyVolatility for any currency without an entry in
+	// CurrencyFXVarianceOverrides. Zero (including an unset field when
+	// loaded from JSON) means DefaultFXVarianceThresholdPct.
+	FXVarianceThresholdPct decimal.Decimal `json:"fx_variance_threshold_pct"`
+
+	// ChargebackRateThresholdPct is the chargeback-rate-over-captures
+	// percentage that triggers AnomalyHighChargebackRate for any supplier
+	// without an entry in SupplierChargebackRateOverrides. Zero (including an
+	// unset field when loaded from JSON) means DefaultChargebackRateThresholdPct.
+	ChargebackRateThresholdPct decimal.Decimal `json:"chargeback_rate_threshold_pct"`
+
+	// SupplierRefundRateOverrides replaces RefundRateThresholdPct for specific
+	// suppliers, keyed by SupplierID.
+	SupplierRefundRateOverrides map[string]decimal.Decimal `json:"supplier_refund_rate_overrides,omitempty"`
+
+	// CurrencyFXVarianceOverrides replaces FXVarianceThresholdPct for specific
+	// currencies. An entry is used as is: unlike the top-level field, a zero
+	// entry is not defaulted, so every nonzero variance in that currency is
+	// flagged.
+	CurrencyFXVarianceOverrides map[domain.Currency]decimal.Decimal `json:"currency_fx_variance_overrides,omitempty"`
+
+	// SupplierChargebackRateOverrides replaces ChargebackRateThresholdPct for
+	// specific suppliers, keyed by SupplierID.
+	SupplierChargebackRateOverrides map[string]decimal.Decimal `json:"supplier_chargeback_rate_overrides,omitempty"`
+
+	// FXRateCVThresholdPct is the coefficient-of-variation percentage
+	// (standard deviation over mean of a currency's capture FX rates within
+	// a settlement window) that triggers AnomalyFXRateCV for any currency
+	// without an entry in CurrencyFXRateCVOverrides. Zero (including an
+	// unset field when loaded from JSON) means DefaultFXRateCVThresholdPct.
+	// See FXRateCVDetector.
+	FXRateCVThresholdPct decimal.Decimal `json:"fx_rate_cv_threshold_pct"`
+
+	// CurrencyFXRateCVOverrides replaces FXRateCVThresholdPct for specific
+	// currencies.
+	CurrencyFXRateCVOverrides map[domain.Currency]decimal.Decimal `json:"currency_fx_rate_cv_overrides,omitempty"`
+
+	// AmountZScoreThresholdStdDevs is how many standard deviations a
+	// capture's USD amount must deviate from the supplier's own captures
+	// before AmountZScoreDetector flags it as AnomalyAmountZScoreOutlier.
+	// Zero (including an unset field when loaded from JSON) means
+	// DefaultAmountZScoreThreshold.
+	AmountZScoreThresholdStdDevs decimal.Decimal `json:"amount_zscore_threshold_std_devs"`
+}
+
+// DefaultAnomalyPolicy returns the policy matching the thresholds the engine
+// used before AnomalyPolicy existed (20% refund rate, 5% FX variance), plus
+// the defaults for the detectors added since, with no per-supplier or
+// per-currency overrides.
+func DefaultAnomalyPolicy() *AnomalyPolicy {
+	return &AnomalyPolicy{
+		RefundRateThresholdPct:       decimal.NewFromInt(DefaultRefundRateThresholdPct),
+		FXVarianceThresholdPct:       decimal.NewFromInt(DefaultFXVarianceThresholdPct),
+		ChargebackRateThresholdPct:   decimal.NewFromInt(DefaultChargebackRateThresholdPct),
+		FXRateCVThresholdPct:         decimal.NewFromInt(DefaultFXRateCVThresholdPct),
+		AmountZScoreThresholdStdDevs: decimal.NewFromInt(DefaultAmountZScoreThreshold),
+	}
+}
+
+// effectivePolicy returns policy with its top-level thresholds defaulted:
+// DefaultAnomalyPolicy() if policy is nil, and for a non-nil policy, any of
+// its five top-level thresholds left at its zero value (e.g. a
+// PolicyProvider's JSON omitted it) is filled in from the matching Default*
+// constant. Per-supplier and per-currency overrides are left as they are.
+// This lets every anomaly check - and a policy loaded from a partial JSON
+// document - treat zero as "use the default" rather than "flag everything",
+// and lets a *AnomalyPolicy{SupplierRefundRateOverrides: ...} literal skip
+// restating the defaults for fields it doesn't want to override.
+func effectivePolicy(policy *AnomalyPolicy) *AnomalyPolicy {
+	if policy == nil {
+		return DefaultAnomalyPolicy()
+	}
+
+	resolved := *policy
+	if resolved.RefundRateThresholdPct.IsZero() {
+		resolved.RefundRateThresholdPct = decimal.NewFromInt(DefaultRefundRateThresholdPct)
+	}
+	if resolved.FXVarianceThresholdPct.IsZero() {
+		resolved.FXVarianceThresholdPct = decimal.NewFromInt(DefaultFXVarianceThresholdPct)
+	}
+	if resolved.ChargebackRateThresholdPct.IsZero() {
+		resolved.ChargebackRateThresholdPct = decimal.NewFromInt(DefaultChargebackRateThresholdPct)
+	}
+	if resolved.FXRateCVThresholdPct.IsZero() {
+		resolved.FXRateCVThresholdPct = decimal.NewFromInt(DefaultFXRateCVThresholdPct)
+	}
+	if resolved.AmountZScoreThresholdStdDevs.IsZero() {
+		resolved.AmountZScoreThresholdStdDevs = decimal.NewFromInt(DefaultAmountZScoreThreshold)
+	}
+	return &resolved
+}
+
+// RefundRateThreshold returns the refund-rate threshold for supplierID: its
+// entry in SupplierRefundRateOverrides if present, else RefundRateThresholdPct.
+func (p *AnomalyPolicy) RefundRateThreshold(supplierID string) decimal.Decimal {
+	if override, ok := p.SupplierRefundRateOverrides[supplierID]; ok {
+		return override
+	}
+	return p.RefundRateThresholdPct
+}
+
+// FXVarianceThreshold returns the FX variance threshold for currency: its
+// entry in CurrencyFXVarianceOverrides if present, else FXVarianceThresholdPct.
+func (p *AnomalyPolicy) FXVarianceThreshold(currency domain.Currency) decimal.Decimal {
+	if override, ok := p.CurrencyFXVarianceOverrides[currency]; ok {
+		return override
+	}
+	return p.FXVarianceThresholdPct
+}
+
+// ChargebackRateThreshold returns the chargeback-rate threshold for
+// supplierID: its entry in SupplierChargebackRateOverrides if present, else
+// ChargebackRateThresholdPct.
+func (p *AnomalyPolicy) ChargebackRateThreshold(supplierID string) decimal.Decimal {
+	if override, ok := p.SupplierChargebackRateOverrides[supplierID]; ok {
+		return override
+	}
+	return p.ChargebackRateThresholdPct
+}
+
+// FXRateCVThreshold returns the FX rate coefficient-of-variation threshold
+// for currency: its entry in CurrencyFXRateCVOverrides if present, else
+// FXRateCVThresholdPct.
+func (p *AnomalyPolicy) FXRateCVThreshold(currency domain.Currency) decimal.Decimal {
+	if override, ok := p.CurrencyFXRateCVOverrides[currency]; ok {
+		return override
+	}
+	return p.FXRateCVThresholdPct
+}
+
+// AmountZScoreThreshold returns the standard-deviation threshold
+// AmountZScoreDetector flags a capture's USD amount against.
+func (p *AnomalyPolicy) AmountZScoreThreshold() decimal.Decimal {
+	return p.AmountZScoreThresholdStdDevs
+}
+
+// PolicyProvider loads an AnomalyPolicy from some external source - a file,
+// an environment variable, a remote config service - so Engine's thresholds
+// can change without a code change or restart. See Engine.WatchPolicy.
+type PolicyProvider interface {
+	Load() (*AnomalyPolicy, error)
+}
+
+// FilePolicyProvider loads an AnomalyPolicy from a JSON file at Path, in the
+// shape AnomalyPolicy's json tags describe. Re-reading the file (e.g. via
+// Engine.WatchPolicy) picks up whatever was last written to it.
+type FilePolicyProvider struct {
+	Path string
+}
+
+// NewFilePolicyProvider creates a provider that loads its policy from the
+// JSON file at path.
+func NewFilePolicyProvider(path string) *FilePolicyProvider {
+	return &FilePolicyProvider{Path: path}
+}
+
+// Load reads and parses the policy file.
+func (p *FilePolicyProvider) Load() (*AnomalyPolicy, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy AnomalyPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", p.Path, err)
+	}
+	return &policy, nil
+}
@@ -0,0 +1,81 @@
+package settlement
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultAnomalyPolicy_MatchesOldHardcodedThresholds(t *testing.T) {
+	policy := DefaultAnomalyPolicy()
+
+	assert.True(t, policy.RefundRateThreshold("any-supplier").Equal(decimal.NewFromInt(20)))
+	assert.True(t, policy.FXVarianceThreshold(domain.BRL).Equal(decimal.NewFromInt(5)))
+	assert.True(t, policy.ChargebackRateThreshold("any-supplier").Equal(decimal.NewFromInt(1)))
+}
+
+func TestAnomalyPolicy_ChargebackRateThreshold_UsesSupplierOverride(t *testing.T) {
+	policy := DefaultAnomalyPolicy()
+	policy.SupplierChargebackRateOverrides = map[string]decimal.Decimal{
+		"high-risk-supplier": decimal.NewFromInt(5),
+	}
+
+	assert.True(t, policy.ChargebackRateThreshold("high-risk-supplier").Equal(decimal.NewFromInt(5)))
+	assert.True(t, policy.ChargebackRateThreshold("other-supplier").Equal(decimal.NewFromInt(1)))
+}
+
+func TestAnomalyPolicy_RefundRateThreshold_UsesSupplierOverride(t *testing.T) {
+	policy := DefaultAnomalyPolicy()
+	policy.SupplierRefundRateOverrides = map[string]decimal.Decimal{
+		"high-risk-supplier": decimal.NewFromInt(10),
+	}
+
+	assert.True(t, policy.RefundRateThreshold("high-risk-supplier").Equal(decimal.NewFromInt(10)))
+	assert.True(t, policy.RefundRateThreshold("other-supplier").Equal(decimal.NewFromInt(20)))
+}
+
+func TestAnomalyPolicy_FXVarianceThreshold_UsesCurrencyOverride(t *testing.T) {
+	policy := DefaultAnomalyPolicy()
+	policy.CurrencyFXVarianceOverrides = map[domain.Currency]decimal.Decimal{
+		domain.ARS: decimal.NewFromInt(8),
+	}
+
+	assert.True(t, policy.FXVarianceThreshold(domain.ARS).Equal(decimal.NewFromInt(8)))
+	assert.True(t, policy.FXVarianceThreshold(domain.BRL).Equal(decimal.NewFromInt(5)))
+}
+
+func TestEffectivePolicy_NilFallsBackToDefault(t *testing.T) {
+	policy := effectivePolicy(nil)
+
+	assert.True(t, policy.RefundRateThreshold("sup123").Equal(decimal.NewFromInt(20)))
+}
+
+func TestFilePolicyProvider_LoadsOverridesFromJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"refund_rate_threshold_pct": "20",
+		"fx_variance_threshold_pct": "5",
+		"supplier_refund_rate_overrides": {"sup-risky": "10"},
+		"currency_fx_variance_overrides": {"ARS": "8"}
+	}`), 0644))
+
+	provider := NewFilePolicyProvider(path)
+	policy, err := provider.Load()
+
+	require.NoError(t, err)
+	assert.True(t, policy.RefundRateThreshold("sup-risky").Equal(decimal.NewFromInt(10)))
+	assert.True(t, policy.FXVarianceThreshold(domain.ARS).Equal(decimal.NewFromInt(8)))
+}
+
+func TestFilePolicyProvider_Load_ReturnsErrorOnMissingFile(t *testing.T) {
+	provider := NewFilePolicyProvider(filepath.Join(t.TempDir(), "does-not-exist.json"))
+
+	_, err := provider.Load()
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package settlement
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeCaptureLine(id string, currency domain.Currency, fxRate, usdAmount float64, ts time.Time) domain.SettlementLine {
+	return domain.SettlementLine{
+		Transaction: &domain.Transaction{
+			ID:         id,
+			SupplierID: "sup123",
+			Type:       domain.Capture,
+			Currency:   currency,
+			Timestamp:  ts,
+			Status:     domain.Completed,
+		},
+		FXRate:    decimal.NewFromFloat(fxRate),
+		USDAmount: decimal.NewFromFloat(usdAmount),
+	}
+}
+
+func TestRegistry_Detect_RunsDetectorsInOrderAndConcatenates(t *testing.T) {
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.NetAmountUSD = decimal.NewFromInt(-5)
+
+	registry := NewRegistry(NegativeNetDetector{}, NegativeNetDetector{})
+	anomalies := registry.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+
+	assert.Len(t, anomalies, 2, "both registered detectors should fire independently")
+	for _, a := range anomalies {
+		assert.Equal(t, AnomalyNegativeNet, a.Rule)
+	}
+}
+
+func TestHighRefundRateDetector_FlagsOverThreshold(t *testing.T) {
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.TotalCapturesUSD = decimal.NewFromInt(100)
+	settlement.TotalRefundsUSD = decimal.NewFromInt(30)
+
+	anomalies := HighRefundRateDetector{}.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalyHighRefundRate, anomalies[0].Rule)
+	assert.True(t, anomalies[0].Observed.Equal(decimal.NewFromInt(30)))
+}
+
+func TestOrphanedRefundDetector_UsesPrecomputedIDs(t *testing.T) {
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+
+	noOrphans := OrphanedRefundDetector{}.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+	assert.Empty(t, noOrphans)
+
+	withOrphans := OrphanedRefundDetector{}.Detect(context.Background(), &SettlementContext{
+		Settlement:      settlement,
+		OrphanRefundIDs: []string{"refund1", "refund2"},
+	})
+	assert.Len(t, withOrphans, 1)
+	assert.Equal(t, AnomalyOrphanedRefund, withOrphans[0].Rule)
+	assert.Equal(t, []string{"refund1", "refund2"}, withOrphans[0].TransactionIDs)
+}
+
+func TestFXRateCVDetector_FlagsHighVarianceCurrency(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(makeCaptureLine("cap1", domain.BRL, 0.18, 100, base))
+	settlement.AddLine(makeCaptureLine("cap2", domain.BRL, 0.22, 100, base.Add(time.Hour)))
+	settlement.AddLine(makeCaptureLine("cap3", domain.BRL, 0.30, 100, base.Add(2*time.Hour)))
+
+	anomalies := FXRateCVDetector{}.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalyFXRateCV, anomalies[0].Rule)
+	assert.Len(t, anomalies[0].TransactionIDs, 3)
+}
+
+func TestFXRateCVDetector_SkipsCurrencyWithOneCapture(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(makeCaptureLine("cap1", domain.BRL, 0.18, 100, base))
+
+	anomalies := FXRateCVDetector{}.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+
+	assert.Empty(t, anomalies)
+}
+
+func TestFXRateCVDetector_StableRateDoesNotFlag(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(makeCaptureLine("cap1", domain.BRL, 0.20, 100, base))
+	settlement.AddLine(makeCaptureLine("cap2", domain.BRL, 0.201, 100, base.Add(time.Hour)))
+
+	anomalies := FXRateCVDetector{}.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+
+	assert.Empty(t, anomalies)
+}
+
+func TestAmountZScoreDetector_FlagsOutlierCapture(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	for i := 0; i < 19; i++ {
+		settlement.AddLine(makeCaptureLine(fmt.Sprintf("cap%d", i), domain.USD, 1, 100, base.Add(time.Duration(i)*time.Hour)))
+	}
+	settlement.AddLine(makeCaptureLine("outlier", domain.USD, 1, 100000, base.Add(20*time.Hour)))
+
+	anomalies := AmountZScoreDetector{}.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+
+	assert.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalyAmountZScoreOutlier, anomalies[0].Rule)
+	assert.Equal(t, []string{"outlier"}, anomalies[0].TransactionIDs)
+}
+
+func TestAmountZScoreDetector_UniformAmountsDoNotFlag(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(makeCaptureLine("cap1", domain.USD, 1, 100, base))
+	settlement.AddLine(makeCaptureLine("cap2", domain.USD, 1, 100, base.Add(time.Hour)))
+
+	anomalies := AmountZScoreDetector{}.Detect(context.Background(), &SettlementContext{Settlement: settlement})
+
+	assert.Empty(t, anomalies)
+}
+
+func TestDefaultRegistry_OnlyRunsStatisticalDetectors(t *testing.T) {
+	registry := DefaultRegistry()
+
+	var names []string
+	for _, d := range registry.Detectors() {
+		names = append(names, d.Name())
+	}
+	assert.Equal(t, []string{AnomalyFXRateCV, AnomalyAmountZScoreOutlier}, names)
+}
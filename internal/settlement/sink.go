@@ -0,0 +1,134 @@
+package settlement
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AnomalyEvent is a structured record of a single anomaly-detection rule
+// firing for a supplier: which rule, the value observed, the threshold it
+// crossed, and the transactions that triggered it. Engine emits one to
+// AnomalySink the first time detectAnomalies appends a rule's warning code
+// for a supplier (for every rule except AnomalyNegativeNet), so
+// operators can wire alerts off of it without parsing warning strings out of
+// SupplierSettlement.Warnings.
+type AnomalyEvent struct {
+	SupplierID     string          `json:"supplier_id"`
+	Rule           string          `json:"rule"` // One of the Anomaly* constants in anomaly.go
+	Observed       decimal.Decimal `json:"observed"`
+	Threshold      decimal.Decimal `json:"threshold"`
+	TransactionIDs []string        `json:"transaction_ids,omitempty"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
+// AnomalySink receives AnomalyEvents as Engine's anomaly checks fire.
+// Engine treats delivery as best-effort: an Emit error is logged and
+// otherwise ignored, since a sink outage shouldn't fail settlement
+// calculation. Emit is called synchronously on the settlement path (inside
+// Calculate/CalculateIncremental/CalculateStream), once per supplier whose
+// settlement newly triggers a rule, so a slow sink (e.g. WebhookAnomalySink
+// against an unresponsive endpoint) adds that latency to settlement
+// calculation rather than just to alert delivery. A sink wanting to decouple
+// the two should queue events internally and return from Emit immediately.
+type AnomalySink interface {
+	Emit(event AnomalyEvent) error
+}
+
+// StdoutAnomalySink writes each AnomalyEvent as a JSON line to os.Stdout.
+type StdoutAnomalySink struct{}
+
+// NewStdoutAnomalySink creates a sink that writes to stdout.
+func NewStdoutAnomalySink() *StdoutAnomalySink {
+	return &StdoutAnomalySink{}
+}
+
+// Emit writes event to os.Stdout as a single line of JSON.
+func (s *StdoutAnomalySink) Emit(event AnomalyEvent) error {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode anomaly event: %w", err)
+	}
+	_, err = fmt.Println(string(encoded))
+	return err
+}
+
+// FileAnomalySink appends each AnomalyEvent as a JSON line to the file at
+// Path, creating it if it doesn't already exist.
+type FileAnomalySink struct {
+	Path string
+}
+
+// NewFileAnomalySink creates a sink that appends to the file at path.
+func NewFileAnomalySink(path string) *FileAnomalySink {
+	return &FileAnomalySink{Path: path}
+}
+
+// Emit appends event to the sink's file as a single line of JSON.
+func (s *FileAnomalySink) Emit(event AnomalyEvent) error {
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open anomaly sink file: %w", err)
+	}
+	defer file.Close()
+
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode anomaly event: %w", err)
+	}
+
+	_, err = file.Write(append(encoded, '\n'))
+	return err
+}
+
+// DefaultWebhookTimeout bounds how long WebhookAnomalySink.Emit waits for a
+// response, used when WebhookAnomalySink.Client isn't set. detectAnomalies
+// calls Emit synchronously on the settlement path, so an unbounded client
+// (e.g. http.DefaultClient, which has no timeout) would let an unresponsive
+// webhook stall Calculate/CalculateIncremental/CalculateStream indefinitely.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookAnomalySink POSTs each AnomalyEvent as a JSON body to URL.
+type WebhookAnomalySink struct {
+	URL string
+
+	// Client is the http.Client used to deliver events. Nil builds one with
+	// DefaultWebhookTimeout.
+	Client *http.Client
+}
+
+// NewWebhookAnomalySink creates a sink that POSTs events to url, bounded by
+// DefaultWebhookTimeout.
+func NewWebhookAnomalySink(url string) *WebhookAnomalySink {
+	return &WebhookAnomalySink{URL: url}
+}
+
+// Emit POSTs event to the sink's URL as a JSON body, returning an error if
+// the request fails or the response status isn't 2xx.
+func (s *WebhookAnomalySink) Emit(event AnomalyEvent) error {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode anomaly event: %w", err)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+
+	resp, err := client.Post(s.URL, "application/json", bytes.NewReader(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to deliver anomaly event to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package settlement
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAnomalyEvent() AnomalyEvent {
+	return AnomalyEvent{
+		SupplierID:     "sup123",
+		Rule:           AnomalyHighRefundRate,
+		Observed:       decimal.NewFromInt(25),
+		Threshold:      decimal.NewFromInt(20),
+		TransactionIDs: []string{"tx001", "tx002"},
+	}
+}
+
+func TestFileAnomalySink_AppendsJSONLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anomalies.jsonl")
+	sink := NewFileAnomalySink(path)
+
+	require.NoError(t, sink.Emit(testAnomalyEvent()))
+	require.NoError(t, sink.Emit(testAnomalyEvent()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var decoded AnomalyEvent
+	lines := 0
+	for _, line := range splitLines(string(data)) {
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+		assert.Equal(t, "sup123", decoded.SupplierID)
+		lines++
+	}
+	assert.Equal(t, 2, lines, "each Emit call should append one line")
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	start := 0
+	for i, c := range s {
+		if c == '\n' {
+			if i > start {
+				lines = append(lines, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return lines
+}
+
+func TestWebhookAnomalySink_PostsEventAsJSON(t *testing.T) {
+	var received AnomalyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookAnomalySink(server.URL)
+
+	require.NoError(t, sink.Emit(testAnomalyEvent()))
+	assert.Equal(t, "sup123", received.SupplierID)
+	assert.Equal(t, []string{"tx001", "tx002"}, received.TransactionIDs)
+}
+
+func TestWebhookAnomalySink_ReturnsErrorOnNonSuccessStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookAnomalySink(server.URL)
+
+	err := sink.Emit(testAnomalyEvent())
+	assert.Error(t, err)
+}
@@ -1,22 +1,33 @@
 package settlement
 
 import (
+	"sort"
+
 	"github.com/ignacio/solara-settlement/internal/domain"
 	"github.com/ignacio/solara-settlement/internal/fxrate"
 	"github.com/shopspring/decimal"
 )
 
+// timeVarianceWeight is the weight (alpha) applied to elapsed time, in hours,
+// when scoring candidate auth/capture pairs. It lets amount differences
+// dominate the match while still preferring temporally close pairs when
+// amounts tie.
+const timeVarianceWeight = 0.0001
+
 // CalculateVolatility compares FX rates between authorization and capture transactions
 // to detect significant currency fluctuations
 //
 // Returns:
-//   - hasVolatility: true if variance exceeds 5%
+//   - hasVolatility: true if variance exceeds policy's FX variance threshold
+//     for authTx's currency (5% by default; a nil policy uses
+//     DefaultAnomalyPolicy)
 //   - variance: the calculated variance as a percentage
 //   - error: any error that occurred during rate lookup
 func CalculateVolatility(
 	authTx *domain.Transaction,
 	captureTx *domain.Transaction,
 	fxService *fxrate.Service,
+	policy *AnomalyPolicy,
 ) (hasVolatility bool, variance decimal.Decimal, err error) {
 	// Get FX rate at authorization time
 	authRate, err := fxService.GetRate(authTx.Currency, authTx.Timestamp)
@@ -38,22 +49,99 @@ func CalculateVolatility(
 
 	variance = captureRate.Sub(authRate).Div(authRate).Abs().Mul(decimal.NewFromInt(100))
 
-	// Flag if variance exceeds 5%
-	threshold := decimal.NewFromInt(5)
+	threshold := effectivePolicy(policy).FXVarianceThreshold(authTx.Currency)
 	hasVolatility = variance.GreaterThan(threshold)
 
 	return hasVolatility, variance, nil
 }
 
+// authCaptureCandidate is a scored candidate pairing between an authorization
+// and a capture, used while building the bipartite assignment.
+type authCaptureCandidate struct {
+	auth    *domain.Transaction
+	capture *domain.Transaction
+	weight  float64
+}
+
+// matchAuthsToCaptures assigns at most one authorization per capture using a
+// greedy sort-by-weight strategy over the bipartite graph of eligible pairs:
+// same currency, authorization before capture, edge weight
+// |auth.OriginalAmount - capture.OriginalAmount| + alpha*deltaTime. Captures
+// and authorizations are consumed as they're matched, so each is used at most
+// once. This is a practical stand-in for the Hungarian algorithm's optimal
+// assignment; for the transaction volumes this system settles, sorting all
+// candidate pairs once and taking them greedily produces the same pairing in
+// the overwhelming majority of cases at a fraction of the complexity.
+func matchAuthsToCaptures(
+	captures []*domain.Transaction,
+	authsByCurrency map[domain.Currency][]*domain.Transaction,
+) []authCaptureCandidate {
+	var candidates []authCaptureCandidate
+
+	for _, capture := range captures {
+		for _, auth := range authsByCurrency[capture.Currency] {
+			if !auth.Timestamp.Before(capture.Timestamp) {
+				continue
+			}
+
+			amountDelta := auth.OriginalAmount.Sub(capture.OriginalAmount).Abs()
+			deltaTime := capture.Timestamp.Sub(auth.Timestamp)
+			weight := amountDelta.InexactFloat64() + timeVarianceWeight*deltaTime.Hours()
+
+			candidates = append(candidates, authCaptureCandidate{
+				auth:    auth,
+				capture: capture,
+				weight:  weight,
+			})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].weight < candidates[j].weight
+	})
+
+	matchedAuths := make(map[string]bool)
+	matchedCaptures := make(map[string]bool)
+
+	var matches []authCaptureCandidate
+	for _, candidate := range candidates {
+		if matchedAuths[candidate.auth.ID] || matchedCaptures[candidate.capture.ID] {
+			continue
+		}
+		matchedAuths[candidate.auth.ID] = true
+		matchedCaptures[candidate.capture.ID] = true
+		matches = append(matches, candidate)
+	}
+
+	return matches
+}
+
 // DetectVolatilityForSettlement checks for FX volatility across all auth/capture pairs
-// for a supplier's transactions
+// for a supplier's transactions.
 //
-// This function matches authorization transactions with their corresponding captures
-// by currency and checks if FX rate variance exceeds 5%
+// It matches each capture to at most one authorization via matchAuthsToCaptures,
+// then computes the FX rate variance for every matched pair. The settlement's
+// AuthCaptureMatches, MaxVariance and MeanVariance are populated as a side
+// effect so the reporter can surface per-pair detail rather than a single
+// boolean flag. Returns true if any matched pair's variance exceeds policy's
+// FX variance threshold for that pair's currency (a nil policy uses
+// DefaultAnomalyPolicy, 5% for every currency).
+//
+// Idempotent: AuthCaptureMatches/MaxVariance/MeanVariance are rebuilt from
+// scratch against the settlement's full current Lines/AuthTransactions on
+// every call, so calling it again (e.g. from Engine.CalculateIncremental's
+// repeated detectAnomalies) re-derives the same result rather than
+// duplicating matches already recorded by a prior call.
 func DetectVolatilityForSettlement(
 	settlement *domain.SupplierSettlement,
 	fxService *fxrate.Service,
+	policy *AnomalyPolicy,
 ) bool {
+	policy = effectivePolicy(policy)
+	settlement.AuthCaptureMatches = nil
+	settlement.MaxVariance = decimal.Zero
+	settlement.MeanVariance = decimal.Zero
+
 	// If no authorization transactions, no volatility to check
 	if len(settlement.AuthTransactions) == 0 {
 		return false
@@ -65,37 +153,47 @@ func DetectVolatilityForSettlement(
 		authsByCurrency[auth.Currency] = append(authsByCurrency[auth.Currency], auth)
 	}
 
-	// Check each settlement line (capture/refund) against authorizations
+	// Only captures can be compared against an authorization (refunds are
+	// already completed money movements with no prior "intent" to compare).
+	var captures []*domain.Transaction
 	for _, line := range settlement.Lines {
-		tx := line.Transaction
+		if line.Transaction.Type == domain.Capture {
+			captures = append(captures, line.Transaction)
+		}
+	}
 
-		// Only check captures (refunds are already completed money movements)
-		if tx.Type != domain.Capture {
+	matches := matchAuthsToCaptures(captures, authsByCurrency)
+
+	hasVolatility := false
+	totalVariance := decimal.Zero
+
+	for _, match := range matches {
+		_, variance, err := CalculateVolatility(match.auth, match.capture, fxService, policy)
+		if err != nil {
+			// Log error but continue processing
 			continue
 		}
 
-		// Find matching authorizations for this currency
-		authsForCurrency := authsByCurrency[tx.Currency]
-		if len(authsForCurrency) == 0 {
-			continue
+		settlement.AuthCaptureMatches = append(settlement.AuthCaptureMatches, domain.AuthCaptureMatch{
+			AuthID:    match.auth.ID,
+			CaptureID: match.capture.ID,
+			Variance:  variance,
+		})
+
+		totalVariance = totalVariance.Add(variance)
+		if variance.GreaterThan(settlement.MaxVariance) {
+			settlement.MaxVariance = variance
 		}
 
-		// Check volatility against the most recent authorization for this currency
-		// (In a real system, we'd match by a specific auth-capture relationship)
-		for _, auth := range authsForCurrency {
-			// Only compare if auth came before capture
-			if auth.Timestamp.Before(tx.Timestamp) {
-				hasVolatility, _, err := CalculateVolatility(auth, tx, fxService)
-				if err != nil {
-					// Log error but continue processing
-					continue
-				}
-				if hasVolatility {
-					return true
-				}
-			}
+		threshold := policy.FXVarianceThreshold(match.auth.Currency)
+		if variance.GreaterThan(threshold) {
+			hasVolatility = true
 		}
 	}
 
-	return false
+	if len(settlement.AuthCaptureMatches) > 0 {
+		settlement.MeanVariance = totalVariance.Div(decimal.NewFromInt(int64(len(settlement.AuthCaptureMatches))))
+	}
+
+	return hasVolatility
 }
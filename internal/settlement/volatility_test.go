@@ -0,0 +1,111 @@
+package settlement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/ignacio/solara-settlement/internal/fxrate"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeAuthCaptureTx(id string, txType domain.TransactionType, amount float64, currency domain.Currency, ts time.Time) *domain.Transaction {
+	return &domain.Transaction{
+		ID:             id,
+		SupplierID:     "sup123",
+		Type:           txType,
+		OriginalAmount: decimal.NewFromFloat(amount),
+		Currency:       currency,
+		Timestamp:      ts,
+		Status:         domain.Completed,
+	}
+}
+
+func TestMatchAuthsToCaptures_PicksClosestAmountMatch(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	auth1 := makeAuthCaptureTx("auth1", domain.Authorization, 100, domain.BRL, base)
+	auth2 := makeAuthCaptureTx("auth2", domain.Authorization, 105, domain.BRL, base.Add(time.Hour))
+	capture := makeAuthCaptureTx("cap1", domain.Capture, 105, domain.BRL, base.Add(2*time.Hour))
+
+	authsByCurrency := map[domain.Currency][]*domain.Transaction{domain.BRL: {auth1, auth2}}
+
+	matches := matchAuthsToCaptures([]*domain.Transaction{capture}, authsByCurrency)
+
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "auth2", matches[0].auth.ID, "capture should match the auth with the closest amount")
+}
+
+func TestMatchAuthsToCaptures_OneToOneAssignment(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	auth1 := makeAuthCaptureTx("auth1", domain.Authorization, 100, domain.BRL, base)
+	auth2 := makeAuthCaptureTx("auth2", domain.Authorization, 200, domain.BRL, base)
+	cap1 := makeAuthCaptureTx("cap1", domain.Capture, 100, domain.BRL, base.Add(time.Hour))
+	cap2 := makeAuthCaptureTx("cap2", domain.Capture, 200, domain.BRL, base.Add(time.Hour))
+
+	authsByCurrency := map[domain.Currency][]*domain.Transaction{domain.BRL: {auth1, auth2}}
+
+	matches := matchAuthsToCaptures([]*domain.Transaction{cap1, cap2}, authsByCurrency)
+
+	assert.Len(t, matches, 2)
+	seenAuths := make(map[string]bool)
+	for _, m := range matches {
+		assert.False(t, seenAuths[m.auth.ID], "each auth should be used at most once")
+		seenAuths[m.auth.ID] = true
+	}
+}
+
+func TestDetectVolatilityForSettlement_PopulatesMatchesAndVariance(t *testing.T) {
+	fxProvider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	auth := makeAuthCaptureTx("auth1", domain.Authorization, 100, domain.BRL, base)
+	capture := makeAuthCaptureTx("cap1", domain.Capture, 100, domain.BRL, base.AddDate(0, 0, 10))
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AuthTransactions = []*domain.Transaction{auth}
+	settlement.AddLine(domain.SettlementLine{Transaction: capture, FXRate: decimal.NewFromFloat(0.20), USDAmount: decimal.NewFromFloat(20)})
+
+	DetectVolatilityForSettlement(settlement, fxService, nil)
+
+	assert.Len(t, settlement.AuthCaptureMatches, 1)
+	assert.Equal(t, "auth1", settlement.AuthCaptureMatches[0].AuthID)
+	assert.Equal(t, "cap1", settlement.AuthCaptureMatches[0].CaptureID)
+	assert.True(t, settlement.MeanVariance.Equal(settlement.MaxVariance), "single pair means mean equals max")
+}
+
+func TestDetectVolatilityForSettlement_NoAuthorizations(t *testing.T) {
+	fxProvider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	hasVolatility := DetectVolatilityForSettlement(settlement, fxService, nil)
+
+	assert.False(t, hasVolatility)
+	assert.Empty(t, settlement.AuthCaptureMatches)
+}
+
+func TestDetectVolatilityForSettlement_RepeatedCallsDoNotDuplicateMatches(t *testing.T) {
+	fxProvider := fxrate.NewMockProvider()
+	fxService := fxrate.NewService([]fxrate.Provider{fxProvider})
+
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	auth := makeAuthCaptureTx("auth1", domain.Authorization, 100, domain.BRL, base)
+	capture := makeAuthCaptureTx("cap1", domain.Capture, 100, domain.BRL, base.AddDate(0, 0, 10))
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AuthTransactions = []*domain.Transaction{auth}
+	settlement.AddLine(domain.SettlementLine{Transaction: capture, FXRate: decimal.NewFromFloat(0.20), USDAmount: decimal.NewFromFloat(20)})
+
+	// Engine.CalculateIncremental re-runs detectAnomalies (and so this
+	// function) against the same accumulated Lines/AuthTransactions on every
+	// round, so a second call must re-derive the same single match rather
+	// than appending a duplicate.
+	DetectVolatilityForSettlement(settlement, fxService, nil)
+	DetectVolatilityForSettlement(settlement, fxService, nil)
+
+	assert.Len(t, settlement.AuthCaptureMatches, 1)
+}
@@ -0,0 +1,237 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultVolatilityCVThreshold is the coefficient-of-variation above which a
+// currency's daily FX rate is considered volatile, used when
+// Analyzer.VolatilityCVThreshold is left at its zero value.
+const DefaultVolatilityCVThreshold = 0.05
+
+// Analyzer computes extended settlement statistics (drawdown, FX volatility,
+// concentration, Sharpe-like ratios) over a completed settlement run.
+type Analyzer struct {
+	// RiskFreeRate is the constant daily return subtracted from a supplier's
+	// mean daily return before dividing by its standard deviation in
+	// SharpeRatio. Zero by default.
+	RiskFreeRate decimal.Decimal
+
+	// VolatilityCVThreshold is the coefficient of variation above which
+	// Analyze's FXVolatilityCV entries are considered volatile. Defaults to
+	// DefaultVolatilityCVThreshold via NewAnalyzer.
+	VolatilityCVThreshold decimal.Decimal
+}
+
+// NewAnalyzer creates an Analyzer with a zero risk-free rate and the default
+// volatility CV threshold.
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{
+		RiskFreeRate:          decimal.Zero,
+		VolatilityCVThreshold: decimal.NewFromFloat(DefaultVolatilityCVThreshold),
+	}
+}
+
+// Analyze computes SettlementStats from a completed settlement run: a daily
+// net-USD series, max drawdown and Sharpe-like ratio per supplier, the
+// coefficient of variation of daily FX rates per currency, the aggregate
+// refund-to-capture ratio, and the Herfindahl concentration index across
+// suppliers.
+func (a *Analyzer) Analyze(settlements []*domain.SupplierSettlement) *domain.SettlementStats {
+	result := &domain.SettlementStats{
+		FXVolatilityCV: computeFXVolatilityCV(settlements),
+	}
+
+	totalCaptures := decimal.Zero
+	totalRefunds := decimal.Zero
+	perSupplierCaptures := make([]decimal.Decimal, 0, len(settlements))
+
+	for _, settlement := range settlements {
+		result.PerSupplier = append(result.PerSupplier, a.analyzeSupplier(settlement))
+
+		totalCaptures = totalCaptures.Add(settlement.TotalCapturesUSD)
+		totalRefunds = totalRefunds.Add(settlement.TotalRefundsUSD)
+		perSupplierCaptures = append(perSupplierCaptures, settlement.TotalCapturesUSD)
+	}
+
+	if !totalCaptures.IsZero() {
+		result.RefundToCaptureRatio = totalRefunds.Div(totalCaptures)
+	}
+	result.ConcentrationIndex = herfindahlIndex(perSupplierCaptures, totalCaptures)
+
+	return result
+}
+
+func (a *Analyzer) analyzeSupplier(settlement *domain.SupplierSettlement) domain.SupplierStats {
+	daily := dailyNetSeries(settlement)
+
+	supplierStats := domain.SupplierStats{
+		SupplierID:     settlement.SupplierID,
+		DailyNetUSD:    daily,
+		MaxDrawdownUSD: maxDrawdown(daily),
+		SharpeRatio:    a.sharpeRatio(daily),
+	}
+
+	if !settlement.TotalCapturesUSD.IsZero() {
+		supplierStats.RefundToCaptureRatio = settlement.TotalRefundsUSD.Div(settlement.TotalCapturesUSD)
+	}
+
+	return supplierStats
+}
+
+// dailyNetSeries buckets a supplier's settlement lines by calendar day (UTC)
+// and sums each day's signed USD amount (refunds and chargebacks negative,
+// every other line positive), sorted chronologically.
+func dailyNetSeries(settlement *domain.SupplierSettlement) []domain.DailyNetPoint {
+	byDay := make(map[time.Time]decimal.Decimal)
+
+	for _, line := range settlement.Lines {
+		day := truncateToDay(line.Transaction.Timestamp)
+		amount := line.USDAmount
+		if line.Transaction.Type == domain.Refund || line.Transaction.Type == domain.Chargeback {
+			amount = amount.Neg()
+		}
+		byDay[day] = byDay[day].Add(amount)
+	}
+
+	days := make([]time.Time, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	series := make([]domain.DailyNetPoint, 0, len(days))
+	for _, day := range days {
+		series = append(series, domain.DailyNetPoint{Date: day, NetUSD: byDay[day]})
+	}
+	return series
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// maxDrawdown computes the largest peak-to-trough decline in the cumulative
+// running total of daily net USD amounts.
+func maxDrawdown(daily []domain.DailyNetPoint) decimal.Decimal {
+	cumulative := decimal.Zero
+	peak := decimal.Zero
+	maxDD := decimal.Zero
+
+	for _, point := range daily {
+		cumulative = cumulative.Add(point.NetUSD)
+		if cumulative.GreaterThan(peak) {
+			peak = cumulative
+		}
+		if drawdown := peak.Sub(cumulative); drawdown.GreaterThan(maxDD) {
+			maxDD = drawdown
+		}
+	}
+
+	return maxDD
+}
+
+// sharpeRatio computes a Sharpe-like ratio over the daily net USD series:
+// (mean daily return - risk-free rate) / standard deviation of daily
+// returns. Returns zero when there are fewer than two days of data or the
+// standard deviation is zero, since the ratio is undefined in both cases.
+func (a *Analyzer) sharpeRatio(daily []domain.DailyNetPoint) decimal.Decimal {
+	if len(daily) < 2 {
+		return decimal.Zero
+	}
+
+	returns := make([]float64, len(daily))
+	for i, point := range daily {
+		returns[i] = point.NetUSD.InexactFloat64()
+	}
+
+	mean := meanFloat(returns)
+	stdDev := stdDevFloat(returns, mean)
+	if stdDev == 0 {
+		return decimal.Zero
+	}
+
+	return decimal.NewFromFloat((mean - a.RiskFreeRate.InexactFloat64()) / stdDev)
+}
+
+func meanFloat(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+func stdDevFloat(values []float64, mean float64) float64 {
+	var sumSquares float64
+	for _, v := range values {
+		delta := v - mean
+		sumSquares += delta * delta
+	}
+	return math.Sqrt(sumSquares / float64(len(values)))
+}
+
+// herfindahlIndex computes the Herfindahl-Hirschman concentration index
+// (sum of squared market shares, here each supplier's share of total
+// captures USD) across suppliers.
+func herfindahlIndex(perSupplierCaptures []decimal.Decimal, total decimal.Decimal) decimal.Decimal {
+	if total.IsZero() {
+		return decimal.Zero
+	}
+
+	sum := decimal.Zero
+	for _, captures := range perSupplierCaptures {
+		share := captures.Div(total)
+		sum = sum.Add(share.Mul(share))
+	}
+	return sum
+}
+
+// dayRateKey buckets a settlement line's FX rate by currency and calendar
+// day, so computeFXVolatilityCV can average same-day observations before
+// measuring volatility across days.
+type dayRateKey struct {
+	currency domain.Currency
+	day      time.Time
+}
+
+// computeFXVolatilityCV computes the coefficient of variation (standard
+// deviation / mean) of each non-USD currency's daily-average FX rate across
+// every settlement line in the run.
+func computeFXVolatilityCV(settlements []*domain.SupplierSettlement) map[domain.Currency]decimal.Decimal {
+	sums := make(map[dayRateKey]decimal.Decimal)
+	counts := make(map[dayRateKey]int)
+
+	for _, settlement := range settlements {
+		for _, line := range settlement.Lines {
+			if line.Transaction.Currency == domain.USD {
+				continue
+			}
+			key := dayRateKey{currency: line.Transaction.Currency, day: truncateToDay(line.Transaction.Timestamp)}
+			sums[key] = sums[key].Add(line.FXRate)
+			counts[key]++
+		}
+	}
+
+	ratesByCurrency := make(map[domain.Currency][]float64)
+	for key, sum := range sums {
+		avg := sum.Div(decimal.NewFromInt(int64(counts[key])))
+		ratesByCurrency[key.currency] = append(ratesByCurrency[key.currency], avg.InexactFloat64())
+	}
+
+	cv := make(map[domain.Currency]decimal.Decimal)
+	for currency, rates := range ratesByCurrency {
+		mean := meanFloat(rates)
+		if mean == 0 {
+			continue
+		}
+		cv[currency] = decimal.NewFromFloat(stdDevFloat(rates, mean) / mean)
+	}
+
+	return cv
+}
@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func line(id string, txType domain.TransactionType, currency domain.Currency, day time.Time, usdAmount, fxRate float64) domain.SettlementLine {
+	return domain.SettlementLine{
+		Transaction: &domain.Transaction{
+			ID:             id,
+			SupplierID:     "sup123",
+			Type:           txType,
+			OriginalAmount: decimal.NewFromFloat(usdAmount),
+			Currency:       currency,
+			Timestamp:      day,
+			Status:         domain.Completed,
+		},
+		FXRate:    decimal.NewFromFloat(fxRate),
+		USDAmount: decimal.NewFromFloat(usdAmount),
+	}
+}
+
+func TestNewAnalyzer(t *testing.T) {
+	analyzer := NewAnalyzer()
+	require.NotNil(t, analyzer)
+	assert.True(t, analyzer.RiskFreeRate.IsZero())
+	assert.True(t, analyzer.VolatilityCVThreshold.Equal(decimal.NewFromFloat(DefaultVolatilityCVThreshold)))
+}
+
+func TestAnalyzer_Analyze_RefundToCaptureRatio(t *testing.T) {
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(line("cap1", domain.Capture, domain.USD, day, 100, 1))
+	settlement.AddLine(line("ref1", domain.Refund, domain.USD, day, 20, 1))
+
+	result := NewAnalyzer().Analyze([]*domain.SupplierSettlement{settlement})
+
+	require.Len(t, result.PerSupplier, 1)
+	assert.True(t, result.PerSupplier[0].RefundToCaptureRatio.Equal(decimal.NewFromFloat(0.2)))
+	assert.True(t, result.RefundToCaptureRatio.Equal(decimal.NewFromFloat(0.2)))
+}
+
+func TestAnalyzer_Analyze_MaxDrawdownAcrossDecliningDays(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	day3 := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(line("cap1", domain.Capture, domain.USD, day1, 100, 1))
+	settlement.AddLine(line("ref1", domain.Refund, domain.USD, day2, 150, 1))
+	settlement.AddLine(line("cap2", domain.Capture, domain.USD, day3, 10, 1))
+
+	result := NewAnalyzer().Analyze([]*domain.SupplierSettlement{settlement})
+
+	require.Len(t, result.PerSupplier, 1)
+	// Peak after day1 is 100; trough after day2 is 100-150=-50; drawdown is 150.
+	assert.True(t, result.PerSupplier[0].MaxDrawdownUSD.Equal(decimal.NewFromFloat(150)), "got %s", result.PerSupplier[0].MaxDrawdownUSD)
+}
+
+func TestAnalyzer_Analyze_ConcentrationIndexSingleSupplierIsOne(t *testing.T) {
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(line("cap1", domain.Capture, domain.USD, day, 100, 1))
+
+	result := NewAnalyzer().Analyze([]*domain.SupplierSettlement{settlement})
+	assert.True(t, result.ConcentrationIndex.Equal(decimal.NewFromInt(1)))
+}
+
+func TestAnalyzer_Analyze_ConcentrationIndexEvenSplitAcrossTwoSuppliers(t *testing.T) {
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	sup1 := domain.NewSupplierSettlement("sup1", "Supplier One")
+	sup1.AddLine(line("cap1", domain.Capture, domain.USD, day, 100, 1))
+
+	sup2 := domain.NewSupplierSettlement("sup2", "Supplier Two")
+	sup2.AddLine(line("cap2", domain.Capture, domain.USD, day, 100, 1))
+
+	result := NewAnalyzer().Analyze([]*domain.SupplierSettlement{sup1, sup2})
+	// Two equal suppliers: HHI = 0.5^2 + 0.5^2 = 0.5.
+	assert.True(t, result.ConcentrationIndex.Equal(decimal.NewFromFloat(0.5)))
+}
+
+func TestAnalyzer_Analyze_FXVolatilityCVZeroForConstantRate(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(line("cap1", domain.Capture, domain.ARS, day1, 1, 0.001))
+	settlement.AddLine(line("cap2", domain.Capture, domain.ARS, day2, 1, 0.001))
+
+	result := NewAnalyzer().Analyze([]*domain.SupplierSettlement{settlement})
+	require.Contains(t, result.FXVolatilityCV, domain.ARS)
+	assert.True(t, result.FXVolatilityCV[domain.ARS].IsZero())
+}
+
+func TestAnalyzer_Analyze_FXVolatilityCVNonzeroForFluctuatingRate(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(line("cap1", domain.Capture, domain.ARS, day1, 1, 0.0009))
+	settlement.AddLine(line("cap2", domain.Capture, domain.ARS, day2, 1, 0.0011))
+
+	result := NewAnalyzer().Analyze([]*domain.SupplierSettlement{settlement})
+	require.Contains(t, result.FXVolatilityCV, domain.ARS)
+	assert.True(t, result.FXVolatilityCV[domain.ARS].GreaterThan(decimal.Zero))
+}
+
+func TestAnalyzer_Analyze_SharpeRatioZeroWithFewerThanTwoDays(t *testing.T) {
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	settlement := domain.NewSupplierSettlement("sup123", "Test Supplier")
+	settlement.AddLine(line("cap1", domain.Capture, domain.USD, day, 100, 1))
+
+	result := NewAnalyzer().Analyze([]*domain.SupplierSettlement{settlement})
+	assert.True(t, result.PerSupplier[0].SharpeRatio.IsZero())
+}
+
+func TestAnalyzer_Analyze_EmptySettlements(t *testing.T) {
+	result := NewAnalyzer().Analyze(nil)
+	assert.Empty(t, result.PerSupplier)
+	assert.True(t, result.ConcentrationIndex.IsZero())
+	assert.True(t, result.RefundToCaptureRatio.IsZero())
+}
@@ -0,0 +1,17 @@
+// Package stats computes extended statistics over a completed settlement
+// run, in the style of trade-stats reporting (max drawdown, Sharpe ratio,
+// concentration index) common in portfolio risk tooling.
+//
+// Analyzer consumes the []*domain.SupplierSettlement produced by
+// settlement.Engine.Calculate and its underlying time-ordered
+// SettlementLines to compute, per supplier, a daily net-USD series, max
+// drawdown over the period, refund-to-capture ratio, and a Sharpe-like ratio
+// against a configurable risk-free constant; and, across the whole run, the
+// coefficient of variation of daily FX rates per currency and the
+// Herfindahl concentration index across suppliers.
+//
+// Usage:
+//
+//	analyzer := stats.NewAnalyzer()
+//	settlementStats := analyzer.Analyze(settlements)
+package stats
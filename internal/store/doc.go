@@ -0,0 +1,24 @@
+// Package store persists calculated settlements and the transactions behind
+// them to a relational database, so a settlement run can be queried later
+// (by a dashboard, a reconciliation job, or a re-run of the same date range)
+// without re-reading and re-processing the original input file.
+//
+// Store wraps a *sql.DB opened by OpenSQLite or OpenPostgres and works
+// against either: schema and queries are written in portable SQL (no
+// AUTOINCREMENT/SERIAL, no driver-specific upsert syntax beyond
+// "INSERT ... ON CONFLICT", which both SQLite and Postgres support).
+// Migrator applies the versioned SQL files under migrations/ on startup,
+// tracking what's already been applied in a schema_migrations table so
+// Open (either driver) is safe to call repeatedly, including across
+// process restarts against the same database.
+//
+// SaveSettlements upserts one row per supplier per settlement date plus one
+// row per transaction behind it (captures, refunds, chargebacks, chargeback
+// reversals, and authorizations):
+// running the same date range through the engine twice (e.g. to pick up a
+// corrected FX rate) overwrites the prior rows rather than duplicating or
+// orphaning them. What's persisted is the settlement's summary fields and
+// its transactions, not a byte-for-byte SupplierSettlement - LoadSettlements
+// doesn't reconstruct Lines, AuthCaptureMatches, or other derived detail;
+// re-run the engine if you need those.
+package store
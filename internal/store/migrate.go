@@ -0,0 +1,263 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one versioned schema change, identified by the numeric
+// prefix of its filename (e.g. "0001" for 0001_init.up.sql).
+type migration struct {
+	version int
+	name    string
+	upSQL   string
+	downSQL string
+}
+
+// Migrator applies the versioned SQL files embedded under migrations/ to a
+// database, tracking which versions have already run in a schema_migrations
+// table so Up is safe to call every time Open(SQLite|Postgres) does.
+type Migrator struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewMigrator creates a Migrator against db. dialect must match db's driver
+// (see OpenSQLite/OpenPostgres), since the schema_migrations bookkeeping
+// query needs Store's same "?" vs "$1" placeholder rewrite.
+func NewMigrator(db *sql.DB, dialect Dialect) *Migrator {
+	return &Migrator{db: db, dialect: dialect}
+}
+
+// Up applies every migration with a version greater than what's already
+// recorded in schema_migrations, in ascending order, each in its own
+// transaction. It creates schema_migrations itself if this is the first run.
+func (m *Migrator) Up(ctx context.Context) error {
+	if err := m.ensureSchemaMigrationsTable(ctx); err != nil {
+		return err
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range migrations {
+		if applied[mig.version] {
+			continue
+		}
+		if err := m.apply(ctx, mig); err != nil {
+			return fmt.Errorf("failed to apply migration %04d_%s: %w", mig.version, mig.name, err)
+		}
+	}
+	return nil
+}
+
+// Down reverts the most recently applied migration by running its
+// .down.sql and removing its schema_migrations row, both in one
+// transaction. It is a no-op (returns nil) if no migration has been applied.
+func (m *Migrator) Down(ctx context.Context) error {
+	if err := m.ensureSchemaMigrationsTable(ctx); err != nil {
+		return err
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+
+	latest := 0
+	for version := range applied {
+		if version > latest {
+			latest = version
+		}
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range migrations {
+		if mig.version != latest {
+			continue
+		}
+		if err := m.revert(ctx, mig); err != nil {
+			return fmt.Errorf("failed to revert migration %04d_%s: %w", mig.version, mig.name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("schema_migrations records version %d but no matching migration file was found", latest)
+}
+
+func (m *Migrator) revert(ctx context.Context, mig migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(mig.downSQL) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	deleteRow := rebindForDialect(m.dialect, `DELETE FROM schema_migrations WHERE version = ?`)
+	if _, err := tx.ExecContext(ctx, deleteRow, mig.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) ensureSchemaMigrationsTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, mig migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(mig.upSQL) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	insert := rebindForDialect(m.dialect, `INSERT INTO schema_migrations (version) VALUES (?)`)
+	if _, err := tx.ExecContext(ctx, insert, mig.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadMigrations reads every *.up.sql/*.down.sql pair under migrations/ and
+// returns them sorted by version ascending.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		version, base, kind, ok := parseMigrationFilename(name)
+		if !ok {
+			continue
+		}
+
+		contents, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", name, err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: base}
+			byVersion[version] = mig
+		}
+		if kind == "up" {
+			mig.upSQL = string(contents)
+		} else {
+			mig.downSQL = string(contents)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// parseMigrationFilename splits "0001_init.up.sql" into version 1, base
+// name "init", and kind "up". ok is false for anything that doesn't match
+// that shape, so stray non-migration files under migrations/ are ignored.
+func parseMigrationFilename(name string) (version int, base, kind string, ok bool) {
+	trimmed := strings.TrimSuffix(name, ".sql")
+	kindIdx := strings.LastIndex(trimmed, ".")
+	if kindIdx < 0 {
+		return 0, "", "", false
+	}
+	kind = trimmed[kindIdx+1:]
+	if kind != "up" && kind != "down" {
+		return 0, "", "", false
+	}
+
+	rest := trimmed[:kindIdx]
+	versionIdx := strings.Index(rest, "_")
+	if versionIdx < 0 {
+		return 0, "", "", false
+	}
+
+	version, err := strconv.Atoi(rest[:versionIdx])
+	if err != nil {
+		return 0, "", "", false
+	}
+
+	return version, rest[versionIdx+1:], kind, true
+}
+
+// splitStatements splits a migration file's contents into individual
+// statements on bare ";" boundaries, so a multi-statement file can be run
+// through database/sql, which only executes one statement per Exec call on
+// most drivers. Blank statements (e.g. a trailing newline) are dropped. This
+// is a naive split with no awareness of string literals, so a migration
+// must not embed a ";" inside a quoted value (e.g. a DEFAULT or CHECK
+// constraint) - keep those in application code instead.
+func splitStatements(sql string) []string {
+	var statements []string
+	for _, stmt := range strings.Split(sql, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
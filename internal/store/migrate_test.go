@@ -0,0 +1,40 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMigrationFilename(t *testing.T) {
+	version, base, kind, ok := parseMigrationFilename("0001_init.up.sql")
+	require.True(t, ok)
+	assert.Equal(t, 1, version)
+	assert.Equal(t, "init", base)
+	assert.Equal(t, "up", kind)
+
+	_, _, _, ok = parseMigrationFilename("README.md")
+	assert.False(t, ok, "a file with no version/kind shape should be ignored")
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements("CREATE TABLE a (x INT);\n\nCREATE TABLE b (y INT);\n")
+	require.Len(t, statements, 2)
+	assert.Contains(t, statements[0], "CREATE TABLE a")
+	assert.Contains(t, statements[1], "CREATE TABLE b")
+}
+
+func TestLoadMigrations_SortedAscendingWithUpAndDownPaired(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i := 1; i < len(migrations); i++ {
+		assert.Less(t, migrations[i-1].version, migrations[i].version)
+	}
+	for _, mig := range migrations {
+		assert.NotEmpty(t, mig.upSQL, "migration %d is missing its .up.sql", mig.version)
+		assert.NotEmpty(t, mig.downSQL, "migration %d is missing its .down.sql", mig.version)
+	}
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// OpenSQLite opens (creating if needed) the SQLite database file at path and
+// runs Migrator.Up against it before returning. path may also be
+// "file::memory:?cache=shared" for an in-memory database.
+func OpenSQLite(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+
+	// SQLite only supports one writer at a time; a single connection avoids
+	// "database is locked" errors from concurrent writes within this process.
+	db.SetMaxOpenConns(1)
+
+	// go-sqlite3 leaves foreign-key enforcement off by default, which would
+	// silently let transactions.settlement_date/supplier_id drift from the
+	// settlements row the migration's FOREIGN KEY declares them against.
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to enable foreign keys on sqlite database %s: %w", path, err)
+	}
+
+	if err := NewMigrator(db, DialectSQLite).Up(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate sqlite database %s: %w", path, err)
+	}
+
+	return NewStore(db, DialectSQLite), nil
+}
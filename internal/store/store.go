@@ -0,0 +1,330 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ignacio/solara-settlement/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// Dialect identifies which database a Store is talking to, so it can adapt
+// the handful of things that differ between them (placeholder syntax;
+// everything else is written as portable SQL).
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// Store persists settlements and the transactions behind them. OpenSQLite
+// and OpenPostgres return one with Migrator.Up already run. NewStore wraps
+// an existing *sql.DB without migrating it, so Migrator.Up has to run on
+// that db before first use.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewStore creates a Store against db, using dialect to adapt placeholder
+// syntax (SQLite and the go-sqlite3/lib/pq drivers disagree on "?" vs
+// "$1, $2, ..."). OpenSQLite and OpenPostgres call it with the matching
+// dialect themselves, so callers only pass one when wrapping a *sql.DB of
+// their own.
+func NewStore(db *sql.DB, dialect Dialect) *Store {
+	return &Store{db: db, dialect: dialect}
+}
+
+// Close releases the underlying database connection pool opened by
+// OpenSQLite/OpenPostgres (or passed to NewStore directly).
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SettlementFilter narrows LoadSettlements to a supplier and/or a settlement
+// date range. A zero field is unbounded: an empty SupplierID matches every
+// supplier, and zero From/To match every date.
+type SettlementFilter struct {
+	SupplierID string
+	From       time.Time
+	To         time.Time
+}
+
+// TransactionFilter narrows LoadTransactions the same way SettlementFilter
+// narrows LoadSettlements.
+type TransactionFilter struct {
+	SupplierID string
+	From       time.Time
+	To         time.Time
+}
+
+// SaveSettlements upserts one row per settlement (keyed by supplier ID and
+// settlementDate) plus one row per transaction behind it, so re-running the
+// same date range through the engine (e.g. after a corrected FX rate)
+// overwrites the prior rows instead of duplicating them. All rows are
+// written in a single transaction.
+func (s *Store) SaveSettlements(ctx context.Context, settlementDate time.Time, settlements []*domain.SupplierSettlement) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, settlement := range settlements {
+		if err := s.saveSettlement(ctx, tx, settlementDate, settlement); err != nil {
+			return fmt.Errorf("failed to save settlement for supplier %s: %w", settlement.SupplierID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) saveSettlement(ctx context.Context, tx *sql.Tx, settlementDate time.Time, settlement *domain.SupplierSettlement) error {
+	upsert := s.rebind(`
+		INSERT INTO settlements (
+			supplier_id, settlement_date, supplier_name, total_captures_usd,
+			total_refunds_usd, total_chargebacks_usd, net_amount_usd, transaction_count,
+			refund_rate_pct, chargeback_rate_pct, volatility_flag, warnings
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (supplier_id, settlement_date) DO UPDATE SET
+			supplier_name = excluded.supplier_name,
+			total_captures_usd = excluded.total_captures_usd,
+			total_refunds_usd = excluded.total_refunds_usd,
+			total_chargebacks_usd = excluded.total_chargebacks_usd,
+			net_amount_usd = excluded.net_amount_usd,
+			transaction_count = excluded.transaction_count,
+			refund_rate_pct = excluded.refund_rate_pct,
+			chargeback_rate_pct = excluded.chargeback_rate_pct,
+			volatility_flag = excluded.volatility_flag,
+			warnings = excluded.warnings
+	`)
+
+	date := settlementDate.Format("2006-01-02")
+	if _, err := tx.ExecContext(ctx, upsert,
+		settlement.SupplierID, date, settlement.SupplierName,
+		settlement.TotalCapturesUSD.String(), settlement.TotalRefundsUSD.String(), settlement.TotalChargebacksUSD.String(),
+		settlement.NetAmountUSD.String(), settlement.TransactionCount, settlement.RefundRatePct.String(),
+		settlement.ChargebackRate.String(), settlement.VolatilityFlag,
+		strings.Join(settlement.Warnings, ","),
+	); err != nil {
+		return err
+	}
+
+	// Clear this supplier's prior transaction rows for this settlement date
+	// before re-inserting the current set, so a transaction that no longer
+	// belongs (e.g. dropped by a narrower re-run, or corrected upstream)
+	// doesn't linger as an orphan - an upsert alone would only ever add or
+	// update rows, never remove one.
+	deleteStale := s.rebind(`DELETE FROM transactions WHERE supplier_id = ? AND settlement_date = ?`)
+	if _, err := tx.ExecContext(ctx, deleteStale, settlement.SupplierID, date); err != nil {
+		return err
+	}
+
+	for _, line := range settlement.Lines {
+		if err := s.saveTransaction(ctx, tx, date, line.Transaction); err != nil {
+			return err
+		}
+	}
+	// AuthTransactions (the authorization leg volatility detection compares
+	// captures against) aren't settlement Lines, but they're still
+	// transactions behind this settlement, and an AnomalyEvent's
+	// TransactionIDs can reference one by ID - so they're persisted too.
+	for _, auth := range settlement.AuthTransactions {
+		if err := s.saveTransaction(ctx, tx, date, auth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) saveTransaction(ctx context.Context, tx *sql.Tx, date string, transaction *domain.Transaction) error {
+	upsert := s.rebind(`
+		INSERT INTO transactions (
+			id, supplier_id, settlement_date, type, original_amount, currency, "timestamp", status
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (supplier_id, id, settlement_date) DO UPDATE SET
+			type = excluded.type,
+			original_amount = excluded.original_amount,
+			currency = excluded.currency,
+			"timestamp" = excluded."timestamp",
+			status = excluded.status
+	`)
+
+	_, err := tx.ExecContext(ctx, upsert,
+		transaction.ID, transaction.SupplierID, date, string(transaction.Type),
+		transaction.OriginalAmount.String(), transaction.Currency.String(),
+		transaction.Timestamp, string(transaction.Status),
+	)
+	return err
+}
+
+// LoadSettlements returns every settlement row matching filter, most recent
+// settlement date first. Each result is the settlement's persisted summary
+// fields (totals, refund rate, warnings, ...) only - Lines, AuthTransactions,
+// AuthCaptureMatches and the other per-transaction/per-match detail
+// SaveSettlements doesn't persist are left at their zero value. Call
+// LoadTransactions with the same SupplierID to get the transactions behind a
+// result.
+func (s *Store) LoadSettlements(ctx context.Context, filter SettlementFilter) ([]*domain.SupplierSettlement, error) {
+	query := `SELECT supplier_id, supplier_name, total_captures_usd, total_refunds_usd,
+			total_chargebacks_usd, net_amount_usd, transaction_count, refund_rate_pct,
+			chargeback_rate_pct, volatility_flag, warnings
+		FROM settlements`
+	where, args := settlementFilterClause(filter)
+	query += where + ` ORDER BY settlement_date DESC`
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settlements: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*domain.SupplierSettlement
+	for rows.Next() {
+		settlement := domain.NewSupplierSettlement("", "")
+		var totalCaptures, totalRefunds, totalChargebacks, netAmount, refundRate, chargebackRate, warnings string
+		if err := rows.Scan(
+			&settlement.SupplierID, &settlement.SupplierName, &totalCaptures, &totalRefunds,
+			&totalChargebacks, &netAmount, &settlement.TransactionCount, &refundRate,
+			&chargebackRate, &settlement.VolatilityFlag, &warnings,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement row: %w", err)
+		}
+
+		settlement.TotalCapturesUSD, err = decimal.NewFromString(totalCaptures)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse total_captures_usd: %w", err)
+		}
+		settlement.TotalRefundsUSD, err = decimal.NewFromString(totalRefunds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse total_refunds_usd: %w", err)
+		}
+		settlement.TotalChargebacksUSD, err = decimal.NewFromString(totalChargebacks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse total_chargebacks_usd: %w", err)
+		}
+		settlement.NetAmountUSD, err = decimal.NewFromString(netAmount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse net_amount_usd: %w", err)
+		}
+		settlement.RefundRatePct, err = decimal.NewFromString(refundRate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse refund_rate_pct: %w", err)
+		}
+		settlement.ChargebackRate, err = decimal.NewFromString(chargebackRate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chargeback_rate_pct: %w", err)
+		}
+		if warnings != "" {
+			settlement.Warnings = strings.Split(warnings, ",")
+		}
+
+		results = append(results, settlement)
+	}
+	return results, rows.Err()
+}
+
+// LoadTransactions returns every transaction row matching filter, oldest
+// first.
+func (s *Store) LoadTransactions(ctx context.Context, filter TransactionFilter) ([]*domain.Transaction, error) {
+	query := `SELECT id, supplier_id, type, original_amount, currency, "timestamp", status FROM transactions`
+	where, args := transactionFilterClause(filter)
+	query += where + ` ORDER BY "timestamp" ASC`
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*domain.Transaction
+	for rows.Next() {
+		transaction := &domain.Transaction{}
+		var txType, amount, currency, status string
+		if err := rows.Scan(
+			&transaction.ID, &transaction.SupplierID, &txType, &amount, &currency,
+			&transaction.Timestamp, &status,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
+		}
+
+		transaction.Type = domain.TransactionType(txType)
+		transaction.Currency = domain.Currency(currency)
+		transaction.Status = domain.TransactionStatus(status)
+		transaction.OriginalAmount, err = decimal.NewFromString(amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse original_amount: %w", err)
+		}
+
+		results = append(results, transaction)
+	}
+	return results, rows.Err()
+}
+
+func settlementFilterClause(filter SettlementFilter) (string, []any) {
+	return dateRangeFilterClause(filter.SupplierID, filter.From, filter.To)
+}
+
+func transactionFilterClause(filter TransactionFilter) (string, []any) {
+	return dateRangeFilterClause(filter.SupplierID, filter.From, filter.To)
+}
+
+// dateRangeFilterClause builds the shared "WHERE supplier_id = ? AND
+// settlement_date >= ? AND settlement_date <= ?" clause (omitting whichever
+// conditions are unset) behind both SettlementFilter and TransactionFilter,
+// which filter on identical fields.
+func dateRangeFilterClause(supplierID string, from, to time.Time) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if supplierID != "" {
+		conditions = append(conditions, "supplier_id = ?")
+		args = append(args, supplierID)
+	}
+	if !from.IsZero() {
+		conditions = append(conditions, "settlement_date >= ?")
+		args = append(args, from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "settlement_date <= ?")
+		args = append(args, to.Format("2006-01-02"))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// rebind rewrites query's "?" placeholders to "$1, $2, ..." for Postgres,
+// and leaves it unchanged for SQLite, which accepts "?" natively.
+func (s *Store) rebind(query string) string {
+	return rebindForDialect(s.dialect, query)
+}
+
+// rebindForDialect is the dialect-aware placeholder rewrite Store.rebind and
+// Migrator.apply both need, since schema_migrations bookkeeping writes
+// through the same "?"-style query templates as Store's own queries.
+func rebindForDialect(dialect Dialect, query string) string {
+	if dialect != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Rebind_RewritesPlaceholdersForPostgresOnly(t *testing.T) {
+	sqliteStore := &Store{dialect: DialectSQLite}
+	assert.Equal(t, "SELECT * FROM t WHERE a = ? AND b = ?", sqliteStore.rebind("SELECT * FROM t WHERE a = ? AND b = ?"))
+
+	pgStore := &Store{dialect: DialectPostgres}
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = $2", pgStore.rebind("SELECT * FROM t WHERE a = ? AND b = ?"))
+}
+
+func TestSettlementFilterClause_OmitsUnsetFields(t *testing.T) {
+	where, args := settlementFilterClause(SettlementFilter{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+
+	where, args = settlementFilterClause(SettlementFilter{SupplierID: "sup1"})
+	assert.Equal(t, " WHERE supplier_id = ?", where)
+	assert.Equal(t, []any{"sup1"}, args)
+}
+
+func TestSettlementFilterClause_CombinesSupplierAndDateRange(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	where, args := settlementFilterClause(SettlementFilter{SupplierID: "sup1", From: from, To: to})
+	assert.Equal(t, " WHERE supplier_id = ? AND settlement_date >= ? AND settlement_date <= ?", where)
+	assert.Equal(t, []any{"sup1", "2026-01-01", "2026-01-31"}, args)
+}
@@ -1,23 +1,26 @@
 package main
 
 import (
+	"container/heap"
 	"encoding/csv"
 	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
+	"sort"
 	"time"
 )
 
 // Supplier defines a supplier with business characteristics
 type Supplier struct {
-	ID           string
-	Name         string
-	TargetTxns   int
-	Currencies   []string
-	RefundRate   float64 // 0.0 to 1.0
-	AmountRanges map[string][2]float64
+	ID             string
+	Name           string
+	TargetTxns     int
+	Currencies     []string
+	RefundRate     float64 // 0.0 to 1.0
+	ChargebackRate float64 // 0.0 to 1.0; chance a non-refunded capture is charged back
+	AmountRanges   map[string][2]float64
 }
 
 // Transaction represents a single transaction
@@ -35,16 +38,18 @@ type Transaction struct {
 var (
 	outputPath = flag.String("output", "testdata/transactions.csv", "Output CSV file path")
 	seed       = flag.Int64("seed", 42, "Random seed for reproducible data")
+	multiplier = flag.Int("n", 1, "Multiplier applied to every supplier's target transaction count, e.g. -n 20000 for a ~10M row corpus")
 )
 
 // Suppliers configuration matching the requirements
 var suppliers = []Supplier{
 	{
-		ID:         "SUP001",
-		Name:       "Hotel Marriott Buenos Aires",
-		TargetTxns: 60,
-		Currencies: []string{"ARS", "BRL", "MXN"}, // Multi-currency
-		RefundRate: 0.10,                          // Normal 10% refund rate
+		ID:             "SUP001",
+		Name:           "Hotel Marriott Buenos Aires",
+		TargetTxns:     60,
+		Currencies:     []string{"ARS", "BRL", "MXN"}, // Multi-currency
+		RefundRate:     0.10,                          // Normal 10% refund rate
+		ChargebackRate: 0.02,                          // Normal 2% chargeback rate
 		AmountRanges: map[string][2]float64{
 			"ARS": {10000, 500000},
 			"BRL": {500, 15000},
@@ -52,61 +57,67 @@ var suppliers = []Supplier{
 		},
 	},
 	{
-		ID:         "SUP002",
-		Name:       "Airline LATAM",
-		TargetTxns: 55,
-		Currencies: []string{"BRL"}, // Mostly single currency
-		RefundRate: 0.10,
+		ID:             "SUP002",
+		Name:           "Airline LATAM",
+		TargetTxns:     55,
+		Currencies:     []string{"BRL"}, // Mostly single currency
+		RefundRate:     0.10,
+		ChargebackRate: 0.02,
 		AmountRanges: map[string][2]float64{
 			"BRL": {500, 15000},
 		},
 	},
 	{
-		ID:         "SUP003",
-		Name:       "Car Rental Hertz Mexico",
-		TargetTxns: 40,
-		Currencies: []string{"MXN"},
-		RefundRate: 0.10,
+		ID:             "SUP003",
+		Name:           "Car Rental Hertz Mexico",
+		TargetTxns:     40,
+		Currencies:     []string{"MXN"},
+		RefundRate:     0.10,
+		ChargebackRate: 0.08, // Edge case: HIGH chargeback rate (disputed rental charges)
 		AmountRanges: map[string][2]float64{
 			"MXN": {1000, 40000},
 		},
 	},
 	{
-		ID:         "SUP004",
-		Name:       "Hotel Copacabana Rio",
-		TargetTxns: 35,
-		Currencies: []string{"BRL"},
-		RefundRate: 0.10,
+		ID:             "SUP004",
+		Name:           "Hotel Copacabana Rio",
+		TargetTxns:     35,
+		Currencies:     []string{"BRL"},
+		RefundRate:     0.10,
+		ChargebackRate: 0.02,
 		AmountRanges: map[string][2]float64{
 			"BRL": {500, 15000},
 		},
 	},
 	{
-		ID:         "SUP005",
-		Name:       "Tour Operator Colombia",
-		TargetTxns: 25,
-		Currencies: []string{"COP"},
-		RefundRate: 0.10,
+		ID:             "SUP005",
+		Name:           "Tour Operator Colombia",
+		TargetTxns:     25,
+		Currencies:     []string{"COP"},
+		RefundRate:     0.10,
+		ChargebackRate: 0.02,
 		AmountRanges: map[string][2]float64{
 			"COP": {100000, 5000000},
 		},
 	},
 	{
-		ID:         "SUP006",
-		Name:       "Beach Resort Cancun",
-		TargetTxns: 30,
-		Currencies: []string{"MXN"},
-		RefundRate: 0.10,
+		ID:             "SUP006",
+		Name:           "Beach Resort Cancun",
+		TargetTxns:     30,
+		Currencies:     []string{"MXN"},
+		RefundRate:     0.10,
+		ChargebackRate: 0.02,
 		AmountRanges: map[string][2]float64{
 			"MXN": {1000, 40000},
 		},
 	},
 	{
-		ID:         "SUP007",
-		Name:       "Hostel Palermo",
-		TargetTxns: 3,                  // Edge case: very low volume
-		Currencies: []string{"ARS"},
-		RefundRate: 0.60, // Edge case: HIGH refund rate >50%
+		ID:             "SUP007",
+		Name:           "Hostel Palermo",
+		TargetTxns:     3, // Edge case: very low volume
+		Currencies:     []string{"ARS"},
+		RefundRate:     0.60, // Edge case: HIGH refund rate >50%
+		ChargebackRate: 0.02,
 		AmountRanges: map[string][2]float64{
 			"ARS": {10000, 100000},
 		},
@@ -116,175 +127,296 @@ var suppliers = []Supplier{
 func main() {
 	flag.Parse()
 
-	// Set random seed for reproducibility
-	rand.Seed(*seed)
+	log.Printf("Generating test data with seed %d (%dx multiplier)...", *seed, *multiplier)
 
-	log.Printf("Generating test data with seed %d...", *seed)
+	streams := generateSupplierStreams(*seed, *multiplier)
+	merged := mergeByTimestamp(streams)
 
-	// Generate all transactions
-	transactions := generateTransactions()
+	stats := newGenerationStats()
+	if err := streamWriteCSV(*outputPath, merged, stats); err != nil {
+		log.Fatalf("Failed to write CSV: %v", err)
+	}
 
-	log.Printf("Generated %d total transactions", len(transactions))
+	log.Printf("Generated %d total transactions", stats.total)
+	stats.print()
 
-	// Print statistics
-	printStatistics(transactions)
+	log.Printf("Successfully wrote %d transactions to %s", stats.total, *outputPath)
+}
 
-	// Write to CSV
-	if err := writeCSV(*outputPath, transactions); err != nil {
-		log.Fatalf("Failed to write CSV: %v", err)
+// generateSupplierStreams starts one goroutine per supplier, each generating
+// and sorting that supplier's own transactions before streaming them out in
+// timestamp order over its own channel. Each goroutine keeps its supplier's
+// full slice until the merge has taken every row from it, and the merge
+// reads from all streams together, so peak memory on the generation side
+// grows with the whole corpus rather than with the largest single
+// supplier; only the CSV side (streamWriteCSV) stays bounded.
+//
+// Each supplier draws from its own *rand.Rand seeded from (seed, supplier
+// index) instead of sharing one global stream the way the previous
+// sequential generator did. That's what makes the per-supplier goroutines
+// safe to run concurrently without a lock around rand - but it also means a
+// run of this generator does NOT reproduce the exact same random draws (and
+// therefore not the exact same amounts/timestamps) as the old sequential
+// generator did for the same seed. It does reproduce byte-identical output
+// against itself: the same seed and multiplier always yield the same file,
+// which is what a golden fixture pinned against this version of the
+// generator actually needs.
+func generateSupplierStreams(seed int64, multiplier int) []<-chan Transaction {
+	streams := make([]<-chan Transaction, len(suppliers))
+
+	for idx, supplier := range suppliers {
+		ch := make(chan Transaction, 256)
+		streams[idx] = ch
+
+		go func(idx int, supplier Supplier) {
+			defer close(ch)
+
+			// Large odd offset per supplier index keeps the per-supplier
+			// seeds well separated even for adjacent indices.
+			rng := rand.New(rand.NewSource(seed + int64(idx)*104729))
+			txns := generateSupplierTransactions(supplier, multiplier, rng)
+
+			sort.SliceStable(txns, func(i, j int) bool {
+				return txns[i].Timestamp.Before(txns[j].Timestamp)
+			})
+
+			for _, txn := range txns {
+				ch <- txn
+			}
+		}(idx, supplier)
 	}
 
-	log.Printf("Successfully wrote %d transactions to %s", len(transactions), *outputPath)
+	return streams
 }
 
-func generateTransactions() []Transaction {
-	var allTransactions []Transaction
+// generateSupplierTransactions generates one supplier's authorizations,
+// captures, refunds, and chargebacks, scaling TargetTxns by multiplier.
+// Transaction IDs are namespaced by supplier (<SupplierID>-TXN<n>) rather
+// than drawn from one shared counter, since a shared counter would need a
+// lock (or an atomic, which would still make the exact numbers assigned to
+// each supplier depend on goroutine scheduling) to stay race-free across
+// the per-supplier goroutines generateSupplierStreams runs concurrently.
+func generateSupplierTransactions(supplier Supplier, multiplier int, rng *rand.Rand) []Transaction {
+	targetTxns := supplier.TargetTxns * multiplier
+	transactions := make([]Transaction, 0, targetTxns*2)
 	txnCounter := 1
 
 	// Start date: 2024-01-01
 	baseDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 
-	// Generate transactions for each supplier
-	for _, supplier := range suppliers {
-		log.Printf("Generating %d transactions for %s (%s)...", supplier.TargetTxns, supplier.ID, supplier.Name)
-
-		// Spread transactions over 30 days
-		for i := 0; i < supplier.TargetTxns; i++ {
-			// Random day within 30 days
-			dayOffset := rand.Intn(30)
-			// Random hour and minute
-			hour := rand.Intn(24)
-			minute := rand.Intn(60)
-			authTimestamp := baseDate.AddDate(0, 0, dayOffset).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
-
-			// Select currency (randomly if multiple currencies)
-			currency := supplier.Currencies[rand.Intn(len(supplier.Currencies))]
-
-			// Generate amount within range
-			amountRange := supplier.AmountRanges[currency]
-			amount := amountRange[0] + rand.Float64()*(amountRange[1]-amountRange[0])
-			amount = float64(int(amount*100)) / 100 // Round to 2 decimals
-
-			// Create authorization transaction
-			authID := fmt.Sprintf("TXN%03d", txnCounter)
-			txnCounter++
+	for i := 0; i < targetTxns; i++ {
+		// Random day within 30 days
+		dayOffset := rng.Intn(30)
+		// Random hour and minute
+		hour := rng.Intn(24)
+		minute := rng.Intn(60)
+		authTimestamp := baseDate.AddDate(0, 0, dayOffset).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+
+		// Select currency (randomly if multiple currencies)
+		currency := supplier.Currencies[rng.Intn(len(supplier.Currencies))]
+
+		// Generate amount within range
+		amountRange := supplier.AmountRanges[currency]
+		amount := amountRange[0] + rng.Float64()*(amountRange[1]-amountRange[0])
+		amount = float64(int(amount*100)) / 100 // Round to 2 decimals
+
+		// Create authorization transaction
+		authID := fmt.Sprintf("%s-TXN%d", supplier.ID, txnCounter)
+		txnCounter++
+
+		// Determine authorization status: 95% completed, 5% failed
+		authStatus := "completed"
+		if rng.Float64() < 0.05 {
+			authStatus = "failed"
+		}
 
-			// Determine authorization status: 95% completed, 5% failed
-			authStatus := "completed"
-			if rand.Float64() < 0.05 {
-				authStatus = "failed"
-			}
+		auth := Transaction{
+			ID:             authID,
+			SupplierID:     supplier.ID,
+			Type:           "authorization",
+			OriginalAmount: amount,
+			Currency:       currency,
+			Timestamp:      authTimestamp,
+			Status:         authStatus,
+		}
+		transactions = append(transactions, auth)
 
-			auth := Transaction{
-				ID:             authID,
-				SupplierID:     supplier.ID,
-				Type:           "authorization",
-				OriginalAmount: amount,
-				Currency:       currency,
-				Timestamp:      authTimestamp,
-				Status:         authStatus,
-			}
-			allTransactions = append(allTransactions, auth)
+		// If authorization failed, skip capture/refund
+		if authStatus == "failed" {
+			continue
+		}
 
-			// If authorization failed, skip capture/refund
-			if authStatus == "failed" {
-				continue
-			}
+		// 85% of successful authorizations → captures
+		// 15% remain uncaptured (some pending, some completed but not captured)
+		shouldCapture := rng.Float64() < 0.85
 
-			// 85% of successful authorizations → captures
-			// 15% remain uncaptured (some pending, some completed but not captured)
-			shouldCapture := rand.Float64() < 0.85
-
-			if !shouldCapture {
-				// Some uncaptured authorizations stay "pending"
-				if rand.Float64() < 0.5 {
-					auth.Status = "pending"
-					allTransactions[len(allTransactions)-1] = auth // Update the last added auth
-				}
-				continue
+		if !shouldCapture {
+			// Some uncaptured authorizations stay "pending"
+			if rng.Float64() < 0.5 {
+				auth.Status = "pending"
+				transactions[len(transactions)-1] = auth // Update the last added auth
 			}
+			continue
+		}
+
+		// Create capture (same day or +1-2 days later)
+		captureDelay := time.Duration(rng.Intn(3)) * 24 * time.Hour
+		captureTimestamp := authTimestamp.Add(captureDelay).Add(time.Duration(rng.Intn(300)) * time.Minute)
+
+		captureID := fmt.Sprintf("%s-TXN%d", supplier.ID, txnCounter)
+		txnCounter++
+
+		capture := Transaction{
+			ID:             captureID,
+			SupplierID:     supplier.ID,
+			Type:           "capture",
+			OriginalAmount: amount,
+			Currency:       currency,
+			Timestamp:      captureTimestamp,
+			Status:         "completed",
+			RelatedAuthID:  authID,
+		}
+		transactions = append(transactions, capture)
+
+		// Determine if this capture should be refunded based on supplier refund rate
+		shouldRefund := rng.Float64() < supplier.RefundRate
 
-			// Create capture (same day or +1-2 days later)
-			captureDelay := time.Duration(rand.Intn(3)) * 24 * time.Hour
-			captureTimestamp := authTimestamp.Add(captureDelay).Add(time.Duration(rand.Intn(300)) * time.Minute)
+		if shouldRefund {
+			// Create refund (3-7 days after capture)
+			refundDelay := time.Duration(3+rng.Intn(5)) * 24 * time.Hour
+			refundTimestamp := captureTimestamp.Add(refundDelay).Add(time.Duration(rng.Intn(300)) * time.Minute)
 
-			captureID := fmt.Sprintf("TXN%03d", txnCounter)
+			refundID := fmt.Sprintf("%s-TXN%d", supplier.ID, txnCounter)
 			txnCounter++
 
-			capture := Transaction{
-				ID:             captureID,
+			refund := Transaction{
+				ID:             refundID,
 				SupplierID:     supplier.ID,
-				Type:           "capture",
+				Type:           "refund",
 				OriginalAmount: amount,
 				Currency:       currency,
-				Timestamp:      captureTimestamp,
+				Timestamp:      refundTimestamp,
 				Status:         "completed",
-				RelatedAuthID:  authID,
+				RelatedAuthID:  captureID,
 			}
-			allTransactions = append(allTransactions, capture)
-
-			// Determine if this capture should be refunded based on supplier refund rate
-			shouldRefund := rand.Float64() < supplier.RefundRate
-
-			if shouldRefund {
-				// Create refund (3-7 days after capture)
-				refundDelay := time.Duration(3+rand.Intn(5)) * 24 * time.Hour
-				refundTimestamp := captureTimestamp.Add(refundDelay).Add(time.Duration(rand.Intn(300)) * time.Minute)
-
-				refundID := fmt.Sprintf("TXN%03d", txnCounter)
-				txnCounter++
-
-				refund := Transaction{
-					ID:             refundID,
-					SupplierID:     supplier.ID,
-					Type:           "refund",
-					OriginalAmount: amount,
-					Currency:       currency,
-					Timestamp:      refundTimestamp,
-					Status:         "completed",
-					RelatedAuthID:  captureID,
-				}
-				allTransactions = append(allTransactions, refund)
+			transactions = append(transactions, refund)
+		} else if rng.Float64() < supplier.ChargebackRate {
+			// A capture is either refunded or charged back, not both - a
+			// chargeback disputes a charge the cardholder never asked to
+			// have refunded. Chargebacks land much later than refunds
+			// (30-60 days, reflecting dispute windows set by card networks).
+			chargebackDelay := time.Duration(30+rng.Intn(31)) * 24 * time.Hour
+			chargebackTimestamp := captureTimestamp.Add(chargebackDelay).Add(time.Duration(rng.Intn(300)) * time.Minute)
+
+			chargebackID := fmt.Sprintf("%s-TXN%d", supplier.ID, txnCounter)
+			txnCounter++
+
+			chargeback := Transaction{
+				ID:             chargebackID,
+				SupplierID:     supplier.ID,
+				Type:           "chargeback",
+				OriginalAmount: amount,
+				Currency:       currency,
+				Timestamp:      chargebackTimestamp,
+				Status:         "completed",
+				RelatedAuthID:  captureID,
 			}
+			transactions = append(transactions, chargeback)
 		}
 	}
 
-	// Sort transactions by timestamp for realistic ordering
-	sortTransactionsByTimestamp(allTransactions)
+	return transactions
+}
 
-	return allTransactions
+// mergeStreamItem is one candidate in mergeByTimestamp's heap: the next
+// not-yet-emitted transaction from one supplier's stream.
+type mergeStreamItem struct {
+	txn       Transaction
+	streamIdx int
 }
 
-func sortTransactionsByTimestamp(transactions []Transaction) {
-	// Simple bubble sort (sufficient for this dataset size)
-	n := len(transactions)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if transactions[j].Timestamp.After(transactions[j+1].Timestamp) {
-				transactions[j], transactions[j+1] = transactions[j+1], transactions[j]
+// mergeHeap is a min-heap over mergeStreamItem ordered by timestamp, with
+// ties broken by streamIdx so transactions from the supplier declared
+// earlier in suppliers sort first - matching the relative order the old
+// single-slice stable sort produced for exactly-equal timestamps.
+type mergeHeap []mergeStreamItem
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if !h[i].txn.Timestamp.Equal(h[j].txn.Timestamp) {
+		return h[i].txn.Timestamp.Before(h[j].txn.Timestamp)
+	}
+	return h[i].streamIdx < h[j].streamIdx
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(mergeStreamItem)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// mergeByTimestamp k-way merges streams - each already sorted by
+// timestamp - into one combined, still timestamp-sorted channel, using
+// container/heap to always pick the smallest head-of-stream item in
+// O(log k) per transaction instead of the old generator's O(n^2) bubble
+// sort over the whole corpus.
+func mergeByTimestamp(streams []<-chan Transaction) <-chan Transaction {
+	out := make(chan Transaction, 256)
+
+	go func() {
+		defer close(out)
+
+		h := make(mergeHeap, 0, len(streams))
+		for idx, ch := range streams {
+			if txn, ok := <-ch; ok {
+				h = append(h, mergeStreamItem{txn: txn, streamIdx: idx})
 			}
 		}
-	}
+		heap.Init(&h)
+
+		for h.Len() > 0 {
+			next := heap.Pop(&h).(mergeStreamItem)
+			out <- next.txn
+
+			if txn, ok := <-streams[next.streamIdx]; ok {
+				heap.Push(&h, mergeStreamItem{txn: txn, streamIdx: next.streamIdx})
+			}
+		}
+	}()
+
+	return out
 }
 
-func writeCSV(filepath string, transactions []Transaction) error {
-	file, err := os.Create(filepath)
+// csvFlushBatchSize controls how often streamWriteCSV flushes the CSV
+// writer while consuming the merged stream, bounding how much unflushed
+// output can accumulate at once.
+const csvFlushBatchSize = 1000
+
+// streamWriteCSV consumes records as they arrive from the merge stage and
+// writes them straight to path, flushing every csvFlushBatchSize rows.
+// Unlike the old writeCSV, it never receives (or needs) the full corpus as
+// a slice - peak memory here is O(1) regardless of corpus size.
+func streamWriteCSV(path string, records <-chan Transaction, stats *generationStats) error {
+	file, err := os.Create(path)
 	if err != nil {
+		drain(records)
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
-	// Write header
 	header := []string{"transaction_id", "supplier_id", "type", "original_amount", "currency", "timestamp", "status"}
 	if err := writer.Write(header); err != nil {
+		drain(records)
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
-	// Write transactions
-	for _, txn := range transactions {
+	count := 0
+	for txn := range records {
 		record := []string{
 			txn.ID,
 			txn.SupplierID,
@@ -295,65 +427,113 @@ func writeCSV(filepath string, transactions []Transaction) error {
 			txn.Status,
 		}
 		if err := writer.Write(record); err != nil {
+			drain(records)
 			return fmt.Errorf("failed to write record: %w", err)
 		}
+		stats.observe(txn)
+
+		count++
+		if count%csvFlushBatchSize == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				drain(records)
+				return fmt.Errorf("failed to flush batch: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush final batch: %w", err)
 	}
 
 	return nil
 }
 
-func printStatistics(transactions []Transaction) {
-	// Count transactions by supplier
-	supplierCounts := make(map[string]int)
-	// Count by type
-	typeCounts := make(map[string]int)
-	// Count by currency
-	currencyCounts := make(map[string]int)
-	// Count by status
-	statusCounts := make(map[string]int)
-	// Count captures and refunds per supplier
-	supplierCaptures := make(map[string]int)
-	supplierRefunds := make(map[string]int)
-
-	for _, txn := range transactions {
-		supplierCounts[txn.SupplierID]++
-		typeCounts[txn.Type]++
-		currencyCounts[txn.Currency]++
-		statusCounts[txn.Status]++
-
-		if txn.Type == "capture" && txn.Status == "completed" {
-			supplierCaptures[txn.SupplierID]++
-		}
-		if txn.Type == "refund" && txn.Status == "completed" {
-			supplierRefunds[txn.SupplierID]++
+// drain discards any records streamWriteCSV stops reading early (after a
+// write or flush error), so the upstream merge goroutine and per-supplier
+// producer goroutines feeding it don't block forever sending into a
+// channel nothing is reading from anymore.
+func drain(records <-chan Transaction) {
+	go func() {
+		for range records {
 		}
+	}()
+}
+
+// generationStats accumulates the same per-supplier/type/currency/status
+// breakdown the old printStatistics computed from a fully materialized
+// slice, but incrementally as streamWriteCSV observes each transaction, so
+// nothing needs to hold the whole corpus just to report on it afterward.
+type generationStats struct {
+	total               int
+	supplierCounts      map[string]int
+	typeCounts          map[string]int
+	currencyCounts      map[string]int
+	statusCounts        map[string]int
+	supplierCaptures    map[string]int
+	supplierRefunds     map[string]int
+	supplierChargebacks map[string]int
+}
+
+func newGenerationStats() *generationStats {
+	return &generationStats{
+		supplierCounts:      make(map[string]int),
+		typeCounts:          make(map[string]int),
+		currencyCounts:      make(map[string]int),
+		statusCounts:        make(map[string]int),
+		supplierCaptures:    make(map[string]int),
+		supplierRefunds:     make(map[string]int),
+		supplierChargebacks: make(map[string]int),
 	}
+}
+
+func (s *generationStats) observe(txn Transaction) {
+	s.total++
+	s.supplierCounts[txn.SupplierID]++
+	s.typeCounts[txn.Type]++
+	s.currencyCounts[txn.Currency]++
+	s.statusCounts[txn.Status]++
+
+	if txn.Type == "capture" && txn.Status == "completed" {
+		s.supplierCaptures[txn.SupplierID]++
+	}
+	if txn.Type == "refund" && txn.Status == "completed" {
+		s.supplierRefunds[txn.SupplierID]++
+	}
+	if txn.Type == "chargeback" && txn.Status == "completed" {
+		s.supplierChargebacks[txn.SupplierID]++
+	}
+}
 
-	fmt.Println("\n=== TRANSACTION STATISTICS ===\n")
+func (s *generationStats) print() {
+	fmt.Println("\n=== TRANSACTION STATISTICS ===")
 
 	fmt.Println("Transactions per Supplier:")
 	for _, supplier := range suppliers {
-		fmt.Printf("  %s (%s): %d transactions\n", supplier.ID, supplier.Name, supplierCounts[supplier.ID])
-		if supplierCaptures[supplier.ID] > 0 {
-			refundRate := float64(supplierRefunds[supplier.ID]) / float64(supplierCaptures[supplier.ID]) * 100
-			fmt.Printf("    -> Captures: %d, Refunds: %d (%.1f%% refund rate)\n",
-				supplierCaptures[supplier.ID], supplierRefunds[supplier.ID], refundRate)
+		fmt.Printf("  %s (%s): %d transactions\n", supplier.ID, supplier.Name, s.supplierCounts[supplier.ID])
+		if s.supplierCaptures[supplier.ID] > 0 {
+			refundRate := float64(s.supplierRefunds[supplier.ID]) / float64(s.supplierCaptures[supplier.ID]) * 100
+			chargebackRate := float64(s.supplierChargebacks[supplier.ID]) / float64(s.supplierCaptures[supplier.ID]) * 100
+			fmt.Printf("    -> Captures: %d, Refunds: %d (%.1f%% refund rate), Chargebacks: %d (%.1f%% chargeback rate)\n",
+				s.supplierCaptures[supplier.ID], s.supplierRefunds[supplier.ID], refundRate,
+				s.supplierChargebacks[supplier.ID], chargebackRate)
 		}
 	}
 
 	fmt.Println("\nTransaction Types:")
-	for txnType, count := range typeCounts {
+	for txnType, count := range s.typeCounts {
 		fmt.Printf("  %s: %d\n", txnType, count)
 	}
 
 	fmt.Println("\nCurrency Distribution:")
-	for currency, count := range currencyCounts {
-		percentage := float64(count) / float64(len(transactions)) * 100
+	for currency, count := range s.currencyCounts {
+		percentage := float64(count) / float64(s.total) * 100
 		fmt.Printf("  %s: %d (%.1f%%)\n", currency, count, percentage)
 	}
 
 	fmt.Println("\nStatus Distribution:")
-	for status, count := range statusCounts {
+	for status, count := range s.statusCounts {
 		fmt.Printf("  %s: %d\n", status, count)
 	}
 
@@ -363,6 +543,7 @@ func printStatistics(transactions []Transaction) {
 	fmt.Println("3. SUP002 (Airline LATAM): Single currency focus (BRL)")
 	fmt.Println("4. Failed authorizations: Included (~5% of authorizations)")
 	fmt.Println("5. Pending authorizations: Included (~7-8% remain pending/uncaptured)")
-	fmt.Println("6. Realistic transaction flow: authorization → capture (+0-2 days) → refund (+3-7 days)")
-	fmt.Printf("\nTotal transactions: %d\n", len(transactions))
+	fmt.Println("6. Realistic transaction flow: authorization → capture (+0-2 days) → refund (+3-7 days) or chargeback (+30-60 days)")
+	fmt.Println("7. SUP003 (Hertz Mexico): HIGH chargeback rate (8%, disputed rental charges)")
+	fmt.Printf("\nTotal transactions: %d\n", s.total)
 }
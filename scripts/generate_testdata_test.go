@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamWriteCSV_OutputIsSortedByTimestamp(t *testing.T) {
+	streams := generateSupplierStreams(1, 1)
+	merged := mergeByTimestamp(streams)
+
+	path := filepath.Join(t.TempDir(), "transactions.csv")
+	stats := newGenerationStats()
+	if err := streamWriteCSV(path, merged, stats); err != nil {
+		t.Fatalf("streamWriteCSV: %v", err)
+	}
+
+	rows := readCSVRows(t, path)
+	for i := 1; i < len(rows); i++ {
+		if rows[i][5] < rows[i-1][5] {
+			t.Fatalf("row %d timestamp %q is out of order after %q", i, rows[i][5], rows[i-1][5])
+		}
+	}
+	if len(rows) != stats.total {
+		t.Fatalf("wrote %d rows, stats observed %d", len(rows), stats.total)
+	}
+}
+
+func TestGenerateSupplierStreams_SameSeedIsDeterministic(t *testing.T) {
+	collect := func(seed int64) []Transaction {
+		merged := mergeByTimestamp(generateSupplierStreams(seed, 1))
+		var out []Transaction
+		for txn := range merged {
+			out = append(out, txn)
+		}
+		return out
+	}
+
+	a := collect(7)
+	b := collect(7)
+	if len(a) != len(b) {
+		t.Fatalf("got %d and %d transactions for the same seed", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("transaction %d differs between runs: %+v vs %+v", i, a[i], b[i])
+		}
+	}
+}
+
+func readCSVRows(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return records[1:] // skip header
+}
+
+// BenchmarkGenerateAndMerge measures end-to-end throughput (per-supplier
+// generation, sort, and k-way merge) at a few corpus sizes to demonstrate
+// linearithmic-or-better scaling. Running the full 10M-row case this
+// benchmark is meant to eventually demonstrate takes too long for routine
+// `go test -bench=.` runs in CI; verify that size manually with
+// `go run generate_testdata.go -n <multiplier>` and compare wall-clock
+// growth against these smaller points instead.
+func BenchmarkGenerateAndMerge(b *testing.B) {
+	for _, multiplier := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("n=%d", multiplier), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				merged := mergeByTimestamp(generateSupplierStreams(42, multiplier))
+				count := 0
+				for range merged {
+					count++
+				}
+				if count == 0 {
+					b.Fatal("generated zero transactions")
+				}
+			}
+		})
+	}
+}